@@ -17,6 +17,7 @@
  
 cd $GOPATH/src
 protoc \
+${GOPATH}/src/github.com/GoogleCloudPlatform/open-match/api/protobuf-spec/admin.proto \
 ${GOPATH}/src/github.com/GoogleCloudPlatform/open-match/api/protobuf-spec/backend.proto \
 ${GOPATH}/src/github.com/GoogleCloudPlatform/open-match/api/protobuf-spec/frontend.proto \
 ${GOPATH}/src/github.com/GoogleCloudPlatform/open-match/api/protobuf-spec/function.proto \
@@ -0,0 +1,28 @@
+/*
+This application serves the built-in evaluator as the Evaluator gRPC service
+defined in ${OM_ROOT}/api/protobuf-spec/evaluator.proto.  The MMForc calls it
+when evaluator.runner is set to 'grpc' in the config.
+
+Copyright 2019 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"github.com/GoogleCloudPlatform/open-match/internal/app/evaluatorapi"
+)
+
+func main() {
+	evaluatorapi.RunApplication()
+}
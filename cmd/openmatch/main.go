@@ -0,0 +1,42 @@
+/*
+The openmatch command is a single binary for working with Open Match during
+development.  Currently it has one subcommand:
+
+	openmatch dev [flags]
+
+which runs the Frontend, Backend and MMLogic APIs, the matchmaker function
+orchestrator and an evaluator in one process, with in-memory state storage.
+Run 'openmatch dev -h' for the list of flags.
+
+All the actual important bits are in internal/app/dev.
+
+Copyright 2019 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/GoogleCloudPlatform/open-match/internal/app/dev"
+)
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "dev" {
+		fmt.Fprintln(os.Stderr, "usage: openmatch dev [flags]")
+		os.Exit(2)
+	}
+	dev.RunApplication(os.Args[2:])
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2019 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apisrv
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage"
+	log "github.com/sirupsen/logrus"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// adminAPI implements the Admin API, which is served alongside the Backend
+// API on the same gRPC server.
+type adminAPI BackendAPI
+
+// ListIndices is this service's implementation of the ListIndices gRPC method
+// defined in api/protobuf-spec/admin.proto
+func (s *adminAPI) ListIndices(ctx context.Context, _ *pb.IndexList) (*pb.IndexList, error) {
+	// Create context for tagging OpenCensus metrics.
+	funcName := "ListIndices"
+	fnCtx, _ := tag.New(ctx, tag.Insert(KeyMethod, funcName))
+
+	entries, err := s.store.RetrieveIndexRegistry(ctx)
+	if err != nil {
+		beLog.WithFields(log.Fields{"error": err.Error(), "funcName": funcName}).Error("State storage error")
+		stats.Record(fnCtx, BeGrpcErrors.M(1))
+		return &pb.IndexList{}, status.Error(codes.Unavailable, err.Error())
+	}
+
+	stats.Record(fnCtx, BeGrpcRequests.M(1))
+	return &pb.IndexList{Indices: entries}, nil
+}
+
+// AddIndex is this service's implementation of the AddIndex gRPC method
+// defined in api/protobuf-spec/admin.proto
+func (s *adminAPI) AddIndex(ctx context.Context, entry *pb.PlayerIndex) (*pb.Result, error) {
+	// Create context for tagging OpenCensus metrics.
+	funcName := "AddIndex"
+	fnCtx, _ := tag.New(ctx, tag.Insert(KeyMethod, funcName))
+
+	aiLog := beLog.WithFields(log.Fields{"attribute": entry.Attribute, "type": entry.Type, "funcName": funcName})
+	index, err := statestorage.IndexFromPB(entry)
+	if err != nil {
+		stats.Record(fnCtx, BeGrpcErrors.M(1))
+		return &pb.Result{Success: false, Error: err.Error()}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	switch {
+	case entry.Attribute == "":
+		err = errors.New("index has no attribute")
+	case strings.HasPrefix(entry.Attribute, "OM_METADATA"):
+		err = errors.New("metadata indices can't be registered")
+	}
+	if err != nil {
+		stats.Record(fnCtx, BeGrpcErrors.M(1))
+		return &pb.Result{Success: false, Error: err.Error()}, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := statestorage.AddIndex(ctx, s.cfg, s.store, index); err != nil {
+		code := codes.Unknown
+		if err == statestorage.ErrIndexTypeConflict {
+			code = codes.FailedPrecondition
+		}
+		aiLog.WithFields(log.Fields{"error": err.Error()}).Error("Failed to add index")
+		stats.Record(fnCtx, BeGrpcErrors.M(1))
+		return &pb.Result{Success: false, Error: err.Error()}, status.Error(code, err.Error())
+	}
+
+	aiLog.Info("Index added")
+	stats.Record(fnCtx, BeGrpcRequests.M(1))
+	return &pb.Result{Success: true, Error: ""}, nil
+}
+
+// RetireIndex is this service's implementation of the RetireIndex gRPC
+// method defined in api/protobuf-spec/admin.proto
+func (s *adminAPI) RetireIndex(ctx context.Context, entry *pb.PlayerIndex) (*pb.Result, error) {
+	// Create context for tagging OpenCensus metrics.
+	funcName := "RetireIndex"
+	fnCtx, _ := tag.New(ctx, tag.Insert(KeyMethod, funcName))
+
+	riLog := beLog.WithFields(log.Fields{"attribute": entry.Attribute, "funcName": funcName})
+	err := statestorage.RetireIndex(ctx, s.cfg, s.store, entry.Attribute)
+	if err == statestorage.ErrNotFound {
+		stats.Record(fnCtx, BeGrpcErrors.M(1))
+		return &pb.Result{Success: false, Error: "index not found"}, status.Error(codes.NotFound, "index not found")
+	}
+	if err != nil {
+		riLog.WithFields(log.Fields{"error": err.Error()}).Error("Failed to retire index")
+		stats.Record(fnCtx, BeGrpcErrors.M(1))
+		return &pb.Result{Success: false, Error: err.Error()}, status.Error(codes.Unknown, err.Error())
+	}
+
+	riLog.Info("Index retired")
+	stats.Record(fnCtx, BeGrpcRequests.M(1))
+	return &pb.Result{Success: true, Error: ""}, nil
+}
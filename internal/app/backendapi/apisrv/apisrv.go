@@ -22,16 +22,17 @@ package apisrv
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/GoogleCloudPlatform/open-match/internal/expbo"
 	"github.com/GoogleCloudPlatform/open-match/internal/metrics"
 	"github.com/GoogleCloudPlatform/open-match/internal/pb"
-	redishelpers "github.com/GoogleCloudPlatform/open-match/internal/statestorage/redis"
-	"github.com/GoogleCloudPlatform/open-match/internal/statestorage/redis/ignorelist"
-	"github.com/GoogleCloudPlatform/open-match/internal/statestorage/redis/redispb"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage"
 	"github.com/cenkalti/backoff"
 	"github.com/gogo/protobuf/jsonpb"
 	"github.com/gogo/protobuf/proto"
@@ -42,7 +43,6 @@ import (
 
 	"github.com/tidwall/gjson"
 
-	"github.com/gomodule/redigo/redis"
 	"github.com/rs/xid"
 	"github.com/spf13/viper"
 
@@ -63,24 +63,25 @@ var (
 // BackendAPI implements backend API Server, the server generated by compiling
 // the protobuf, by fulfilling the API Client interface.
 type BackendAPI struct {
-	grpc *grpc.Server
-	cfg  *viper.Viper
-	pool *redis.Pool
+	grpc  *grpc.Server
+	cfg   *viper.Viper
+	store statestorage.Service
 }
 type backendAPI BackendAPI
 
 // New returns an instantiated srvice
-func New(cfg *viper.Viper, pool *redis.Pool) *BackendAPI {
+func New(cfg *viper.Viper, store statestorage.Service) *BackendAPI {
 	s := BackendAPI{
-		pool: pool,
-		grpc: grpc.NewServer(grpc.StatsHandler(&ocgrpc.ServerHandler{})),
-		cfg:  cfg,
+		store: store,
+		grpc:  grpc.NewServer(grpc.StatsHandler(&ocgrpc.ServerHandler{})),
+		cfg:   cfg,
 	}
 
 	// Add a hook to the logger to auto-count log lines for metrics output thru OpenCensus
 	log.AddHook(metrics.NewHook(BeLogLines, KeySeverity))
 
 	pb.RegisterBackendServer(s.grpc, (*backendAPI)(&s))
+	pb.RegisterAdminServer(s.grpc, (*adminAPI)(&s))
 	beLog.Info("Successfully registered gRPC server")
 	return &s
 }
@@ -98,6 +99,9 @@ func (s *BackendAPI) Open() error {
 
 	beLog.WithFields(log.Fields{"port": s.cfg.GetInt("api.backend.port")}).Info("TCP net listener initialized")
 
+	// Keep this API's player indices in sync with the index registry.
+	go statestorage.WatchIndices(context.Background(), s.cfg, s.store)
+
 	go func() {
 		err := s.grpc.Serve(ln)
 		if err != nil {
@@ -121,6 +125,41 @@ func (s *backendAPI) CreateMatch(c context.Context, profile *pb.MatchObject) (*p
 	funcName := "CreateMatch"
 	fnCtx, _ := tag.New(ctx, tag.Insert(KeyMethod, funcName))
 
+	requestKey, err := s.queueProfile(ctx, funcName, profile)
+	if err != nil {
+		// Failure! Return empty match object and the error
+		stats.Record(fnCtx, BeGrpcErrors.M(1))
+		return &pb.MatchObject{}, status.Error(codes.Unknown, err.Error())
+	}
+
+	// get and return matchobject, it will be written to the requestKey when the MMF has finished.
+	newMO, err := s.watchResult(ctx, requestKey)
+	if err != nil {
+		stats.Record(fnCtx, BeGrpcRequests.M(1))
+		return newMO, status.Errorf(codes.Unavailable, "Error retrieving matchmaking results from state storage: %s", newMO.Error)
+	}
+
+	// 'ok' was true, so properties should contain the results from redis.
+	// Do basic error checking on the returned JSON
+	if !gjson.Valid(profile.Properties) {
+		newMO.Error = "retreived properties json was malformed"
+	}
+
+	// TODO test that this is the correct condition for an empty error.
+	if newMO.Error != "" {
+		stats.Record(fnCtx, BeGrpcErrors.M(1))
+		return newMO, status.Error(codes.Unknown, newMO.Error)
+	}
+
+	beLog.Info("Matchmaking results received, returning to backend client")
+	stats.Record(fnCtx, BeGrpcRequests.M(1))
+	return newMO, nil
+}
+
+// queueProfile writes the profile to state storage and queues a request for
+// an MMF to fill it.  It returns the match request ID, which is the key the
+// results will be written to.
+func (s *backendAPI) queueProfile(ctx context.Context, funcName string, profile *pb.MatchObject) (string, error) {
 	// Generate a request to fill the profile. Make a unique request ID.
 	moID := xid.New().String()
 	requestKey := moID + "." + profile.Id
@@ -185,33 +224,35 @@ func (s *backendAPI) CreateMatch(c context.Context, profile *pb.MatchObject) (*p
 	beLog.Info(profile)
 
 	// Write profile to state storage
-	err := redispb.MarshalToRedis(ctx, s.pool, profile, s.cfg.GetInt("redis.expirations.matchobject"))
+	err := s.store.CreateMatchObject(ctx, profile)
 	if err != nil {
 		beLog.WithFields(log.Fields{
 			"error":     err.Error(),
 			"component": "statestorage",
 		}).Error("State storage failure to create match profile")
 
-		// Failure! Return empty match object and the error
-		stats.Record(fnCtx, BeGrpcErrors.M(1))
-		return &pb.MatchObject{}, status.Error(codes.Unknown, err.Error())
+		return "", err
 	}
 	beLog.Info("Profile written to state storage")
 
 	// Queue the request ID to be sent to an MMF
-	_, err = redishelpers.Update(ctx, s.pool, s.cfg.GetString("queues.profiles.name"), requestKey)
+	err = s.store.PushQueue(ctx, s.cfg.GetString("queues.profiles.name"), requestKey)
 	if err != nil {
 		beLog.WithFields(log.Fields{
 			"error":     err.Error(),
 			"component": "statestorage",
 		}).Error("State storage failure to queue profile")
 
-		// Failure! Return empty match object and the error
-		stats.Record(fnCtx, BeGrpcErrors.M(1))
-		return &pb.MatchObject{}, status.Error(codes.Unknown, err.Error())
+		return "", err
 	}
 	beLog.Info("Profile added to processing queue")
+	return requestKey, nil
+}
 
+// watchResult waits for the results of a match request to be written to
+// state storage, using the backend backoff settings.  If the results don't
+// arrive in time, the returned match object's error field says why.
+func (s *backendAPI) watchResult(ctx context.Context, requestKey string) (*pb.MatchObject, error) {
 	watcherBO := backoff.NewExponentialBackOff()
 	if err := expbo.UnmarshalExponentialBackOff(s.cfg.GetString("api.backend.backoff"), watcherBO); err != nil {
 		beLog.WithError(err).Warn("Could not parse backoff string, using default backoff parameters for MatchObject watcher")
@@ -219,36 +260,135 @@ func (s *backendAPI) CreateMatch(c context.Context, profile *pb.MatchObject) (*p
 
 	watcherBOCtx := backoff.WithContext(watcherBO, ctx)
 
-	// get and return matchobject, it will be written to the requestKey when the MMF has finished.
-	watchChan := redispb.Watcher(watcherBOCtx, s.pool, pb.MatchObject{Id: requestKey}) // Watcher() runs the appropriate Redis commands.
+	watchChan := s.store.WatchMatchObject(watcherBOCtx, pb.MatchObject{Id: requestKey}) // WatchMatchObject() runs the appropriate state storage commands.
 	newMO, ok := <-watchChan
 	if !ok {
-		// ok is false if watchChan has been closed by redispb.Watcher()
+		// ok is false if watchChan has been closed by WatchMatchObject()
 		// This happens when Watcher stops because of context cancellation or backing off reached time limit
-		stats.Record(fnCtx, BeGrpcRequests.M(1))
 		if watcherBOCtx.Context().Err() != nil {
 			newMO.Error = "channel closed: " + watcherBOCtx.Context().Err().Error()
 		} else {
 			newMO.Error = "channel closed: backoff deadline exceeded"
 		}
-		return &newMO, status.Errorf(codes.Unavailable, "Error retrieving matchmaking results from state storage: %s", newMO.Error)
+		newMO.Id = requestKey
+		return &newMO, errors.New(newMO.Error)
 	}
+	return &newMO, nil
+}
 
-	// 'ok' was true, so properties should contain the results from redis.
-	// Do basic error checking on the returned JSON
-	if !gjson.Valid(profile.Properties) {
-		newMO.Error = "retreived properties json was malformed"
+// RequestMatch is this service's implementation of the RequestMatch gRPC
+// method defined in api/protobuf-spec/backend.proto
+func (s *backendAPI) RequestMatch(ctx context.Context, profile *pb.MatchObject) (*pb.MatchObject, error) {
+
+	// Create context for tagging OpenCensus metrics.
+	funcName := "RequestMatch"
+	fnCtx, _ := tag.New(ctx, tag.Insert(KeyMethod, funcName))
+
+	requestKey, err := s.queueProfile(ctx, funcName, profile)
+	if err != nil {
+		stats.Record(fnCtx, BeGrpcErrors.M(1))
+		return &pb.MatchObject{}, status.Error(codes.Unknown, err.Error())
 	}
 
-	// TODO test that this is the correct condition for an empty error.
-	if newMO.Error != "" {
+	stats.Record(fnCtx, BeGrpcRequests.M(1))
+	return &pb.MatchObject{Id: requestKey}, nil
+}
+
+// GetMatchResult is this service's implementation of the GetMatchResult gRPC
+// method defined in api/protobuf-spec/backend.proto
+func (s *backendAPI) GetMatchResult(ctx context.Context, mo *pb.MatchObject) (*pb.MatchObject, error) {
+
+	// Create context for tagging OpenCensus metrics.
+	funcName := "GetMatchResult"
+	fnCtx, _ := tag.New(ctx, tag.Insert(KeyMethod, funcName))
+
+	result := &pb.MatchObject{Id: mo.Id}
+	err := s.store.RetrieveMatchObject(ctx, result)
+	switch {
+	case err == statestorage.ErrNotFound:
+		stats.Record(fnCtx, BeGrpcRequests.M(1))
+		return &pb.MatchObject{Id: mo.Id}, status.Error(codes.NotFound, "match result not ready")
+	case err != nil:
+		beLog.WithFields(log.Fields{
+			"error":      err.Error(),
+			"component":  "statestorage",
+			"func":       funcName,
+			"requestKey": mo.Id,
+		}).Error("State storage error")
+
 		stats.Record(fnCtx, BeGrpcErrors.M(1))
-		return &newMO, status.Error(codes.Unknown, newMO.Error)
+		return &pb.MatchObject{Id: mo.Id}, status.Error(codes.Unknown, err.Error())
+	case result.Error != "":
+		stats.Record(fnCtx, BeGrpcErrors.M(1))
+		return result, status.Error(codes.Unknown, result.Error)
 	}
 
-	beLog.Info("Matchmaking results received, returning to backend client")
 	stats.Record(fnCtx, BeGrpcRequests.M(1))
-	return &newMO, nil
+	return result, nil
+}
+
+// WatchMatchResults is this service's implementation of the
+// WatchMatchResults gRPC method defined in api/protobuf-spec/backend.proto
+func (s *backendAPI) WatchMatchResults(stream pb.Backend_WatchMatchResultsServer) error {
+	ctx := stream.Context()
+
+	// Create context for tagging OpenCensus metrics.
+	funcName := "WatchMatchResults"
+	fnCtx, _ := tag.New(ctx, tag.Insert(KeyMethod, funcName))
+	wLog := beLog.WithFields(log.Fields{"func": funcName})
+
+	// Watch every requested result in its own goroutine.  gRPC streams can't
+	// be sent to concurrently, so results are passed back here to send.
+	results := make(chan *pb.MatchObject)
+	var watches sync.WaitGroup
+	recvErr := make(chan error, 1)
+	go func() {
+		defer close(recvErr)
+		for {
+			mo, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF {
+					recvErr <- err
+				}
+				return
+			}
+			wLog.WithFields(log.Fields{"requestKey": mo.Id}).Debug("Watching for match result")
+			watches.Add(1)
+			go func(requestKey string) {
+				defer watches.Done()
+				result, _ := s.watchResult(ctx, requestKey)
+				result.Id = requestKey
+				select {
+				case results <- result:
+				case <-ctx.Done():
+				}
+			}(mo.Id)
+		}
+	}()
+
+	// Close the results once the client has stopped sending requests and
+	// all watches have finished.
+	done := make(chan error, 1)
+	go func() {
+		err := <-recvErr
+		watches.Wait()
+		done <- err
+		close(results)
+	}()
+
+	for result := range results {
+		if err := stream.Send(result); err != nil {
+			wLog.WithFields(log.Fields{"error": err.Error()}).Error("Failure sending match result")
+			stats.Record(fnCtx, BeGrpcErrors.M(1))
+			return err
+		}
+	}
+	if err := <-done; err != nil {
+		stats.Record(fnCtx, BeGrpcErrors.M(1))
+		return err
+	}
+	stats.Record(fnCtx, BeGrpcRequests.M(1))
+	return ctx.Err()
 }
 
 // ListMatches is this service's implementation of the ListMatches gRPC method
@@ -322,7 +462,7 @@ func (s *backendAPI) DeleteMatch(ctx context.Context, mo *pb.MatchObject) (*pb.R
 		"matchObjectID": mo.Id,
 	}).Info("gRPC call executing")
 
-	err := redishelpers.Delete(ctx, s.pool, mo.Id)
+	err := s.store.DeleteMatchObject(ctx, mo.Id)
 	if err != nil {
 		beLog.WithFields(log.Fields{
 			"error":     err.Error(),
@@ -372,13 +512,24 @@ func (s *backendAPI) CreateAssignments(ctx context.Context, a *pb.Assignments) (
 		"numAssignments": len(players),
 	}).Info("gRPC call executing")
 
+	// Parties are matched as a single player; copy their assignments to
+	// every member so each member's GetUpdates stream receives it.
+	for partyID, members := range statestorage.PartyMembers(ctx, s.store, playerIDs) {
+		for _, member := range members {
+			players[member] = players[partyID]
+		}
+	}
+
 	// TODO: These two calls are done in two different transactions; could be
 	// combined as an optimization but probably not particularly necessary
 	// Send the players their assignments.
-	err := redishelpers.UpdateMultiFields(ctx, s.pool, players, "assignment")
+	err := s.store.UpdatePlayersField(ctx, "assignment", players)
+	if err == nil {
+		err = statestorage.SetPlayerStatus(ctx, s.store, pb.PlayerStatus_ASSIGNED, playerIDs)
+	}
 
 	// Move these players from the proposed list to the deindexed list.
-	ignorelist.Move(ctx, s.pool, playerIDs, "proposed", "deindexed")
+	s.store.MoveIgnoredPlayers(ctx, playerIDs, "proposed", "deindexed")
 
 	// Issue encountered
 	if err != nil {
@@ -416,7 +567,11 @@ func (s *backendAPI) DeleteAssignments(ctx context.Context, r *pb.Roster) (*pb.R
 		"numAssignments": len(assignments),
 	}).Info("gRPC call executing")
 
-	err := redishelpers.DeleteMultiFields(ctx, s.pool, assignments, "assignment")
+	for _, members := range statestorage.PartyMembers(ctx, s.store, assignments) {
+		assignments = append(assignments, members...)
+	}
+
+	err := s.store.DeletePlayersField(ctx, "assignment", assignments)
 
 	// Issue encountered
 	if err != nil {
@@ -84,15 +84,15 @@ func RunApplication() {
 	initializeApplication()
 
 	// Connect to redis
-	pool, err := redishelpers.ConnectionPool(cfg)
+	store, err := redishelpers.New(cfg)
 	if err != nil {
 		beLog.Fatal(err)
 	}
-	defer pool.Close()
+	defer store.Close()
 
 	// Instantiate the gRPC server with the connections we've made
 	beLog.Info("Attempting to start gRPC server")
-	srv := apisrv.New(cfg, pool)
+	srv := apisrv.New(cfg, store)
 
 	// Run the gRPC server
 	err = srv.Open()
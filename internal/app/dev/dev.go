@@ -0,0 +1,208 @@
+/*
+Package dev runs every Open Match component in a single process: the
+Frontend, Backend and MMLogic gRPC servers, the matchmaker function
+orchestrator loop and an evaluator.  Matchmaking functions are run as local
+goroutines or subprocesses instead of Kubernetes Jobs, and state is kept in
+memory by default, so the full flow can be tried on a laptop with no
+external services.
+
+This is intended for development only; it is not a supported way to run
+Open Match in production.
+
+Copyright 2019 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package dev
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/open-match/config"
+	beapisrv "github.com/GoogleCloudPlatform/open-match/internal/app/backendapi/apisrv"
+	feapisrv "github.com/GoogleCloudPlatform/open-match/internal/app/frontendapi/apisrv"
+	"github.com/GoogleCloudPlatform/open-match/internal/app/mmforc"
+	mlapisrv "github.com/GoogleCloudPlatform/open-match/internal/app/mmlogicapi/apisrv"
+	"github.com/GoogleCloudPlatform/open-match/internal/logging"
+	"github.com/GoogleCloudPlatform/open-match/internal/metrics"
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	"github.com/GoogleCloudPlatform/open-match/internal/signal"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage/memory"
+	redishelpers "github.com/GoogleCloudPlatform/open-match/internal/statestorage/redis"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"go.opencensus.io/plugin/ocgrpc"
+	"go.opencensus.io/stats/view"
+	"google.golang.org/grpc"
+)
+
+var (
+	// Logrus structured logging setup
+	devLogFields = log.Fields{
+		"app":       "openmatch",
+		"component": "dev",
+	}
+	devLog = log.WithFields(devLogFields)
+)
+
+var errUnknownStateStorage = errors.New("unknown state storage, must be 'memory' or 'redis'")
+
+// initializeApplication reads the config, applies any command line
+// overrides, and sets up logging and metrics once for all components.
+func initializeApplication(args []string) (*viper.Viper, error) {
+	// Add a hook to the logger to log the filename & line number.
+	log.SetReportCaller(true)
+
+	// Viper config management initialization
+	cfg, err := config.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	// Command line flags override the values in the config file.
+	fs := flag.NewFlagSet("dev", flag.ExitOnError)
+	fePort := fs.Int("frontend-port", cfg.GetInt("api.frontend.port"), "Frontend API gRPC port")
+	bePort := fs.Int("backend-port", cfg.GetInt("api.backend.port"), "Backend API gRPC port")
+	mlPort := fs.Int("mmlogic-port", cfg.GetInt("api.mmlogic.port"), "MMLogic API gRPC port")
+	metricsPort := fs.Int("metrics-port", cfg.GetInt("metrics.port"), "Prometheus metrics port")
+	storage := fs.String("statestorage", cfg.GetString("dev.statestorage"), "State storage to use: 'memory' or 'redis'")
+	mmfCommand := fs.String("mmf-command", strings.Join(cfg.GetStringSlice("functionRunners.exec.command"), " "),
+		"Command to run as a subprocess for each MMF; if empty, the built-in MMF is run in a goroutine")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	cfg.Set("api.frontend.port", *fePort)
+	cfg.Set("api.backend.port", *bePort)
+	cfg.Set("api.mmlogic.hostname", "localhost")
+	cfg.Set("api.mmlogic.port", *mlPort)
+	cfg.Set("metrics.port", *metricsPort)
+	cfg.Set("dev.statestorage", *storage)
+	cfg.Set("functionRunners.exec.command", strings.Fields(*mmfCommand))
+
+	// Configure open match logging defaults
+	logging.ConfigureLogging(cfg)
+
+	// Configure OpenCensus exporter to Prometheus.  All components share one
+	// exporter, so views with the same name (e.g. the log line counters) are
+	// only registered for the first component that defines them.
+	ocViews := uniqueViews(
+		feapisrv.DefaultFrontendAPIViews,
+		beapisrv.DefaultBackendAPIViews,
+		mlapisrv.DefaultMmlogicAPIViews,
+		mmforc.DefaultMmforcViews,
+		ocgrpc.DefaultServerViews,
+		[]*view.View{config.CfgVarCountView},
+	)
+	devLog.WithFields(log.Fields{"viewscount": len(ocViews)}).Info("Loaded OpenCensus views")
+	metrics.ConfigureOpenCensusPrometheusExporter(cfg, ocViews)
+
+	return cfg, nil
+}
+
+// uniqueViews combines lists of OpenCensus views, dropping any view whose
+// name has already been seen.
+func uniqueViews(viewLists ...[]*view.View) []*view.View {
+	seen := make(map[string]bool)
+	views := make([]*view.View, 0)
+	for _, list := range viewLists {
+		for _, v := range list {
+			if !seen[v.Name] {
+				seen[v.Name] = true
+				views = append(views, v)
+			}
+		}
+	}
+	return views
+}
+
+// newStateStorage returns the state storage selected by 'dev.statestorage'.
+func newStateStorage(cfg *viper.Viper) (statestorage.Service, error) {
+	switch cfg.GetString("dev.statestorage") {
+	case "redis":
+		return redishelpers.New(cfg)
+	case "", "memory":
+		return memory.New(cfg), nil
+	default:
+		return nil, errUnknownStateStorage
+	}
+}
+
+// RunApplication is a hook for the main() method in the main executable.
+// args are the command line arguments after the 'dev' subcommand.
+func RunApplication(args []string) {
+	cfg, err := initializeApplication(args)
+	if err != nil {
+		devLog.WithFields(log.Fields{"error": err.Error()}).Fatal("Unable to initialize")
+	}
+
+	store, err := newStateStorage(cfg)
+	if err != nil {
+		devLog.WithFields(log.Fields{
+			"error":        err.Error(),
+			"statestorage": cfg.GetString("dev.statestorage"),
+		}).Fatal("Unable to create state storage")
+	}
+	defer store.Close()
+	devLog.WithFields(log.Fields{"statestorage": cfg.GetString("dev.statestorage")}).Info("State storage initialized")
+
+	// Start all the API servers with the shared state storage.
+	devLog.Info("Attempting to start gRPC servers")
+	if err := feapisrv.New(cfg, store).Open(); err != nil {
+		devLog.WithFields(log.Fields{"error": err.Error()}).Fatal("Failed to start frontend gRPC server")
+	}
+	if err := beapisrv.New(cfg, store).Open(); err != nil {
+		devLog.WithFields(log.Fields{"error": err.Error()}).Fatal("Failed to start backend gRPC server")
+	}
+	if err := mlapisrv.New(cfg, store).Open(); err != nil {
+		devLog.WithFields(log.Fields{"error": err.Error()}).Fatal("Failed to start mmlogic gRPC server")
+	}
+
+	// Built-in MMFs talk to the MMLogic API just like MMFs running elsewhere.
+	conn, err := grpc.Dial(cfg.GetString("api.mmlogic.hostname")+":"+cfg.GetString("api.mmlogic.port"), grpc.WithInsecure())
+	if err != nil {
+		devLog.WithFields(log.Fields{"error": err.Error()}).Fatal("Failed to connect to mmlogic gRPC server")
+	}
+	defer conn.Close()
+
+	// MMFs run on this machine; there is no cluster to submit Jobs to.  If
+	// an MMF command is configured it is used by default, otherwise the
+	// built-in MMF is.
+	runners := &mmforc.Runners{
+		Functions: map[string]mmforc.FunctionRunner{
+			runnerBuiltin:     &builtinRunner{mmlogic: pb.NewMmLogicClient(conn)},
+			mmforc.RunnerExec: mmforc.NewExecRunner(cfg),
+			mmforc.RunnerHTTP: mmforc.NewHTTPRunner(cfg),
+			mmforc.RunnerGRPC: mmforc.NewGRPCRunner(cfg),
+		},
+		DefaultFunction: runnerBuiltin,
+		Evaluator:       &evaluatorRunner{cfg: cfg, store: store},
+	}
+	if len(cfg.GetStringSlice("functionRunners.exec.command")) > 0 {
+		runners.DefaultFunction = mmforc.RunnerExec
+	}
+	defer runners.Close()
+
+	// Run the orchestrator loop until we see a signal.
+	ctx, cancel := context.WithCancel(context.Background())
+	go mmforc.Run(ctx, cfg, store, runners)
+
+	wait, _ := signal.New()
+	wait()
+	devLog.Info("Shutting down")
+	cancel()
+}
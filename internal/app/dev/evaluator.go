@@ -0,0 +1,36 @@
+/*
+Copyright 2019 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dev
+
+import (
+	"context"
+
+	"github.com/GoogleCloudPlatform/open-match/internal/evaluator"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage"
+	"github.com/spf13/viper"
+)
+
+// evaluatorRunner runs the built-in evaluator in the calling goroutine.
+type evaluatorRunner struct {
+	cfg   *viper.Viper
+	store statestorage.Service
+}
+
+// Run evaluates the queued proposals.
+func (r *evaluatorRunner) Run(ctx context.Context, jobName string, timestamp string) error {
+	return evaluator.Evaluate(ctx, r.cfg, r.store)
+}
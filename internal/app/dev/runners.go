@@ -0,0 +1,87 @@
+/*
+Copyright 2019 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dev
+
+import (
+	"context"
+
+	"github.com/GoogleCloudPlatform/open-match/internal/app/mmforc"
+	"github.com/GoogleCloudPlatform/open-match/internal/mmf"
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	log "github.com/sirupsen/logrus"
+)
+
+// runnerBuiltin is the name of the built-in function runner.
+const runnerBuiltin = "builtin"
+
+// builtinRunner runs a simple built-in MMF in the calling goroutine.  It uses
+// the MMF harness, and through it the MMLogic API, like any other Go MMF.
+type builtinRunner struct {
+	mmlogic pb.MmLogicClient
+}
+
+// Run runs the built-in matchmaking function for job.
+func (r *builtinRunner) Run(ctx context.Context, job *mmforc.MMFJob, profile *pb.MatchObject) error {
+	req := &pb.Request{
+		ProfileId:  job.ProfileID,
+		ProposalId: job.ProposalID,
+		RequestId:  job.RequestID,
+		ErrorId:    job.ResultsID,
+		Timestamp:  job.Timestamp,
+	}
+	return mmf.Run(ctx, r.mmlogic, req, profile, fillRosters)
+}
+
+// fillRosters is a minimal matchmaking function: it fills every player
+// slot in the profile's rosters, in order, with the first unused player from
+// the pool the slot asks for.  If any slot can't be filled, an
+// 'insufficient_players' error is returned to the Backend API instead.
+func fillRosters(ctx context.Context, profile *pb.MatchObject, pools map[string]*pb.PlayerPool) (*pb.MatchObject, error) {
+	available := make(map[string][]*pb.Player)
+	for name, pool := range pools {
+		available[name] = pool.Roster.Players
+	}
+
+	// A player can match more than one pool, so keep track of who has
+	// already been chosen.
+	chosen := make(map[string]bool)
+	proposal := &pb.MatchObject{Properties: profile.Properties}
+	for _, roster := range profile.Rosters {
+		proposed := &pb.Roster{Name: roster.Name}
+		for _, slot := range roster.Players {
+			playerID := ""
+			for len(available[slot.Pool]) > 0 && playerID == "" {
+				if id := available[slot.Pool][0].Id; !chosen[id] {
+					playerID = id
+				}
+				available[slot.Pool] = available[slot.Pool][1:]
+			}
+
+			if playerID == "" {
+				devLog.WithFields(log.Fields{
+					"roster": roster.Name,
+					"pool":   slot.Pool,
+				}).Info("Not enough players in the pool to fill all player slots in requested roster")
+				return nil, mmf.ErrInsufficientPlayers
+			}
+			chosen[playerID] = true
+			proposed.Players = append(proposed.Players, &pb.Player{Id: playerID, Pool: slot.Pool})
+		}
+		proposal.Rosters = append(proposal.Rosters, proposed)
+	}
+	return proposal, nil
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2019 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package evaluatorapi serves the built-in evaluator as a long-running
+// Evaluator gRPC service, for use with the MMForc's 'grpc' evaluator runner.
+package evaluatorapi
+
+import (
+	"errors"
+	"net"
+
+	"github.com/GoogleCloudPlatform/open-match/config"
+	"github.com/GoogleCloudPlatform/open-match/internal/evaluator"
+	"github.com/GoogleCloudPlatform/open-match/internal/logging"
+	"github.com/GoogleCloudPlatform/open-match/internal/metrics"
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	"github.com/GoogleCloudPlatform/open-match/internal/signal"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"go.opencensus.io/plugin/ocgrpc"
+	"google.golang.org/grpc"
+)
+
+var (
+	// Logrus structured logging setup
+	evLogFields = log.Fields{
+		"app":       "openmatch",
+		"component": "evaluator",
+	}
+	evLog = log.WithFields(evLogFields)
+
+	// Viper config management setup
+	cfg = viper.New()
+	err = errors.New("")
+)
+
+func initializeApplication() {
+	// Viper config management initialization
+	cfg, err = config.Read()
+	if err != nil {
+		evLog.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Error("Unable to load config file")
+	}
+
+	// Configure open match logging defaults
+	logging.ConfigureLogging(cfg)
+
+	// Configure OpenCensus exporter to Prometheus
+	ocServerViews := ocgrpc.DefaultServerViews                    // gRPC OpenCensus views.
+	ocServerViews = append(ocServerViews, config.CfgVarCountView) // config loader view.
+	evLog.WithFields(log.Fields{"viewscount": len(ocServerViews)}).Info("Loaded OpenCensus views")
+	metrics.ConfigureOpenCensusPrometheusExporter(cfg, ocServerViews)
+}
+
+// RunApplication is a hook for the main() method in the main executable.
+func RunApplication() {
+	initializeApplication()
+
+	ln, err := net.Listen("tcp", ":"+cfg.GetString("api.evaluator.port"))
+	if err != nil {
+		evLog.WithFields(log.Fields{
+			"error": err.Error(),
+			"port":  cfg.GetInt("api.evaluator.port"),
+		}).Fatal("net.Listen() error")
+	}
+	evLog.WithFields(log.Fields{"port": cfg.GetInt("api.evaluator.port")}).Info("TCP net listener initialized")
+
+	srv := grpc.NewServer(grpc.StatsHandler(&ocgrpc.ServerHandler{}))
+	pb.RegisterEvaluatorServer(srv, evaluator.NewServer(cfg))
+	go func() {
+		err := srv.Serve(ln)
+		if err != nil {
+			evLog.WithFields(log.Fields{"error": err.Error()}).Error("gRPC serve() error")
+		}
+	}()
+
+	// Exit when we see a signal
+	wait, _ := signal.New()
+	wait()
+	evLog.Info("Shutting down gRPC server")
+	srv.Stop()
+}
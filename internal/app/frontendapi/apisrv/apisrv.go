@@ -23,21 +23,18 @@ import (
 	"context"
 	"errors"
 	"net"
+	"time"
 
 	"github.com/GoogleCloudPlatform/open-match/internal/expbo"
 	"github.com/GoogleCloudPlatform/open-match/internal/metrics"
 	"github.com/GoogleCloudPlatform/open-match/internal/pb"
-	redishelpers "github.com/GoogleCloudPlatform/open-match/internal/statestorage/redis"
-	"github.com/GoogleCloudPlatform/open-match/internal/statestorage/redis/ignorelist"
-	"github.com/GoogleCloudPlatform/open-match/internal/statestorage/redis/playerindices"
-	"github.com/GoogleCloudPlatform/open-match/internal/statestorage/redis/redispb"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage"
 
 	"github.com/cenkalti/backoff"
 	log "github.com/sirupsen/logrus"
 	"go.opencensus.io/stats"
 	"go.opencensus.io/tag"
 
-	"github.com/gomodule/redigo/redis"
 	"github.com/spf13/viper"
 
 	"go.opencensus.io/plugin/ocgrpc"
@@ -58,18 +55,18 @@ var (
 // FrontendAPI implements frontend.ApiServer, the server generated by compiling
 // the protobuf, by fulfilling the frontend.APIClient interface.
 type FrontendAPI struct {
-	grpc *grpc.Server
-	cfg  *viper.Viper
-	pool *redis.Pool
+	grpc  *grpc.Server
+	cfg   *viper.Viper
+	store statestorage.Service
 }
 type frontendAPI FrontendAPI
 
 // New returns an instantiated srvice
-func New(cfg *viper.Viper, pool *redis.Pool) *FrontendAPI {
+func New(cfg *viper.Viper, store statestorage.Service) *FrontendAPI {
 	s := FrontendAPI{
-		pool: pool,
-		grpc: grpc.NewServer(grpc.StatsHandler(&ocgrpc.ServerHandler{})),
-		cfg:  cfg,
+		store: store,
+		grpc:  grpc.NewServer(grpc.StatsHandler(&ocgrpc.ServerHandler{})),
+		cfg:   cfg,
 	}
 
 	// Add a hook to the logger to auto-count log lines for metrics output thru OpenCensus
@@ -93,6 +90,11 @@ func (s *FrontendAPI) Open() error {
 	}
 	feLog.WithFields(log.Fields{"port": s.cfg.GetInt("api.frontend.port")}).Info("TCP net listener initialized")
 
+	// Keep this API's player indices in sync with the index registry.
+	go statestorage.WatchIndices(context.Background(), s.cfg, s.store)
+	// Remove the players whose clients have gone away.
+	go (*frontendAPI)(s).sweepExpired(context.Background())
+
 	go func() {
 		err := s.grpc.Serve(ln)
 		if err != nil {
@@ -110,8 +112,9 @@ func (s *frontendAPI) CreatePlayer(ctx context.Context, group *pb.Player) (*pb.R
 	funcName := "CreatePlayer"
 	fnCtx, _ := tag.New(ctx, tag.Insert(KeyMethod, funcName))
 
-	// Write group
-	err := redispb.MarshalToRedis(ctx, s.pool, group, s.cfg.GetInt("redis.expirations.player"))
+	// Write and index group
+	group.Status = pb.PlayerStatus_QUEUED.String()
+	err := s.store.CreatePlayer(ctx, group)
 	if err != nil {
 		feLog.WithFields(log.Fields{
 			"error":     err.Error(),
@@ -122,32 +125,295 @@ func (s *frontendAPI) CreatePlayer(ctx context.Context, group *pb.Player) (*pb.R
 		return &pb.Result{Success: false, Error: err.Error()}, status.Error(codes.Unknown, err.Error())
 	}
 
-	// Index group
-	err = playerindices.Create(ctx, s.pool, s.cfg, *group)
+	// Return success.
+	stats.Record(fnCtx, FeGrpcRequests.M(1))
+	return &pb.Result{Success: true, Error: ""}, nil
+}
+
+// UpdatePlayer is this service's implementation of the UpdatePlayer gRPC method defined in frontend.proto
+func (s *frontendAPI) UpdatePlayer(ctx context.Context, player *pb.Player) (*pb.Result, error) {
+	// Create context for tagging OpenCensus metrics.
+	funcName := "UpdatePlayer"
+	fnCtx, _ := tag.New(ctx, tag.Insert(KeyMethod, funcName))
+
+	// Update the player and their changed indices.
+	err := s.store.UpdatePlayer(ctx, player)
+	if err == statestorage.ErrNotFound {
+		stats.Record(fnCtx, FeGrpcErrors.M(1))
+		return &pb.Result{Success: false, Error: err.Error()}, status.Error(codes.NotFound, "player not found")
+	}
 	if err != nil {
 		feLog.WithFields(log.Fields{
 			"error":     err.Error(),
 			"component": "statestorage",
+			"playerid":  player.Id,
 		}).Error("State storage error")
 
 		stats.Record(fnCtx, FeGrpcErrors.M(1))
 		return &pb.Result{Success: false, Error: err.Error()}, status.Error(codes.Unknown, err.Error())
 	}
 
-	// Return success.
 	stats.Record(fnCtx, FeGrpcRequests.M(1))
 	return &pb.Result{Success: true, Error: ""}, nil
 }
 
 // DeletePlayer is this service's implementation of the DeletePlayer gRPC method defined in frontend.proto
 func (s *frontendAPI) DeletePlayer(ctx context.Context, group *pb.Player) (*pb.Result, error) {
+	return s.removePlayer(ctx, "DeletePlayer", group.Id)
+}
+
+// CreatePlayers is this service's implementation of the CreatePlayers gRPC method defined in frontend.proto
+func (s *frontendAPI) CreatePlayers(ctx context.Context, roster *pb.Roster) (*pb.Results, error) {
+	// Create context for tagging OpenCensus metrics.
+	funcName := "CreatePlayers"
+	fnCtx, _ := tag.New(ctx, tag.Insert(KeyMethod, funcName))
+
+	// Write and index all the players.
+	for _, player := range roster.Players {
+		player.Status = pb.PlayerStatus_QUEUED.String()
+	}
+	errs := s.store.CreatePlayers(ctx, roster.Players)
+
+	results, failed := batchResults(errs)
+	cpLog := feLog.WithFields(log.Fields{"count": len(roster.Players), "failed": failed})
+	if failed > 0 {
+		cpLog.WithFields(log.Fields{"component": "statestorage"}).Error("State storage error creating players")
+	} else {
+		cpLog.Debug("Players created")
+	}
+
+	stats.Record(fnCtx, FeGrpcRequests.M(1))
+	return results, nil
+}
+
+// DeletePlayers is this service's implementation of the DeletePlayers gRPC method defined in frontend.proto
+func (s *frontendAPI) DeletePlayers(ctx context.Context, roster *pb.Roster) (*pb.Results, error) {
+	// Create context for tagging OpenCensus metrics.
+	funcName := "DeletePlayers"
+	fnCtx, _ := tag.New(ctx, tag.Insert(KeyMethod, funcName))
+
+	// Deindex all the players, then lazily delete the ones that were
+	// deindexed, as in DeletePlayer.
+	ids := getPlayerIdsFromRoster(roster)
+	errs := s.store.DeindexPlayers(ctx, ids)
+	deindexed := make([]string, 0, len(ids))
+	for i, err := range errs {
+		if err == nil {
+			deindexed = append(deindexed, ids[i])
+		}
+	}
+	if err := statestorage.SetPlayerStatus(ctx, s.store, pb.PlayerStatus_CANCELLED, deindexed); err != nil {
+		feLog.WithFields(log.Fields{
+			"error":     err.Error(),
+			"component": "statestorage",
+		}).Warn("Unable to set player statuses")
+	}
+	go func() {
+		for _, id := range deindexed {
+			s.deletePlayer(id)
+		}
+	}()
+
+	results, failed := batchResults(errs)
+	dpLog := feLog.WithFields(log.Fields{"count": len(ids), "failed": failed})
+	if failed > 0 {
+		dpLog.WithFields(log.Fields{"component": "statestorage"}).Error("State storage error deleting players")
+	} else {
+		dpLog.Debug("Players deleted")
+	}
+
+	stats.Record(fnCtx, FeGrpcRequests.M(1))
+	return results, nil
+}
+
+// Heartbeat is this service's implementation of the Heartbeat gRPC method defined in frontend.proto
+func (s *frontendAPI) Heartbeat(ctx context.Context, player *pb.Player) (*pb.Result, error) {
+	// Create context for tagging OpenCensus metrics.
+	funcName := "Heartbeat"
+	fnCtx, _ := tag.New(ctx, tag.Insert(KeyMethod, funcName))
+
+	// Refresh the player's 'accessed' timestamp so the expiry sweeper leaves
+	// them alone, unless their matchmaking is over.
+	err := s.heartbeat(ctx, []string{player.Id})[0]
+	if err == statestorage.ErrNotFound {
+		stats.Record(fnCtx, FeGrpcErrors.M(1))
+		return &pb.Result{Success: false, Error: err.Error()}, status.Error(codes.NotFound, "player not found")
+	}
+	if _, ok := err.(finishedError); ok {
+		stats.Record(fnCtx, FeGrpcErrors.M(1))
+		return &pb.Result{Success: false, Error: err.Error()}, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	if err != nil {
+		feLog.WithFields(log.Fields{
+			"error":     err.Error(),
+			"component": "statestorage",
+			"playerid":  player.Id,
+		}).Error("State storage error")
+
+		stats.Record(fnCtx, FeGrpcErrors.M(1))
+		return &pb.Result{Success: false, Error: err.Error()}, status.Error(codes.Unknown, err.Error())
+	}
+
+	stats.Record(fnCtx, FeGrpcRequests.M(1))
+	return &pb.Result{Success: true, Error: ""}, nil
+}
+
+// HeartbeatPlayers is this service's implementation of the HeartbeatPlayers gRPC method defined in frontend.proto
+func (s *frontendAPI) HeartbeatPlayers(ctx context.Context, roster *pb.Roster) (*pb.Results, error) {
+	// Create context for tagging OpenCensus metrics.
+	funcName := "HeartbeatPlayers"
+	fnCtx, _ := tag.New(ctx, tag.Insert(KeyMethod, funcName))
+
+	ids := getPlayerIdsFromRoster(roster)
+	results, failed := batchResults(s.heartbeat(ctx, ids))
+	feLog.WithFields(log.Fields{"count": len(ids), "failed": failed}).Debug("Players heartbeat")
+
+	stats.Record(fnCtx, FeGrpcRequests.M(1))
+	return results, nil
+}
+
+// finishedError is returned by heartbeat for players whose matchmaking is
+// over; it is the player's status.
+type finishedError string
+
+func (e finishedError) Error() string {
+	return "player is " + string(e)
+}
+
+// heartbeat touches the players whose matchmaking isn't over.  It returns
+// an error for each player, in order: ErrNotFound for players who aren't in
+// state storage, and a finishedError for players who are assigned, expired
+// or cancelled.
+func (s *frontendAPI) heartbeat(ctx context.Context, ids []string) []error {
+	errs := make([]error, len(ids))
+	statuses, err := s.store.RetrievePlayersField(ctx, "status", ids)
+	if err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+	finished := map[string]bool{
+		pb.PlayerStatus_ASSIGNED.String():  true,
+		pb.PlayerStatus_CANCELLED.String(): true,
+		pb.PlayerStatus_EXPIRED.String():   true,
+	}
+	live := make([]string, 0, len(ids))
+	for i, id := range ids {
+		if finished[statuses[id]] {
+			errs[i] = finishedError(statuses[id])
+			continue
+		}
+		live = append(live, id)
+	}
+	touched := s.store.TouchPlayers(ctx, live)
+	for i, j := 0, 0; i < len(ids); i++ {
+		if errs[i] == nil {
+			errs[i] = touched[j]
+			j++
+		}
+	}
+	return errs
+}
+
+// batchResults converts the per-player errors from a batch state storage
+// call into Results, and counts the failures.
+func batchResults(errs []error) (*pb.Results, int) {
+	results := &pb.Results{Results: make([]*pb.Result, len(errs))}
+	failed := 0
+	for i, err := range errs {
+		if err != nil {
+			results.Results[i] = &pb.Result{Success: false, Error: err.Error()}
+			failed++
+		} else {
+			results.Results[i] = &pb.Result{Success: true, Error: ""}
+		}
+	}
+	return results, failed
+}
+
+// getPlayerIdsFromRoster returns the IDs of the players in the roster.
+func getPlayerIdsFromRoster(r *pb.Roster) []string {
+	playerIDs := make([]string, 0, len(r.Players))
+	for _, p := range r.Players {
+		playerIDs = append(playerIDs, p.Id)
+	}
+	return playerIDs
+}
+
+// CreateParty is this service's implementation of the CreateParty gRPC method defined in frontend.proto
+func (s *frontendAPI) CreateParty(ctx context.Context, party *pb.Party) (*pb.Result, error) {
+	// Create context for tagging OpenCensus metrics.
+	funcName := "CreateParty"
+	fnCtx, _ := tag.New(ctx, tag.Insert(KeyMethod, funcName))
+
+	cpLog := feLog.WithFields(log.Fields{"partyid": party.Id, "members": len(party.Members)})
+
+	// Read the members' properties to aggregate their indexed attributes.
+	members := make([]*pb.Player, 0, len(party.Members))
+	for _, id := range party.Members {
+		member := &pb.Player{Id: id}
+		if err := s.store.RetrievePlayer(ctx, member); err != nil && err != statestorage.ErrNotFound {
+			cpLog.WithFields(log.Fields{
+				"error":     err.Error(),
+				"component": "statestorage",
+				"playerid":  id,
+			}).Error("State storage error")
+
+			stats.Record(fnCtx, FeGrpcErrors.M(1))
+			return &pb.Result{Success: false, Error: err.Error()}, status.Error(codes.Unknown, err.Error())
+		}
+		members = append(members, member)
+	}
+
+	player, err := statestorage.PartyPlayer(s.cfg, party, members)
+	if err != nil {
+		cpLog.WithFields(log.Fields{"error": err.Error()}).Error("Invalid party")
+
+		stats.Record(fnCtx, FeGrpcErrors.M(1))
+		return &pb.Result{Success: false, Error: err.Error()}, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// Write and index the party and deindex the members so they are only
+	// matched as part of the party.  Missing members and members of another
+	// party are checked by state storage, atomically with the writes.
+	player.Status = pb.PlayerStatus_QUEUED.String()
+	if err = s.store.CreateParty(ctx, player); err != nil {
+		code := codes.Unknown
+		if partyErr, ok := err.(*statestorage.PartyError); ok {
+			code = codes.FailedPrecondition
+			if len(partyErr.Missing) > 0 {
+				code = codes.NotFound
+			}
+		}
+		cpLog.WithFields(log.Fields{
+			"error":     err.Error(),
+			"component": "statestorage",
+		}).Error("State storage error")
+
+		stats.Record(fnCtx, FeGrpcErrors.M(1))
+		return &pb.Result{Success: false, Error: err.Error()}, status.Error(code, err.Error())
+	}
+
+	cpLog.Info("Party created")
+	stats.Record(fnCtx, FeGrpcRequests.M(1))
+	return &pb.Result{Success: true, Error: ""}, nil
+}
+
+// DeleteParty is this service's implementation of the DeleteParty gRPC method defined in frontend.proto
+func (s *frontendAPI) DeleteParty(ctx context.Context, party *pb.Party) (*pb.Result, error) {
+	return s.removePlayer(ctx, "DeleteParty", party.Id)
+}
+
+// removePlayer deindexes a player or party, then deletes it from state
+// storage in the background.
+func (s *frontendAPI) removePlayer(ctx context.Context, funcName string, id string) (*pb.Result, error) {
 	// Create context for tagging OpenCensus metrics.
-	funcName := "DeletePlayer"
 	fnCtx, _ := tag.New(ctx, tag.Insert(KeyMethod, funcName))
 
 	// Deindex this player; at that point they don't show up in MMFs anymore.  We can then delete
 	// their actual player object from Redis later.
-	err := playerindices.Delete(ctx, s.pool, s.cfg, group.Id)
+	err := s.store.DeindexPlayer(ctx, id)
 	if err != nil {
 		feLog.WithFields(log.Fields{
 			"error":     err.Error(),
@@ -157,8 +423,18 @@ func (s *frontendAPI) DeletePlayer(ctx context.Context, group *pb.Player) (*pb.R
 		stats.Record(fnCtx, FeGrpcErrors.M(1))
 		return &pb.Result{Success: false, Error: err.Error()}, status.Error(codes.Unknown, err.Error())
 	}
+	// Let anyone still watching the player know why.  Not fatal; the player
+	// has already been removed from matchmaking.
+	if err := statestorage.SetPlayerStatus(ctx, s.store, pb.PlayerStatus_CANCELLED, []string{id}); err != nil {
+		feLog.WithFields(log.Fields{
+			"error":     err.Error(),
+			"component": "statestorage",
+			"playerid":  id,
+		}).Warn("Unable to set player status")
+	}
+
 	// Kick off delete but don't wait for it to complete.
-	go s.deletePlayer(group.Id)
+	go s.deletePlayer(id)
 
 	stats.Record(fnCtx, FeGrpcRequests.M(1))
 	return &pb.Result{Success: true, Error: ""}, nil
@@ -168,35 +444,46 @@ func (s *frontendAPI) DeletePlayer(ctx context.Context, group *pb.Player) (*pb.R
 // It should always be called as a goroutine and should only be called after
 // confirmation that a player has been deindexed (and therefore MMF's can't
 // find the player to read them anyway)
-// As a final action, it also kicks off a lazy delete of the player's metadata
+// State storage also removes the player from all ignorelists and deletes the
+// player's metadata.
 func (s *frontendAPI) deletePlayer(id string) {
-	err := redishelpers.Delete(context.Background(), s.pool, id)
+	err := s.store.DeletePlayer(context.Background(), id)
 	if err != nil {
 		feLog.WithFields(log.Fields{
 			"error":     err.Error(),
 			"component": "statestorage",
 		}).Warn("Error deleting player from state storage, this could leak state storage memory but is usually not a fatal error")
 	}
+}
 
-	// Delete player from all ignorelists
-	go func() {
-		redisConn := s.pool.Get()
-		defer redisConn.Close()
-
-		redisConn.Send("MULTI")
-		for il := range s.cfg.GetStringMap("ignoreLists") {
-			ignorelist.SendRemove(redisConn, il, []string{id})
-		}
-		_, err := redisConn.Do("EXEC")
-		if err != nil {
-			feLog.WithFields(log.Fields{
-				"error":     err.Error(),
-				"component": "statestorage",
-			}).Error("Error de-indexing player from ignorelists")
-		}
-	}()
+// expirePlayer sets an idle player's status to EXPIRED, unless their
+// matchmaking is already over, and deindexes them.
+func (s *frontendAPI) expirePlayer(id string) {
+	ctx := context.Background()
+	if err := statestorage.SetPlayerStatus(ctx, s.store, pb.PlayerStatus_EXPIRED, []string{id}); err != nil {
+		feLog.WithFields(log.Fields{
+			"error":     err.Error(),
+			"component": "statestorage",
+			"playerid":  id,
+		}).Warn("Unable to set player status")
+	}
+	if err := s.store.DeindexPlayer(ctx, id); err != nil {
+		feLog.WithFields(log.Fields{
+			"error":     err.Error(),
+			"component": "statestorage",
+			"playerid":  id,
+		}).Error("Unable to deindex expired player")
+	}
+}
 
-	go playerindices.DeleteMeta(context.Background(), s.pool, id)
+// touchPlayer refreshes the 'accessed' timestamp of a player whose
+// GetUpdates stream is open.
+func (s *frontendAPI) touchPlayer(ctx context.Context, id string) {
+	if err := s.store.TouchPlayers(ctx, []string{id})[0]; err != nil && err != statestorage.ErrNotFound {
+		// Not fatal, but this error should be addressed.  This could
+		// cause the player to expire while still actively connected!
+		feLog.WithFields(log.Fields{"error": err.Error(), "playerid": id}).Error("Unable to update accessed metadata timestamp")
+	}
 }
 
 // GetUpdates is this service's implementation of the GetUpdates gRPC method defined in frontend.proto
@@ -220,10 +507,22 @@ func (s *frontendAPI) GetUpdates(p *pb.Player, assignmentStream pb.Frontend_GetU
 	watcherBOCtx := backoff.WithContext(watcherBO, watcherCtx)
 
 	// get and return connection string
-	watchChan := redispb.PlayerWatcher(watcherBOCtx, s.pool, *p) // watcher() runs the appropriate Redis commands.
+	watchChan := s.store.WatchPlayer(watcherBOCtx, *p) // WatchPlayer() runs the appropriate state storage commands.
+
+	// With 'watch' liveness, the open stream keeps the player alive.
+	var touch <-chan time.Time
+	if s.cfg.GetString("playerExpiry.liveness") != "heartbeat" {
+		s.touchPlayer(ctx, p.Id)
+		ticker := time.NewTicker(s.expiryDuration("touchInterval", defaultExpiryTouchInterval))
+		defer ticker.Stop()
+		touch = ticker.C
+	}
 
 	for {
 		select {
+		case <-touch:
+			s.touchPlayer(ctx, p.Id)
+
 		case <-ctx.Done():
 			// Context cancelled
 			feLog.WithField("playerid", p.Id).Info("client closed connection successfully")
@@ -244,9 +543,9 @@ func (s *frontendAPI) GetUpdates(p *pb.Player, assignmentStream pb.Frontend_GetU
 				errTag, _ := tag.NewKey("errtype")
 				fnCtx, _ := tag.New(ctx, tag.Insert(errTag, "watch_timeout"))
 				stats.Record(fnCtx, FeGrpcErrors.M(1))
-				//TODO: we could generate a frontend.player message with an error
-				//field and stream it to the client before throwing the error here
-				//if we wanted to send more useful client retry information
+
+				// The client can reconnect; players who stay away are
+				// expired by the sweeper.
 				return status.Error(codes.DeadlineExceeded, err.Error())
 			}
 
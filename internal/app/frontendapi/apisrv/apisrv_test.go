@@ -0,0 +1,54 @@
+package apisrv
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage/memory"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreateParty(t *testing.T) {
+	ctx := context.Background()
+	cfg := viper.New()
+	cfg.Set("playerIndices", []string{"mmr"})
+	store := memory.New(cfg)
+	s := &frontendAPI{cfg: cfg, store: store}
+	for _, id := range []string{"a", "b", "c"} {
+		store.CreatePlayer(ctx, &pb.Player{Id: id, Properties: `{"mmr": 10}`})
+	}
+
+	if _, err := s.CreateParty(ctx, &pb.Party{Id: "party1", Members: []string{"a", "b"}}); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := store.CountIndexRange(ctx, &pb.Filter{Attribute: "mmr"}); n != 2 {
+		t.Errorf("got %v indexed, want the party and c", n)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		members []string
+		want    codes.Code
+	}{
+		{"unknown member", []string{"c", "nobody"}, codes.NotFound},
+		{"member of another party", []string{"a", "c"}, codes.FailedPrecondition},
+		{"repeated member", []string{"c", "c"}, codes.InvalidArgument},
+	} {
+		if _, err := s.CreateParty(ctx, &pb.Party{Id: "party2", Members: tc.members}); status.Code(err) != tc.want {
+			t.Errorf("%v: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+	// Rejected parties change nothing.
+	if n, _ := store.CountIndexRange(ctx, &pb.Filter{Attribute: "mmr"}); n != 2 {
+		t.Errorf("got %v indexed after rejections, want 2", n)
+	}
+
+	// Members of a deleted party can join another.
+	store.DeletePlayer(ctx, "party1")
+	if _, err := s.CreateParty(ctx, &pb.Party{Id: "party2", Members: []string{"a", "c"}}); err != nil {
+		t.Errorf("after deleting party1: got %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2019 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apisrv
+
+import (
+	"context"
+	"time"
+
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage"
+	log "github.com/sirupsen/logrus"
+	"go.opencensus.io/stats"
+)
+
+// The expiry sweeper removes players whose clients have gone away.  A
+// player's OM_METADATA.accessed time is refreshed by Heartbeat calls and,
+// with the default 'watch' liveness, every 'playerExpiry.touchInterval'
+// seconds while a GetUpdates call watches them; once it is older than
+// 'playerExpiry.idleTime' seconds, the sweeper deindexes the player and sets
+// their status to EXPIRED, so a client that reconnects learns what happened.  Players who stay idle for
+// 'playerExpiry.gracePeriod' seconds more after they expired, were cancelled
+// or were assigned are deleted from state storage, along with their metadata
+// indices.  Parties are left alone while any of their members is live.
+// Sweeps are idempotent, so every Frontend API replica can run one.
+
+// Defaults for the playerExpiry config, in seconds.
+const (
+	defaultExpiryInterval      = 60
+	defaultExpiryIdleTime      = 800
+	defaultExpiryGracePeriod   = 300
+	defaultExpiryTouchInterval = 30
+)
+
+// expiryDuration reads a playerExpiry config value in seconds.
+func (s *frontendAPI) expiryDuration(key string, def int) time.Duration {
+	if !s.cfg.IsSet("playerExpiry." + key) {
+		return time.Duration(def) * time.Second
+	}
+	return time.Duration(s.cfg.GetInt("playerExpiry."+key)) * time.Second
+}
+
+// sweepExpired sweeps idle players every 'playerExpiry.interval' seconds
+// until the context is cancelled.  An interval of 0 disables the sweeper.
+func (s *frontendAPI) sweepExpired(ctx context.Context) {
+	interval := s.expiryDuration("interval", defaultExpiryInterval)
+	if interval <= 0 {
+		feLog.Info("Player expiry sweeper disabled")
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+		s.sweep(ctx, time.Now())
+	}
+}
+
+// sweep expires the players idle since before now less the idle time, and
+// deletes those idle for the grace period longer whose matchmaking is over.
+// It returns the number of players expired and deleted.
+func (s *frontendAPI) sweep(ctx context.Context, now time.Time) (expired int, deleted int) {
+	idleSince := now.Add(-s.expiryDuration("idleTime", defaultExpiryIdleTime)).Unix()
+	deleteSince := idleSince - int64(s.expiryDuration("gracePeriod", defaultExpiryGracePeriod).Seconds())
+
+	// Read every idle player before changing any, as deleting players
+	// shifts the pages of the index.
+	idle := make(map[string]float64)
+	accessed := &pb.Filter{Attribute: "OM_METADATA.accessed", Maxv: idleSince}
+	pageSize := s.cfg.GetInt("redis.queryArgs.count")
+	if pageSize <= 0 {
+		pageSize = 10000
+	}
+	for offset := 0; ; offset += pageSize {
+		if ctx.Err() != nil {
+			return
+		}
+		page, err := s.store.RetrieveIndexRange(ctx, accessed, offset, pageSize)
+		if err != nil {
+			feLog.WithFields(log.Fields{"error": err.Error()}).Error("Failed to find idle players")
+			return
+		}
+		for id, at := range page {
+			idle[id] = at
+		}
+		if len(page) < pageSize {
+			break
+		}
+	}
+
+	done := map[string]bool{
+		pb.PlayerStatus_ASSIGNED.String():  true,
+		pb.PlayerStatus_CANCELLED.String(): true,
+		pb.PlayerStatus_EXPIRED.String():   true,
+	}
+	for id, at := range idle {
+		if ctx.Err() != nil {
+			break
+		}
+		player := &pb.Player{Id: id}
+		err := s.store.RetrievePlayer(ctx, player)
+		if err != nil && err != statestorage.ErrNotFound {
+			feLog.WithFields(log.Fields{"error": err.Error(), "playerid": id}).Error("Unable to read idle player")
+			continue
+		}
+		// A player with no record has already timed out of state storage,
+		// leaving their indices behind.
+		gone := err == statestorage.ErrNotFound
+		// Clients keep their members alive rather than the party itself.
+		if !gone && s.anyMemberLive(ctx, player.Members, idle) {
+			continue
+		}
+		switch {
+		case gone || (done[player.Status] && int64(at) <= deleteSince):
+			if err := s.store.DeindexPlayer(ctx, id); err != nil {
+				feLog.WithFields(log.Fields{"error": err.Error(), "playerid": id}).Error("Unable to deindex idle player")
+				continue
+			}
+			if err := s.store.DeletePlayer(ctx, id); err != nil {
+				feLog.WithFields(log.Fields{"error": err.Error(), "playerid": id}).Error("Unable to delete idle player")
+				continue
+			}
+			deleted++
+		case !done[player.Status]:
+			s.expirePlayer(id)
+			expired++
+		}
+	}
+
+	stats.Record(ctx, FeExpiredPlayers.M(int64(expired)), FeReclaimedPlayers.M(int64(deleted)))
+	feLog.WithFields(log.Fields{"idle": len(idle), "expired": expired, "deleted": deleted}).Info("Swept idle players")
+	return expired, deleted
+}
+
+// anyMemberLive reports whether any of a party's members is in state
+// storage and not idle.
+func (s *frontendAPI) anyMemberLive(ctx context.Context, members []string, idle map[string]float64) bool {
+	for _, id := range members {
+		if _, ok := idle[id]; ok {
+			continue
+		}
+		if err := s.store.RetrievePlayer(ctx, &pb.Player{Id: id}); err == nil {
+			return true
+		}
+	}
+	return false
+}
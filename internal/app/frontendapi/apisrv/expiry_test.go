@@ -0,0 +1,130 @@
+package apisrv
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage/memory"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSweep(t *testing.T) {
+	ctx := context.Background()
+	cfg := viper.New()
+	cfg.Set("playerIndices", []string{"mmr"})
+	cfg.Set("playerExpiry.idleTime", 100)
+	cfg.Set("playerExpiry.gracePeriod", 50)
+	store := memory.New(cfg)
+	s := &frontendAPI{cfg: cfg, store: store}
+	for _, id := range []string{"queued", "cancelled", "assigned"} {
+		store.CreatePlayer(ctx, &pb.Player{Id: id, Properties: `{"mmr": 1}`, Status: pb.PlayerStatus_QUEUED.String()})
+	}
+	statestorage.SetPlayerStatus(ctx, store, pb.PlayerStatus_CANCELLED, []string{"cancelled"})
+	statestorage.SetPlayerStatus(ctx, store, pb.PlayerStatus_ASSIGNED, []string{"assigned"})
+	now := time.Now()
+
+	if expired, deleted := s.sweep(ctx, now); expired != 0 || deleted != 0 {
+		t.Errorf("fresh players: got %v expired, %v deleted, want none", expired, deleted)
+	}
+
+	// Idle players still queued are expired and removed from matchmaking.
+	if expired, deleted := s.sweep(ctx, now.Add(110*time.Second)); expired != 1 || deleted != 0 {
+		t.Errorf("idle players: got %v expired, %v deleted, want 1, 0", expired, deleted)
+	}
+	player := &pb.Player{Id: "queued"}
+	store.RetrievePlayer(ctx, player)
+	if player.Status != pb.PlayerStatus_EXPIRED.String() {
+		t.Errorf("got status %v, want EXPIRED", player.Status)
+	}
+	if n, _ := store.CountIndexRange(ctx, &pb.Filter{Attribute: "mmr"}); n != 2 {
+		t.Errorf("got %v players indexed, want 2", n)
+	}
+
+	// After the grace period, every player whose matchmaking is over is deleted.
+	if expired, deleted := s.sweep(ctx, now.Add(170*time.Second)); expired != 0 || deleted != 3 {
+		t.Errorf("after grace period: got %v expired, %v deleted, want 0, 3", expired, deleted)
+	}
+	if n, _ := store.CountIndexRange(ctx, &pb.Filter{Attribute: "OM_METADATA.accessed"}); n != 0 {
+		t.Errorf("got %v players in the metadata index, want 0", n)
+	}
+}
+
+func TestSweepParty(t *testing.T) {
+	ctx := context.Background()
+	cfg := viper.New()
+	cfg.Set("playerIndices", []string{"mmr"})
+	cfg.Set("playerExpiry.idleTime", 1)
+	store := memory.New(cfg)
+	s := &frontendAPI{cfg: cfg, store: store}
+	for _, id := range []string{"m1", "m2"} {
+		store.CreatePlayer(ctx, &pb.Player{Id: id, Properties: `{"mmr": 1}`, Status: pb.PlayerStatus_QUEUED.String()})
+	}
+	if _, err := s.CreateParty(ctx, &pb.Party{Id: "party", Members: []string{"m1", "m2"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only m1 keeps heartbeating, which keeps the party alive.
+	time.Sleep(2 * time.Second)
+	s.Heartbeat(ctx, &pb.Player{Id: "m1"})
+	if expired, _ := s.sweep(ctx, time.Now()); expired != 1 {
+		t.Errorf("live member: got %v expired, want only m2", expired)
+	}
+	party := &pb.Player{Id: "party"}
+	store.RetrievePlayer(ctx, party)
+	if party.Status != pb.PlayerStatus_QUEUED.String() {
+		t.Errorf("live member: got party status %v, want QUEUED", party.Status)
+	}
+
+	// Once every member is idle, the party expires.  Expiring the party
+	// expires its members too, so how many are counted depends on the
+	// order the sweep finds them in.
+	s.sweep(ctx, time.Now().Add(10*time.Second))
+	for _, id := range []string{"party", "m1"} {
+		player := &pb.Player{Id: id}
+		store.RetrievePlayer(ctx, player)
+		if player.Status != pb.PlayerStatus_EXPIRED.String() {
+			t.Errorf("idle members: got %v status %v, want EXPIRED", id, player.Status)
+		}
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	ctx := context.Background()
+	cfg := viper.New()
+	cfg.Set("playerIndices", []string{"mmr"})
+	store := memory.New(cfg)
+	s := &frontendAPI{cfg: cfg, store: store}
+	store.CreatePlayer(ctx, &pb.Player{Id: "queued", Properties: `{"mmr": 1}`})
+
+	if _, err := s.Heartbeat(ctx, &pb.Player{Id: "queued"}); err != nil {
+		t.Errorf("Heartbeat(queued) = %v, want nil", err)
+	}
+	if _, err := s.Heartbeat(ctx, &pb.Player{Id: "unknown"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Heartbeat(unknown) = %v, want NotFound", err)
+	}
+
+	// Heartbeats don't add players who aren't in state storage.
+	results, _ := s.HeartbeatPlayers(ctx, &pb.Roster{Players: []*pb.Player{{Id: "queued"}, {Id: "unknown"}}})
+	if !results.Results[0].Success || results.Results[1].Success {
+		t.Errorf("HeartbeatPlayers = %v, want success then failure", results.Results)
+	}
+	if n, _ := store.CountIndexRange(ctx, &pb.Filter{Attribute: "OM_METADATA.accessed"}); n != 1 {
+		t.Errorf("got %v players in the metadata index, want 1", n)
+	}
+
+	// Players whose matchmaking is over can't heartbeat.
+	store.CreatePlayer(ctx, &pb.Player{Id: "assigned", Properties: `{"mmr": 1}`})
+	statestorage.SetPlayerStatus(ctx, store, pb.PlayerStatus_ASSIGNED, []string{"assigned"})
+	if _, err := s.Heartbeat(ctx, &pb.Player{Id: "assigned"}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Heartbeat(assigned) = %v, want FailedPrecondition", err)
+	}
+	results, _ = s.HeartbeatPlayers(ctx, &pb.Roster{Players: []*pb.Player{{Id: "assigned"}, {Id: "queued"}}})
+	if results.Results[0].Success || !results.Results[1].Success {
+		t.Errorf("HeartbeatPlayers = %v, want failure then success", results.Results)
+	}
+}
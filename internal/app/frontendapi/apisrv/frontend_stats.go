@@ -69,6 +69,10 @@ var (
 
 	// Failure instrumentation
 	FeFailures = stats.Int64("frontendapi/failures_total", "Number of Frontend API failures", "1")
+
+	// Expiry sweeper instrumentation
+	FeExpiredPlayers   = stats.Int64("frontendapi/expired_players_total", "Number of idle players expired by the sweeper", "1")
+	FeReclaimedPlayers = stats.Int64("frontendapi/reclaimed_players_total", "Number of idle players deleted from state storage by the sweeper", "1")
 )
 
 var (
@@ -136,6 +140,20 @@ var (
 		Description: "The number of failures",
 		Aggregation: view.Count(),
 	}
+
+	FeExpiredPlayersView = &view.View{
+		Name:        "frontend/expired_players",
+		Measure:     FeExpiredPlayers,
+		Description: "The number of idle players expired",
+		Aggregation: view.Sum(),
+	}
+
+	FeReclaimedPlayersView = &view.View{
+		Name:        "frontend/reclaimed_players",
+		Measure:     FeReclaimedPlayers,
+		Description: "The number of idle players deleted from state storage",
+		Aggregation: view.Sum(),
+	}
 )
 
 // DefaultFrontendAPIViews are the default frontend API OpenCensus measure views.
@@ -146,4 +164,6 @@ var DefaultFrontendAPIViews = []*view.View{
 	FeErrorCountView,
 	FeLogCountView,
 	FeFailureCountView,
+	FeExpiredPlayersView,
+	FeReclaimedPlayersView,
 }
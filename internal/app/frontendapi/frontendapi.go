@@ -85,15 +85,15 @@ func RunApplication() {
 	initializeApplication()
 
 	// Connect to redis
-	pool, err := redishelpers.ConnectionPool(cfg)
+	store, err := redishelpers.New(cfg)
 	if err != nil {
 		feLog.Fatal(err)
 	}
-	defer pool.Close()
+	defer store.Close()
 
 	// Instantiate the gRPC server with the connections we've made
 	feLog.Info("Attempting to start gRPC server")
-	srv := apisrv.New(cfg, pool)
+	srv := apisrv.New(cfg, store)
 
 	// Run the gRPC server
 	err = srv.Open()
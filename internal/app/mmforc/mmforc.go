@@ -33,12 +33,13 @@ import (
 	"github.com/GoogleCloudPlatform/open-match/config"
 	"github.com/GoogleCloudPlatform/open-match/internal/logging"
 	"github.com/GoogleCloudPlatform/open-match/internal/metrics"
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage"
 	redishelpers "github.com/GoogleCloudPlatform/open-match/internal/statestorage/redis"
 	"github.com/tidwall/gjson"
 	"go.opencensus.io/stats"
 	"go.opencensus.io/tag"
 
-	"github.com/gomodule/redigo/redis"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	batchv1 "k8s.io/api/batch/v1"
@@ -47,7 +48,6 @@ import (
 
 	//"k8s.io/kubernetes/pkg/api"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
 
 	// Uncomment the following line to load the gcp plugin (only required to authenticate against GKE clusters).
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
@@ -115,37 +115,71 @@ func initializeApplication() {
 
 }
 
+// MMFJob holds the names and state storage keys for one matchmaking function
+// run.  These are passed to the MMF, usually as the MMF_* environment variables.
+type MMFJob struct {
+	Name       string // Unique name of this run, used for the k8s job name.
+	ProfileID  string // Key of the profile to read.
+	ProposalID string // Key to write the proposal to.
+	RequestID  string // Match object ID generated by the Backend API.
+	ResultsID  string // Key the Backend API is watching for results; also used for errors.
+	Timestamp  string // Epoch timestamp of when this run was started.
+}
+
 // RunApplication is a hook for the main() method in the main executable.
 func RunApplication() {
 	initializeApplication()
 
-	pool, err := redishelpers.ConnectionPool(cfg)
+	store, err := redishelpers.New(cfg)
 	if err != nil {
 		mmforcLog.Fatal(err)
 	}
-
-	redisConn := pool.Get()
-	defer redisConn.Close()
-
-	// Get k8s credentials so we can starts k8s Jobs
-	mmforcLog.Info("Attempting to acquire k8s credentials")
-	config, err := rest.InClusterConfig()
-	if err != nil {
-		panic(err)
+	defer store.Close()
+
+	// k8s credentials are only acquired once a k8s Job is started.
+	k8s := &K8sClient{}
+
+	var evaluatorRunner EvaluatorRunner
+	switch cfg.GetString("evaluator.runner") {
+	case "", RunnerK8s:
+		evaluatorRunner = NewK8sEvaluatorRunner(cfg, k8s)
+	case RunnerGRPC:
+		evaluatorRunner, err = NewGRPCEvaluatorRunner(cfg, store)
+	default:
+		err = errUnknownEvaluatorRunner
 	}
-	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		panic(err)
+		mmforcLog.WithFields(log.Fields{
+			"error":  err.Error(),
+			"runner": cfg.GetString("evaluator.runner"),
+		}).Fatal("Unable to set up evaluator runner")
 	}
-	mmforcLog.Info("K8s credentials acquired")
 
+	runners := &Runners{
+		Functions: map[string]FunctionRunner{
+			RunnerK8s:  NewK8sRunner(cfg, k8s),
+			RunnerHTTP: NewHTTPRunner(cfg),
+			RunnerGRPC: NewGRPCRunner(cfg),
+			RunnerExec: NewExecRunner(cfg),
+		},
+		DefaultFunction: cfg.GetString("functionRunners.default"),
+		Evaluator:       evaluatorRunner,
+	}
+	defer runners.Close()
+	Run(context.Background(), cfg, store, runners)
+}
+
+// Run is the main orchestrator loop; it kicks off matchmaker functions for
+// profiles in the profile queue and an evaluator when proposals are in the
+// proposals queue.  It returns when the context is cancelled.
+func Run(runCtx context.Context, cfg *viper.Viper, store statestorage.Service, runners *Runners) {
 	start := time.Now()
 	checkProposals := true
 
 	// main loop; kick off matchmaker functions for profiles in the profile
 	// queue and an evaluator when proposals are in the proposals queue
-	for {
-		ctx, cancel := context.WithCancel(context.Background())
+	for runCtx.Err() == nil {
+		ctx, cancel := context.WithCancel(runCtx)
 		_ = cancel
 
 		// Get profiles and kick off a job for each
@@ -156,8 +190,8 @@ func RunApplication() {
 			"component":        "statestorage",
 		}).Debug("Retreiving match profiles")
 
-		results, err := redis.Strings(redisConn.Do("SPOP",
-			cfg.GetString("queues.profiles.name"), cfg.GetInt("queues.profiles.pullCount")))
+		results, err := store.PopQueue(context.Background(),
+			cfg.GetString("queues.profiles.name"), cfg.GetInt("queues.profiles.pullCount"))
 		if err != nil {
 			panic(err)
 		}
@@ -169,9 +203,9 @@ func RunApplication() {
 
 			for _, profile := range results {
 				// Kick off the job asynchrnously
-				go mmfunc(ctx, profile, cfg, clientset, pool)
+				go mmfunc(ctx, profile, cfg, runners, store)
 				// Count the number of jobs running
-				redishelpers.Increment(context.Background(), pool, "concurrentMMFs")
+				store.IncrementCounter(context.Background(), "concurrentMMFs")
 			}
 		} else {
 			mmforcLog.WithFields(log.Fields{
@@ -181,23 +215,21 @@ func RunApplication() {
 
 		// Check to see if we should run the evaluator.
 		// Get number of running MMFs
-		r, err := redishelpers.Retrieve(context.Background(), pool, "concurrentMMFs")
+		numRunning, err := store.RetrieveCounter(context.Background(), "concurrentMMFs")
 
 		if err != nil {
-			if err.Error() == "redigo: nil returned" {
+			if err == statestorage.ErrNotFound {
 				// No MMFs have run since we last evaluated; reset timer and loop
 				mmforcLog.Debug("Number of concurrentMMFs is nil")
 				start = time.Now()
 				time.Sleep(1000 * time.Millisecond)
+			} else {
+				mmforcLog.WithFields(log.Fields{
+					"error": err.Error(),
+				}).Error("Issue retrieving number of currently running MMFs")
 			}
 			continue
 		}
-		numRunning, err := strconv.Atoi(r)
-		if err != nil {
-			mmforcLog.WithFields(log.Fields{
-				"error": err.Error(),
-			}).Error("Issue retrieving number of currently running MMFs")
-		}
 
 		// We are ready to evaluate either when all MMFs are complete, or the
 		// timeout is reached.
@@ -232,7 +264,7 @@ func RunApplication() {
 			// evaluator if there are none.
 			checkProposals = false
 			mmforcLog.Info("Checking statestorage for match object proposals")
-			results, err := redishelpers.Count(context.Background(), pool, cfg.GetString("queues.proposals.name"))
+			results, err := store.CountQueue(context.Background(), cfg.GetString("queues.proposals.name"))
 			switch {
 			case err != nil:
 				mmforcLog.WithFields(log.Fields{
@@ -244,13 +276,18 @@ func RunApplication() {
 				mmforcLog.WithFields(log.Fields{
 					"numProposals": results,
 				}).Info("Proposals available, evaluating!")
-				go evaluator(ctx, cfg, clientset)
+				go evaluator(ctx, cfg, runners.Evaluator)
 			}
-			err = redishelpers.Delete(context.Background(), pool, "concurrentMMFs")
-			if err != nil {
-				mmforcLog.WithFields(log.Fields{
-					"error": err.Error(),
-				}).Error("Error deleting concurrent MMF counter!")
+			// Only reset the counter once all MMFs have finished.  If some
+			// are still running after the interval, their proposals
+			// trigger another evaluation as soon as they finish.
+			if numRunning <= 0 {
+				err = store.DeleteCounter(context.Background(), "concurrentMMFs")
+				if err != nil {
+					mmforcLog.WithFields(log.Fields{
+						"error": err.Error(),
+					}).Error("Error deleting concurrent MMF counter!")
+				}
 			}
 			start = time.Now()
 		}
@@ -262,41 +299,49 @@ func RunApplication() {
 		mmforcLog.WithFields(log.Fields{
 			"ms": mainSleep,
 		}).Info("Sleeping...")
-		time.Sleep(time.Duration(mainSleep) * time.Millisecond)
+		select {
+		case <-runCtx.Done():
+		case <-time.After(time.Duration(mainSleep) * time.Millisecond):
+		}
 	} // End main for loop
 }
 
-// mmfunc generates a k8s job that runs the specified mmf container image.
+// mmfunc reads the profile for a backend request and runs a matchmaking function for it with the runner the profile selects.
 // resultsID is the redis key that the Backend API is monitoring for results; we can 'short circuit' and write errors directly to this key if we can't run the MMF for some reason.
-func mmfunc(ctx context.Context, resultsID string, cfg *viper.Viper, clientset *kubernetes.Clientset, pool *redis.Pool) {
+func mmfunc(ctx context.Context, resultsID string, cfg *viper.Viper, runners *Runners, store statestorage.Service) {
 
-	// Generate the various keys/names, some of which must be populated to the k8s job.
-	imageName := cfg.GetString("defaultImages.mmf.name") + ":" + cfg.GetString("defaultImages.mmf.tag")
+	// Generate the various keys/names, some of which must be populated to the MMF.
 	jobType := "mmf"
 	ids := strings.Split(resultsID, ".") // comes in as dot-concatinated moID and profID.
 	moID := ids[0]
 	profID := ids[1]
 	timestamp := strconv.Itoa(int(time.Now().Unix()))
-	jobName := timestamp + "." + moID + "." + profID + "." + jobType
-	propID := "proposal." + timestamp + "." + moID + "." + profID
+	job := &MMFJob{
+		Name:       timestamp + "." + moID + "." + profID + "." + jobType,
+		ProfileID:  profID,
+		ProposalID: "proposal." + timestamp + "." + moID + "." + profID,
+		RequestID:  moID,
+		ResultsID:  resultsID,
+		Timestamp:  timestamp,
+	}
 
 	// Extra fields for structured logging
-	lf := log.Fields{"jobName": jobName}
+	lf := log.Fields{"jobName": job.Name}
 	if cfg.GetBool("debug") { // Log a lot more info.
 		lf = log.Fields{
 			"jobType":             jobType,
 			"backendMatchObject":  moID,
 			"profile":             profID,
 			"jobTimestamp":        timestamp,
-			"jobName":             jobName,
+			"jobName":             job.Name,
 			"profileImageJSONKey": cfg.GetString("jsonkeys.mmfImage"),
 		}
 	}
 	mmfuncLog := mmforcLog.WithFields(lf)
 
-	// Read the full profile from redis and access any keys that are important to deciding how MMFs are run.
-	// TODO: convert this to using redispb and directly access the protobuf message instead of retrieving as a map?
-	profile, err := redishelpers.RetrieveAll(ctx, pool, profID)
+	// Read the full profile from state storage and access any keys that are important to deciding how MMFs are run.
+	profile := &pb.MatchObject{Id: profID}
+	err := store.RetrieveMatchObject(ctx, profile)
 	if err != nil {
 		// Log failure to read this profile and return - won't run an MMF for an unreadable profile.
 		mmfuncLog.WithFields(log.Fields{"error": err.Error()}).Error("Failure retreiving profile from statestorage")
@@ -304,67 +349,36 @@ func mmfunc(ctx context.Context, resultsID string, cfg *viper.Viper, clientset *
 	}
 
 	// Got profile from state storage, make sure it is valid
-	if !gjson.Valid(profile["properties"]) {
+	if !gjson.Valid(profile.Properties) {
 		mmforcLog.WithFields(log.Fields{
-			"jobName": jobName,
+			"jobName": job.Name,
 		}).Warn("Profile JSON was invalid")
 		return
 	}
 
-	// Determine what kind of job orchestration the profile requires
-	profileHostName := gjson.Get(profile["properties"], cfg.GetString("jsonkeys.mmfHostName"))
-	profileImage := gjson.Get(profile["properties"], cfg.GetString("jsonkeys.mmfImage"))
-
-	// If a hostname was provided, try making a restful POST to the existing endpoint
-	if profileHostName.Exists() && len(profileHostName.String()) > 0 {
-		port := "80"
-		profilePort := gjson.Get(profile["properties"], cfg.GetString("jsonkeys.mmfPort"))
-		if profilePort.Exists() {
-			port = profilePort.String()
-		} else {
-			mmfuncLog.Debug("No port specified in configured properties json key, using default port instead")
-		}
-
-		mmforcLog.WithFields(log.Fields{
-			"jobName":  jobName,
-			"hostName": profileHostName,
-			"port":     port,
-		}).Debug("Profile specifies a host name for running the match function as a POST rest service call")
-
-		// Make a rest service call
-		err = callRestFunction(profileHostName.String(), port, jobName, profID, moID, propID, resultsID, timestamp)
-
-	} else {
-		// Otherwise, if a profile image is available, use a k8s job
-		if profileImage.Exists() && len(profileImage.String()) > 0 {
-			imageName = profileImage.String()
-		} else {
-			mmfuncLog.Warn("Failed to read image name from profile at configured json key, using default image instead")
-		}
-
-		mmfuncLog = mmfuncLog.WithFields(log.Fields{"containerImage": imageName})
-		mmfuncLog.Info("Attempting to create mmf k8s job")
-
-		// Kick off k8s job
-		envvars := []apiv1.EnvVar{
-			{Name: "MMF_PROFILE_ID", Value: profID},
-			{Name: "MMF_PROPOSAL_ID", Value: propID},
-			{Name: "MMF_REQUEST_ID", Value: moID},
-			{Name: "MMF_ERROR_ID", Value: resultsID},
-			{Name: "MMF_TIMESTAMP", Value: timestamp},
-			// Deprecated: 0.1.0 compatibility config vars.
-			{Name: "DEBUG", Value: cfg.GetString("debug")},
-			{Name: "JSONKEYS_ROSTERS", Value: cfg.GetString("jsonkeys.rosters")},
-			{Name: "JSONKEYS_MMFIMAGE", Value: cfg.GetString("jsonkeys.mmfImage")},
-			{Name: "JSONKEYS_POOLS", Value: cfg.GetString("jsonkeys.pools")},
-		}
-		err = submitJob(cfg, clientset, jobType, jobName, imageName, envvars)
+	runnerName, runner, err := runners.functionRunner(cfg, profile)
+	mmfuncLog = mmfuncLog.WithFields(log.Fields{"runner": runnerName})
+	if err == nil {
+		mmfuncLog.Debug("Running MMF")
+		err = runner.Run(ctx, job, profile)
 	}
-
 	if err != nil {
 		// Record failure & log
 		stats.Record(ctx, mmforcMmfFailures.M(1))
 		mmfuncLog.WithFields(log.Fields{"error": err.Error()}).Error("MMF submission failure!")
+
+		// The MMF won't write a proposal, so short circuit: write the error
+		// to the results key so the Backend API isn't left waiting, and mark
+		// this MMF as finished for the evaluator.  The run context may
+		// already be cancelled, so don't use it for the cleanup.
+		err = store.CreateMatchObject(context.Background(), &pb.MatchObject{Id: resultsID, Error: err.Error()})
+		if err != nil {
+			mmfuncLog.WithFields(log.Fields{"error": err.Error()}).Error("Failure writing MMF error to statestorage")
+		}
+		_, err = store.DecrementCounter(context.Background(), "concurrentMMFs")
+		if err != nil {
+			mmfuncLog.WithFields(log.Fields{"error": err.Error()}).Error("Failure marking MMF finished in statestorage")
+		}
 	} else {
 		// Record Success
 		stats.Record(ctx, mmforcMmfs.M(1))
@@ -433,30 +447,20 @@ func callRestFunction(hostName string, strPort string, jobName string, profID st
 	return nil
 }
 
-// evaluator generates a k8s job that runs the specified evaluator container image.
-func evaluator(ctx context.Context, cfg *viper.Viper, clientset *kubernetes.Clientset) {
-
-	imageName := cfg.GetString("defaultImages.evaluator.name") + ":" + cfg.GetString("defaultImages.evaluator.tag")
+// evaluator runs the evaluator once.
+func evaluator(ctx context.Context, cfg *viper.Viper, runner EvaluatorRunner) {
 	// Generate the job name
 	timestamp := strconv.Itoa(int(time.Now().Unix()))
 	jobType := "evaluator"
 	jobName := timestamp + "." + jobType
 
-	mmforcLog.WithFields(log.Fields{
-		"jobName":        jobName,
-		"containerImage": imageName,
-	}).Info("Attempting to create evaluator k8s job")
-
-	// Kick off k8s job
-	envvars := []apiv1.EnvVar{{Name: "MMF_TIMESTAMP", Value: timestamp}}
-	err = submitJob(cfg, clientset, jobType, jobName, imageName, envvars)
+	err := runner.Run(ctx, jobName, timestamp)
 	if err != nil {
 		// Record failure & log
 		stats.Record(ctx, mmforcEvalFailures.M(1))
 		mmforcLog.WithFields(log.Fields{
-			"error":          err.Error(),
-			"jobName":        jobName,
-			"containerImage": imageName,
+			"error":   err.Error(),
+			"jobName": jobName,
 		}).Error("Evaluator job submission failure!")
 	} else {
 		// Record success
@@ -0,0 +1,507 @@
+/*
+Copyright 2019 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mmforc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	ev "github.com/GoogleCloudPlatform/open-match/internal/evaluator"
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"github.com/tidwall/gjson"
+	"go.opencensus.io/plugin/ocgrpc"
+	"google.golang.org/grpc"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// Names of the function runners a profile can select with the
+// 'jsonkeys.mmfRunner' config key.
+const (
+	RunnerK8s  = "k8s"
+	RunnerHTTP = "http"
+	RunnerGRPC = "grpc"
+	RunnerExec = "exec"
+)
+
+// errUnknownEvaluatorRunner is returned for evaluator.runner values other
+// than 'k8s' or 'grpc'.
+var errUnknownEvaluatorRunner = errors.New("unknown evaluator runner, must be 'k8s' or 'grpc'")
+
+// FunctionRunner runs a matchmaking function for one profile.  Run is called
+// in its own goroutine and may block until the MMF has finished.
+type FunctionRunner interface {
+	Run(ctx context.Context, job *MMFJob, profile *pb.MatchObject) error
+}
+
+// EvaluatorRunner runs one evaluation of the proposals in the proposal queue.
+type EvaluatorRunner interface {
+	Run(ctx context.Context, jobName string, timestamp string) error
+}
+
+// Runners holds everything the orchestrator loop can use to run matchmaking
+// functions and evaluators.
+type Runners struct {
+	// Functions are the function runners profiles can select from, by name.
+	Functions map[string]FunctionRunner
+	// DefaultFunction is the name of the runner to use for profiles that
+	// don't select one.
+	DefaultFunction string
+	// Evaluator runs the evaluator.
+	Evaluator EvaluatorRunner
+}
+
+// Close releases any resources held by the runners, such as connections to
+// MMF and evaluator servers.
+func (r *Runners) Close() error {
+	var err error
+	closers := make([]interface{}, 0, len(r.Functions)+1)
+	for _, runner := range r.Functions {
+		closers = append(closers, runner)
+	}
+	closers = append(closers, r.Evaluator)
+	for _, runner := range closers {
+		if c, ok := runner.(io.Closer); ok {
+			if cerr := c.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+		}
+	}
+	return err
+}
+
+// functionRunner returns the function runner the profile selects.  Profiles
+// select a runner by name using the 'jsonkeys.mmfRunner' key in their
+// properties.  For backwards compatibility, profiles that don't select a
+// runner but provide an MMF host name use the HTTP runner.
+func (r *Runners) functionRunner(cfg *viper.Viper, profile *pb.MatchObject) (string, FunctionRunner, error) {
+	name := r.DefaultFunction
+	if v := gjson.Get(profile.Properties, cfg.GetString("jsonkeys.mmfRunner")); cfg.IsSet("jsonkeys.mmfRunner") && v.String() != "" {
+		name = v.String()
+	} else if v := gjson.Get(profile.Properties, cfg.GetString("jsonkeys.mmfHostName")); cfg.IsSet("jsonkeys.mmfHostName") && v.String() != "" {
+		name = RunnerHTTP
+	}
+
+	runner, ok := r.Functions[name]
+	if !ok {
+		return name, nil, fmt.Errorf("function runner '%v' is not available", name)
+	}
+	return name, runner, nil
+}
+
+// envVar is an environment variable passed to an MMF.
+type envVar struct {
+	name  string
+	value string
+}
+
+// jobEnv returns the environment variables that pass the job to MMFs run as
+// Kubernetes Jobs or subprocesses.
+func jobEnv(cfg *viper.Viper, job *MMFJob) []envVar {
+	return []envVar{
+		{"MMF_PROFILE_ID", job.ProfileID},
+		{"MMF_PROPOSAL_ID", job.ProposalID},
+		{"MMF_REQUEST_ID", job.RequestID},
+		{"MMF_ERROR_ID", job.ResultsID},
+		{"MMF_TIMESTAMP", job.Timestamp},
+		// Deprecated: 0.1.0 compatibility config vars.
+		{"DEBUG", cfg.GetString("debug")},
+		{"JSONKEYS_ROSTERS", cfg.GetString("jsonkeys.rosters")},
+		{"JSONKEYS_MMFIMAGE", cfg.GetString("jsonkeys.mmfImage")},
+		{"JSONKEYS_POOLS", cfg.GetString("jsonkeys.pools")},
+	}
+}
+
+// profileHost returns the host and port of the MMF service a profile
+// specifies, using defaultPort if the profile doesn't set a port.
+func profileHost(cfg *viper.Viper, profile *pb.MatchObject, defaultPort string) (string, string, error) {
+	host := gjson.Get(profile.Properties, cfg.GetString("jsonkeys.mmfHostName")).String()
+	if !cfg.IsSet("jsonkeys.mmfHostName") || host == "" {
+		return "", "", errors.New("profile does not specify an MMF host name")
+	}
+	port := defaultPort
+	if p := gjson.Get(profile.Properties, cfg.GetString("jsonkeys.mmfPort")); cfg.IsSet("jsonkeys.mmfPort") && p.Exists() {
+		port = p.String()
+	}
+	return host, port, nil
+}
+
+// K8sClient builds the Kubernetes clientset the first time a k8s runner
+// needs it, so an orchestrator that only uses other runners can run outside
+// a cluster.  The k8s runners of an orchestrator share one K8sClient.
+type K8sClient struct {
+	once      sync.Once
+	clientset *kubernetes.Clientset
+	err       error
+}
+
+// Clientset returns the clientset for the cluster the orchestrator runs in.
+func (c *K8sClient) Clientset() (*kubernetes.Clientset, error) {
+	c.once.Do(func() {
+		mmforcLog.Info("Attempting to acquire k8s credentials")
+		config, err := rest.InClusterConfig()
+		if err != nil {
+			c.err = err
+			return
+		}
+		c.clientset, c.err = kubernetes.NewForConfig(config)
+		if c.err == nil {
+			mmforcLog.Info("K8s credentials acquired")
+		}
+	})
+	return c.clientset, c.err
+}
+
+// k8sRunner runs MMFs as Kubernetes Jobs, using the container image the
+// profile specifies or the default MMF image.
+type k8sRunner struct {
+	cfg    *viper.Viper
+	client *K8sClient
+}
+
+// NewK8sRunner returns a FunctionRunner that runs MMFs as Kubernetes Jobs.
+func NewK8sRunner(cfg *viper.Viper, client *K8sClient) FunctionRunner {
+	return &k8sRunner{cfg: cfg, client: client}
+}
+
+// Run generates a k8s job that runs the specified mmf container image.
+func (r *k8sRunner) Run(ctx context.Context, job *MMFJob, profile *pb.MatchObject) error {
+	clientset, err := r.client.Clientset()
+	if err != nil {
+		return err
+	}
+	imageName := r.cfg.GetString("defaultImages.mmf.name") + ":" + r.cfg.GetString("defaultImages.mmf.tag")
+	mmfuncLog := mmforcLog.WithFields(log.Fields{"jobName": job.Name})
+
+	// If a profile image is available, use it instead of the default
+	profileImage := gjson.Get(profile.Properties, r.cfg.GetString("jsonkeys.mmfImage"))
+	if profileImage.Exists() && len(profileImage.String()) > 0 {
+		imageName = profileImage.String()
+	} else {
+		mmfuncLog.Warn("Failed to read image name from profile at configured json key, using default image instead")
+	}
+
+	mmfuncLog = mmfuncLog.WithFields(log.Fields{"containerImage": imageName})
+	mmfuncLog.Info("Attempting to create mmf k8s job")
+
+	// Kick off k8s job
+	envvars := make([]apiv1.EnvVar, 0)
+	for _, e := range jobEnv(r.cfg, job) {
+		envvars = append(envvars, apiv1.EnvVar{Name: e.name, Value: e.value})
+	}
+	return submitJob(r.cfg, clientset, "mmf", job.Name, imageName, envvars)
+}
+
+// httpRunner runs MMFs with a REST call to the host the profile specifies.
+type httpRunner struct {
+	cfg *viper.Viper
+}
+
+// NewHTTPRunner returns a FunctionRunner that runs MMFs with an HTTP POST to
+// the /api/function endpoint of the host the profile specifies.
+func NewHTTPRunner(cfg *viper.Viper) FunctionRunner {
+	return &httpRunner{cfg: cfg}
+}
+
+// Run makes a restful POST to the MMF endpoint.
+func (r *httpRunner) Run(ctx context.Context, job *MMFJob, profile *pb.MatchObject) error {
+	host, port, err := profileHost(r.cfg, profile, "80")
+	if err != nil {
+		return err
+	}
+
+	mmforcLog.WithFields(log.Fields{
+		"jobName":  job.Name,
+		"hostName": host,
+		"port":     port,
+	}).Debug("Profile specifies a host name for running the match function as a POST rest service call")
+
+	return callRestFunction(host, port, job.Name, job.ProfileID, job.RequestID, job.ProposalID, job.ResultsID, job.Timestamp)
+}
+
+// grpcRunner runs MMFs by calling Function.Run on the gRPC server at the host
+// the profile specifies.  MMF servers are expected to be long-lived, so one
+// connection per server is kept open and shared by all calls to it.
+type grpcRunner struct {
+	cfg *viper.Viper
+
+	mu    sync.Mutex
+	conns map[string]*grpc.ClientConn
+}
+
+// NewGRPCRunner returns a FunctionRunner that runs MMFs by calling the
+// Function gRPC service (api/protobuf-spec/function.proto) at the host the
+// profile specifies.  The port defaults to 'api.functions.port'.
+//
+// Each call has a deadline of one evaluator interval, so an MMF that can't
+// get its proposal in before the next evaluator run is cancelled.
+func NewGRPCRunner(cfg *viper.Viper) FunctionRunner {
+	return &grpcRunner{cfg: cfg, conns: make(map[string]*grpc.ClientConn)}
+}
+
+// conn returns the shared connection to the MMF server, dialing it if this is
+// the first call to that server.
+func (r *grpcRunner) conn(address string) (*grpc.ClientConn, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if conn, ok := r.conns[address]; ok {
+		return conn, nil
+	}
+
+	// Dialing doesn't block; the connection is established (and
+	// re-established if it drops) in the background.
+	conn, err := grpc.Dial(address, grpc.WithInsecure(), grpc.WithStatsHandler(&ocgrpc.ClientHandler{}))
+	if err != nil {
+		return nil, err
+	}
+	r.conns[address] = conn
+	return conn, nil
+}
+
+// Run calls Function.Run and waits for the result.  The MMF is complete
+// once the call returns; an unsuccessful Result is returned as an error.
+func (r *grpcRunner) Run(ctx context.Context, job *MMFJob, profile *pb.MatchObject) error {
+	host, port, err := profileHost(r.cfg, profile, r.cfg.GetString("api.functions.port"))
+	if err != nil {
+		return err
+	}
+	address := host + ":" + port
+
+	mmforcLog.WithFields(log.Fields{
+		"jobName": job.Name,
+		"address": address,
+	}).Debug("Calling match function gRPC service")
+
+	conn, err := r.conn(address)
+	if err != nil {
+		return err
+	}
+
+	if interval := r.cfg.GetInt("evaluator.interval"); interval > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(interval)*time.Second)
+		defer cancel()
+	}
+
+	result, err := pb.NewFunctionClient(conn).Run(ctx, &pb.Arguments{
+		Request: &pb.Request{
+			ProfileId:  job.ProfileID,
+			ProposalId: job.ProposalID,
+			RequestId:  job.RequestID,
+			ErrorId:    job.ResultsID,
+			Timestamp:  job.Timestamp,
+		},
+		Matchobject: profile,
+	})
+	if err != nil {
+		return err
+	}
+	if !result.Success {
+		return errors.New(result.Error)
+	}
+	return nil
+}
+
+// Close closes the connections to all MMF servers.
+func (r *grpcRunner) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var err error
+	for address, conn := range r.conns {
+		if cerr := conn.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+		delete(r.conns, address)
+	}
+	return err
+}
+
+// execRunner runs MMFs as local subprocesses.
+type execRunner struct {
+	cfg *viper.Viper
+}
+
+// NewExecRunner returns a FunctionRunner that runs the command configured in
+// 'functionRunners.exec.command' as a subprocess for every MMF.  The job is
+// passed in the same MMF_* environment variables a Kubernetes Job would get,
+// along with the address of the MMLogic API.  The command is only ever read
+// from the config, never from a profile.
+func NewExecRunner(cfg *viper.Viper) FunctionRunner {
+	return &execRunner{cfg: cfg}
+}
+
+// Run runs the MMF command and waits for it to exit.
+func (r *execRunner) Run(ctx context.Context, job *MMFJob, profile *pb.MatchObject) error {
+	command := r.cfg.GetStringSlice("functionRunners.exec.command")
+	if len(command) == 0 {
+		return errors.New("no MMF command configured for the exec function runner")
+	}
+
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"OM_MMLOGICAPI_SERVICE_HOST="+r.cfg.GetString("api.mmlogic.hostname"),
+		"OM_MMLOGICAPI_SERVICE_PORT="+r.cfg.GetString("api.mmlogic.port"),
+	)
+	for _, e := range jobEnv(r.cfg, job) {
+		cmd.Env = append(cmd.Env, e.name+"="+e.value)
+	}
+
+	mmforcLog.WithFields(log.Fields{
+		"jobName": job.Name,
+		"command": command,
+	}).Info("Running MMF subprocess")
+	return cmd.Run()
+}
+
+// k8sEvaluatorRunner runs the evaluator as a Kubernetes Job.
+type k8sEvaluatorRunner struct {
+	cfg    *viper.Viper
+	client *K8sClient
+}
+
+// NewK8sEvaluatorRunner returns an EvaluatorRunner that runs the default
+// evaluator image as a Kubernetes Job.
+func NewK8sEvaluatorRunner(cfg *viper.Viper, client *K8sClient) EvaluatorRunner {
+	return &k8sEvaluatorRunner{cfg: cfg, client: client}
+}
+
+// Run generates a k8s job that runs the specified evaluator container image.
+func (r *k8sEvaluatorRunner) Run(ctx context.Context, jobName string, timestamp string) error {
+	clientset, err := r.client.Clientset()
+	if err != nil {
+		return err
+	}
+	imageName := r.cfg.GetString("defaultImages.evaluator.name") + ":" + r.cfg.GetString("defaultImages.evaluator.tag")
+
+	mmforcLog.WithFields(log.Fields{
+		"jobName":        jobName,
+		"containerImage": imageName,
+	}).Info("Attempting to create evaluator k8s job")
+
+	// Kick off k8s job
+	envvars := []apiv1.EnvVar{{Name: "MMF_TIMESTAMP", Value: timestamp}}
+	return submitJob(r.cfg, clientset, "evaluator", jobName, imageName, envvars)
+}
+
+// grpcEvaluatorRunner runs evaluations by streaming the proposals to an
+// Evaluator gRPC service, and applying the results it returns.
+type grpcEvaluatorRunner struct {
+	cfg   *viper.Viper
+	store statestorage.Service
+	conn  *grpc.ClientConn
+}
+
+// NewGRPCEvaluatorRunner returns an EvaluatorRunner that calls the Evaluator
+// service at api.evaluator.hostname and port.
+func NewGRPCEvaluatorRunner(cfg *viper.Viper, store statestorage.Service) (EvaluatorRunner, error) {
+	address := cfg.GetString("api.evaluator.hostname") + ":" + cfg.GetString("api.evaluator.port")
+	conn, err := grpc.Dial(address, grpc.WithInsecure(), grpc.WithStatsHandler(&ocgrpc.ClientHandler{}))
+	if err != nil {
+		return nil, err
+	}
+	return &grpcEvaluatorRunner{cfg: cfg, store: store, conn: conn}, nil
+}
+
+// Run calls Evaluator.Evaluate with every queued proposal.  If the call
+// fails, the proposals are put back in the queue for the next evaluation.
+func (r *grpcEvaluatorRunner) Run(ctx context.Context, jobName string, timestamp string) error {
+	proposals, err := ev.Load(ctx, r.cfg, r.store)
+	if err != nil || len(proposals) == 0 {
+		return err
+	}
+
+	approved, rejected, err := r.evaluate(ctx, proposals)
+	if err != nil {
+		ev.Unload(context.Background(), r.cfg, r.store, proposals)
+		return err
+	}
+	ev.Apply(ctx, r.cfg, r.store, approved, rejected)
+	return nil
+}
+
+// evaluate streams the proposals to the evaluator service and splits them
+// into those it approved and those it didn't.
+func (r *grpcEvaluatorRunner) evaluate(ctx context.Context, proposals []*ev.Proposal) ([]*ev.Proposal, []*ev.Proposal, error) {
+	if interval := r.cfg.GetInt("evaluator.interval"); interval > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(interval)*time.Second)
+		defer cancel()
+	}
+
+	stream, err := pb.NewEvaluatorClient(r.conn).Evaluate(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	byKey := make(map[string]*ev.Proposal, len(proposals))
+	for _, p := range proposals {
+		byKey[p.Key] = p
+		if err := stream.Send(p.Match); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, nil, err
+	}
+
+	approved := make([]*ev.Proposal, 0)
+	for {
+		match, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		p, ok := byKey[match.Id]
+		if !ok {
+			mmforcLog.WithFields(log.Fields{"proposal": match.Id}).Warn("Evaluator approved an unknown proposal")
+			continue
+		}
+		// The evaluator may have changed the match; re-read the players
+		// from what it returned.
+		if p, err = ev.NewProposal(p.Key, match); err != nil {
+			return nil, nil, err
+		}
+		approved = append(approved, p)
+		delete(byKey, match.Id)
+	}
+
+	rejected := make([]*ev.Proposal, 0, len(byKey))
+	for _, p := range proposals {
+		if _, ok := byKey[p.Key]; ok {
+			rejected = append(rejected, p)
+		}
+	}
+	return approved, rejected, nil
+}
+
+// Close closes the connection to the evaluator service.
+func (r *grpcEvaluatorRunner) Close() error {
+	return r.conn.Close()
+}
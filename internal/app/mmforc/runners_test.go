@@ -0,0 +1,186 @@
+package mmforc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	ev "github.com/GoogleCloudPlatform/open-match/internal/evaluator"
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage/memory"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeRunner struct{}
+
+func (fakeRunner) Run(ctx context.Context, job *MMFJob, profile *pb.MatchObject) error {
+	return nil
+}
+
+func TestFunctionRunnerSelection(t *testing.T) {
+	cfg := viper.New()
+	cfg.Set("jsonkeys.mmfRunner", "runner")
+	cfg.Set("jsonkeys.mmfHostName", "hostname")
+
+	runners := &Runners{
+		Functions: map[string]FunctionRunner{
+			RunnerK8s:  fakeRunner{},
+			RunnerHTTP: fakeRunner{},
+			RunnerGRPC: fakeRunner{},
+		},
+		DefaultFunction: RunnerK8s,
+	}
+
+	cases := []struct {
+		properties string
+		want       string
+		wantErr    bool
+	}{
+		{`{}`, RunnerK8s, false},
+		{`{"runner": "grpc", "hostname": "mmf"}`, RunnerGRPC, false},
+		{`{"hostname": "mmf"}`, RunnerHTTP, false},
+		{`{"runner": "exec"}`, RunnerExec, true},
+	}
+
+	for _, c := range cases {
+		name, runner, err := runners.functionRunner(cfg, &pb.MatchObject{Properties: c.properties})
+		if name != c.want || (err != nil) != c.wantErr || (runner == nil) != c.wantErr {
+			t.Errorf("%v: got (%v, %v, %v), want runner %v (error: %v)", c.properties, name, runner, err, c.want, c.wantErr)
+		}
+	}
+}
+
+type fakeFunctionServer struct {
+	result *pb.Result
+	delay  time.Duration
+}
+
+func (s *fakeFunctionServer) Run(ctx context.Context, args *pb.Arguments) (*pb.Result, error) {
+	time.Sleep(s.delay)
+	if args.Request.ProposalId != "proposal.1.mo.profile" || args.Matchobject.Id != "profile" {
+		return &pb.Result{Success: false, Error: "bad arguments"}, nil
+	}
+	return s.result, nil
+}
+
+func TestGRPCRunner(t *testing.T) {
+	fs := &fakeFunctionServer{result: &pb.Result{Success: true}}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	pb.RegisterFunctionServer(srv, fs)
+	go srv.Serve(ln)
+	defer srv.Stop()
+
+	cfg := viper.New()
+	cfg.Set("jsonkeys.mmfHostName", "hostname")
+	cfg.Set("jsonkeys.mmfPort", "port")
+	cfg.Set("evaluator.interval", 1)
+	runner := NewGRPCRunner(cfg).(*grpcRunner)
+	defer runner.Close()
+
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	profile := &pb.MatchObject{Id: "profile", Properties: `{"hostname": "127.0.0.1", "port": "` + port + `"}`}
+	job := &MMFJob{ProfileID: "profile", ProposalID: "proposal.1.mo.profile", RequestID: "mo", ResultsID: "mo.profile", Timestamp: "1"}
+
+	for i := 0; i < 2; i++ {
+		if err := runner.Run(context.Background(), job, profile); err != nil {
+			t.Errorf("call %v: got %v, want success", i, err)
+		}
+	}
+	if len(runner.conns) != 1 {
+		t.Errorf("got %v connections, want 1 shared connection", len(runner.conns))
+	}
+
+	fs.result = &pb.Result{Success: false, Error: "insufficient_players"}
+	if err := runner.Run(context.Background(), job, profile); err == nil || err.Error() != "insufficient_players" {
+		t.Errorf("unsuccessful result: got %v, want insufficient_players", err)
+	}
+
+	// Calls that take longer than an evaluator interval are cancelled.
+	fs.delay = 2 * time.Second
+	if err := runner.Run(context.Background(), job, profile); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("slow MMF: got %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestGRPCEvaluatorRunner(t *testing.T) {
+	cfg := viper.New()
+	cfg.Set("queues.proposals.name", "proposalq")
+	cfg.Set("queues.profiles.name", "profileq")
+	cfg.Set("ignoreLists.proposed.name", "proposed")
+	cfg.Set("evaluator.maxRequeues", 1)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	pb.RegisterEvaluatorServer(srv, ev.NewServer(cfg))
+	go srv.Serve(ln)
+	defer srv.Stop()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	cfg.Set("api.evaluator.hostname", host)
+	cfg.Set("api.evaluator.port", port)
+	store := memory.New(cfg)
+	runner, err := NewGRPCEvaluatorRunner(cfg, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer runner.(*grpcEvaluatorRunner).Close()
+
+	ctx := context.Background()
+	for key, players := range map[string][]string{
+		"proposal.1.a.profile": {"p1", "p2", "p3"},
+		"proposal.2.b.profile": {"p3"},
+	} {
+		roster := &pb.Roster{}
+		for _, id := range players {
+			roster.Players = append(roster.Players, &pb.Player{Id: id})
+		}
+		store.CreateMatchObject(ctx, &pb.MatchObject{Id: key, Rosters: []*pb.Roster{roster}})
+		store.PushQueue(ctx, "proposalq", key)
+	}
+
+	if err := runner.Run(ctx, "1.evaluator", "1"); err != nil {
+		t.Fatal(err)
+	}
+	a := &pb.MatchObject{Id: "a.profile"}
+	if err := store.RetrieveMatchObject(ctx, a); err != nil || len(a.Rosters) != 1 {
+		t.Errorf("got (%v, %v), want the larger match approved", a, err)
+	}
+	if requeued, _ := store.PopQueue(ctx, "profileq", 10); len(requeued) != 1 || requeued[0] != "b.profile" {
+		t.Errorf("got requeued %v, want b.profile", requeued)
+	}
+
+	// Proposals are returned to the queue if the evaluator can't be reached.
+	srv.Stop()
+	store.PushQueue(ctx, "proposalq", "proposal.3.c.profile")
+	store.CreateMatchObject(ctx, &pb.MatchObject{Id: "proposal.3.c.profile"})
+	if err := runner.Run(ctx, "2.evaluator", "2"); err == nil {
+		t.Error("got success from a stopped evaluator, want error")
+	}
+	if n, _ := store.CountQueue(ctx, "proposalq"); n != 1 {
+		t.Errorf("got %v queued proposals, want 1", n)
+	}
+}
+
+func TestK8sRunnerOutsideCluster(t *testing.T) {
+	// Runners are created without credentials; only running a k8s Job
+	// needs them, and fails outside a cluster.
+	k8s := &K8sClient{}
+	runner := NewK8sRunner(viper.New(), k8s)
+	if err := runner.Run(context.Background(), &MMFJob{Name: "job"}, &pb.MatchObject{}); err == nil {
+		t.Error("got nil, want error running a k8s Job outside a cluster")
+	}
+	if _, err := k8s.Clientset(); err == nil {
+		t.Error("got nil, want the same error for the shared client")
+	}
+}
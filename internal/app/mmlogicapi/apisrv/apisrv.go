@@ -26,20 +26,19 @@ import (
 	"fmt"
 	"math"
 	"net"
-	"strconv"
 	"time"
 
+	"github.com/GoogleCloudPlatform/open-match/internal/evaluator"
 	"github.com/GoogleCloudPlatform/open-match/internal/metrics"
 	"github.com/GoogleCloudPlatform/open-match/internal/pb"
 	"github.com/GoogleCloudPlatform/open-match/internal/set"
-	redishelpers "github.com/GoogleCloudPlatform/open-match/internal/statestorage/redis"
-	"github.com/GoogleCloudPlatform/open-match/internal/statestorage/redis/ignorelist"
-	"github.com/GoogleCloudPlatform/open-match/internal/statestorage/redis/redispb"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage"
 	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
 	"go.opencensus.io/stats"
 	"go.opencensus.io/tag"
 
-	"github.com/gomodule/redigo/redis"
 	"github.com/spf13/viper"
 
 	"go.opencensus.io/plugin/ocgrpc"
@@ -60,18 +59,20 @@ var (
 // MmlogicAPI implements mmlogic.ApiServer, the server generated by compiling
 // the protobuf, by fulfilling the mmlogic.APIClient interface.
 type MmlogicAPI struct {
-	grpc *grpc.Server
-	cfg  *viper.Viper
-	pool *redis.Pool
+	grpc  *grpc.Server
+	cfg   *viper.Viper
+	store statestorage.Service
+	cache *filterCache
 }
 type mmlogicAPI MmlogicAPI
 
 // New returns an instantiated srvice
-func New(cfg *viper.Viper, pool *redis.Pool) *MmlogicAPI {
+func New(cfg *viper.Viper, store statestorage.Service) *MmlogicAPI {
 	s := MmlogicAPI{
-		pool: pool,
-		grpc: grpc.NewServer(grpc.StatsHandler(&ocgrpc.ServerHandler{})),
-		cfg:  cfg,
+		store: store,
+		grpc:  grpc.NewServer(grpc.StatsHandler(&ocgrpc.ServerHandler{})),
+		cfg:   cfg,
+		cache: newFilterCache(time.Duration(cfg.GetInt("playerPoolCache.ttl")) * time.Millisecond),
 	}
 
 	// Add a hook to the logger to auto-count log lines for metrics output thru OpenCensus
@@ -95,6 +96,11 @@ func (s *MmlogicAPI) Open() error {
 	}
 	mlLog.WithFields(log.Fields{"port": s.cfg.GetInt("api.mmlogic.port")}).Info("TCP net listener initialized")
 
+	// Keep this API's player indices in sync with the index registry.
+	go statestorage.WatchIndices(context.Background(), s.cfg, s.store)
+	// Return the players of proposals nobody released to the pool.
+	go statestorage.ReapLeases(context.Background(), s.cfg, s.store)
+
 	go func() {
 		err := s.grpc.Serve(ln)
 		if err != nil {
@@ -110,18 +116,14 @@ func (s *MmlogicAPI) Open() error {
 // mmlogicapi/proto/mmlogic.proto
 func (s *mmlogicAPI) GetProfile(c context.Context, profile *pb.MatchObject) (*pb.MatchObject, error) {
 
-	// Get redis connection from pool
-	redisConn := s.pool.Get()
-	defer redisConn.Close()
-
 	// Create context for tagging OpenCensus metrics.
 	funcName := "GetProfile"
 	fnCtx, _ := tag.New(c, tag.Insert(KeyMethod, funcName))
 
 	// Get profile.
 	mlLog.WithFields(log.Fields{"profileid": profile.Id}).Info("Attempting retreival of profile")
-	err := redispb.UnmarshalFromRedis(c, s.pool, profile)
-	mlLog.Warn("returned profile from redispb", profile)
+	err := s.store.RetrieveMatchObject(c, profile)
+	mlLog.Warn("returned profile from state storage", profile)
 	if err != nil {
 		mlLog.WithFields(log.Fields{
 			"error":     err.Error(),
@@ -146,13 +148,9 @@ func (s *mmlogicAPI) GetProfile(c context.Context, profile *pb.MatchObject) (*pb
 func (s *mmlogicAPI) CreateProposal(c context.Context, prop *pb.MatchObject) (*pb.Result, error) {
 
 	// Retreive configured redis keys.
-	list := "proposed"
+	list := s.cfg.GetString("ignoreLists.proposed.name")
 	proposalq := s.cfg.GetString("queues.proposals.name")
 
-	// Get redis connection from pool
-	redisConn := s.pool.Get()
-	defer redisConn.Close()
-
 	// Create context for tagging OpenCensus metrics.
 	funcName := "CreateProposal"
 	fnCtx, _ := tag.New(c, tag.Insert(KeyMethod, funcName))
@@ -165,79 +163,114 @@ func (s *mmlogicAPI) CreateProposal(c context.Context, prop *pb.MatchObject) (*p
 		cpLog.Info("writing MMF error to state storage")
 	}
 
-	// Write all non-id fields from the protobuf message to state storage.
-	err := redispb.MarshalToRedis(c, s.pool, prop, s.cfg.GetInt("redis.expirations.matchobject"))
-	if err != nil {
-		stats.Record(fnCtx, MlGrpcErrors.M(1))
-		return &pb.Result{Success: false, Error: err.Error()}, status.Error(codes.Unknown, err.Error())
-	}
-
-	// Proposals need two more actions: players added to ignorelist, and adding
-	// the proposalkey to the proposal queue for the evaluator to read.
+	// Proposals add their players to the ignorelist, under a lease held by
+	// the proposal that is released if it is rejected.
+	playerIDs := make([]string, 0)
 	if len(prop.Error) == 0 {
-		// look for players to add to the ignorelist
 		cpLog.Info("parsing rosters")
-		playerIDs := make([]string, 0)
 		for _, roster := range prop.Rosters {
 			playerIDs = append(playerIDs, getPlayerIdsFromRoster(roster)...)
 		}
-
-		// If players were on the roster, add them to the ignorelist
-		if len(playerIDs) > 0 {
-			cpLog.WithFields(log.Fields{
-				"count":      len(playerIDs),
-				"ignorelist": list,
-			}).Info("adding players to ignorelist")
-
-			err := ignorelist.Add(redisConn, list, playerIDs)
-			if err != nil {
-				cpLog.WithFields(log.Fields{
-					"error":      err.Error(),
-					"component":  "statestorage",
-					"ignorelist": list,
-				}).Error("State storage error")
-
-				// record error.
-				stats.Record(fnCtx, MlGrpcErrors.M(1))
-				return &pb.Result{Success: false, Error: err.Error()}, status.Error(codes.Unknown, err.Error())
-			}
-		} else {
+		if len(playerIDs) == 0 {
 			cpLog.Warn("found no players in rosters, not adding any players to the proposed ignorelist")
 		}
+	}
 
-		// add propkey to proposalsq
-		pqLog := cpLog.WithFields(log.Fields{
-			"component": "statestorage",
-			"queue":     proposalq,
-		})
-		pqLog.Info("adding propsal to queue")
+	// Write the results, add the players to the ignorelist, add the proposal
+	// to the proposal queue for the evaluator to read, and mark this MMF as
+	// finished by decrementing the concurrent MMFs, all at once.  The
+	// counter is used to trigger the evaluator early if all MMFs have
+	// finished before its next scheduled run.
+	ssLog := cpLog.WithFields(log.Fields{
+		"component":  "statestorage",
+		"count":      len(playerIDs),
+		"ignorelist": list,
+		"queue":      proposalq,
+	})
+	ssLog.Info("submitting results to state storage")
+	// In strict mode, proposals with players another proposal or match has
+	// already claimed are refused, unless the claim is older than the
+	// proposed ignorelist's duration, so the players are back in the pool.
+	strict := s.cfg.GetBool("proposalLeases.strict")
+	_, since, _ := statestorage.IgnoreListWindow(s.cfg.Sub("ignoreLists.proposed"), "proposed", time.Now())
+	created, err := s.store.SubmitProposal(c, prop, list, proposalq, "concurrentMMFs", playerIDs, strict, since)
+	if claimErr, ok := err.(*statestorage.ClaimError); ok {
+		ssLog.WithFields(log.Fields{"claimed": claimErr.PlayerIDs}).Info("Proposal has players already claimed")
+		stats.Record(fnCtx, MlGrpcErrors.M(1), MlClaimConflicts.M(1))
+		return &pb.Result{Success: false, Error: err.Error()}, status.Error(codes.Aborted, err.Error())
+	}
+	if err == statestorage.ErrResultsConflict {
+		// Another MMF run made different results with the same ID.
+		ssLog.Warn("Different results already submitted")
+		stats.Record(fnCtx, MlGrpcErrors.M(1))
+		return &pb.Result{Success: false, Error: err.Error()}, status.Error(codes.AlreadyExists, err.Error())
+	}
+	if err != nil {
+		ssLog.WithFields(log.Fields{"error": err.Error()}).Error("State storage error")
 
-		_, err = redisConn.Do("SADD", proposalq, prop.Id)
-		if err != nil {
-			pqLog.WithFields(log.Fields{"error": err.Error()}).Error("State storage error")
+		// record error.
+		stats.Record(fnCtx, MlGrpcErrors.M(1))
+		return &pb.Result{Success: false, Error: err.Error()}, status.Error(codes.Unknown, err.Error())
+	}
+	if !created {
+		// A retry of a call that already succeeded.  The statuses below may
+		// not have been set the first time.
+		ssLog.Info("results already submitted")
+	}
 
-			// record error.
-			stats.Record(fnCtx, MlGrpcErrors.M(1))
-			return &pb.Result{Success: false, Error: err.Error()}, status.Error(codes.Unknown, err.Error())
+	// Let the players know a match has been proposed.  Not fatal.
+	if len(playerIDs) > 0 {
+		err = statestorage.SetPlayerStatus(c, s.store, pb.PlayerStatus_PROPOSED, playerIDs)
+		if err != nil {
+			cpLog.WithFields(log.Fields{
+				"error":     err.Error(),
+				"component": "statestorage",
+			}).Warn("Unable to set player statuses")
 		}
 	}
 
-	// Mark this MMF as finished by decrementing the concurrent MMFs.
-	// This is used to trigger the evaluator early if all MMFs have finished
-	// before its next scheduled run.
-	cmLog := cpLog.WithFields(log.Fields{
-		"component": "statestorage",
-		"key":       "concurrentMMFs",
-	})
-	cmLog.Info("marking MMF finished for evaluator")
-	_, err = redishelpers.Decrement(fnCtx, s.pool, "concurrentMMFs")
-	if err != nil {
-		cmLog.WithFields(log.Fields{"error": err.Error()}).Error("State storage error")
+	stats.Record(fnCtx, MlGrpcRequests.M(1))
+	return &pb.Result{Success: true}, nil
+}
 
-		// record error.
+// ReleaseProposal is this service's implementation of the gRPC call defined
+// in mmlogicapi/proto/mmlogic.proto
+func (s *mmlogicAPI) ReleaseProposal(c context.Context, prop *pb.MatchObject) (*pb.Result, error) {
+	list := s.cfg.GetString("ignoreLists.proposed.name")
+
+	// Create context for tagging OpenCensus metrics.
+	funcName := "ReleaseProposal"
+	fnCtx, _ := tag.New(c, tag.Insert(KeyMethod, funcName))
+
+	rpLog := mlLog.WithFields(log.Fields{"id": prop.Id, "ignorelist": list})
+
+	// Only proposals can be released; anything else would delete another
+	// kind of match object.
+	if _, err := evaluator.NewProposal(prop.Id, &pb.MatchObject{}); err != nil {
+		rpLog.WithFields(log.Fields{"error": err.Error()}).Warn("Not a proposal ID")
+		stats.Record(fnCtx, MlGrpcErrors.M(1))
+		return &pb.Result{Success: false, Error: err.Error()}, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// Withdraw the proposal first, so the evaluator can't approve it once
+	// its players are back in the pool.
+	if err := s.store.DeleteMatchObject(c, prop.Id); err != nil {
+		rpLog.WithFields(log.Fields{"error": err.Error(), "component": "statestorage"}).Error("State storage error")
 		stats.Record(fnCtx, MlGrpcErrors.M(1))
 		return &pb.Result{Success: false, Error: err.Error()}, status.Error(codes.Unknown, err.Error())
 	}
+	released, err := s.store.ReleaseLease(c, list, prop.Id)
+	if err != nil {
+		rpLog.WithFields(log.Fields{"error": err.Error(), "component": "statestorage"}).Error("State storage error")
+		stats.Record(fnCtx, MlGrpcErrors.M(1))
+		return &pb.Result{Success: false, Error: err.Error()}, status.Error(codes.Unknown, err.Error())
+	}
+
+	// Let the players know they are waiting for a match again.  Not fatal.
+	if err = statestorage.SetPlayerStatus(c, s.store, pb.PlayerStatus_QUEUED, released); err != nil {
+		rpLog.WithFields(log.Fields{"error": err.Error(), "component": "statestorage"}).Warn("Unable to set player statuses")
+	}
+	rpLog.WithFields(log.Fields{"count": len(released)}).Info("Proposal released")
 
 	stats.Record(fnCtx, MlGrpcRequests.M(1))
 	return &pb.Result{Success: true}, nil
@@ -249,8 +282,9 @@ func (s *mmlogicAPI) CreateProposal(c context.Context, prop *pb.MatchObject) (*p
 // all be reassembled into one set on the calling side, as they are just
 // paginated subsets of the player pool.
 func (s *mmlogicAPI) GetPlayerPool(pool *pb.PlayerPool, stream pb.MmLogic_GetPlayerPoolServer) error {
-	// TODO: quit if context is cancelled
-	ctx, cancel := context.WithCancel(context.Background())
+	// Stop querying state storage as soon as the MMF cancels the stream or
+	// its deadline passes.
+	ctx, cancel := context.WithCancel(stream.Context())
 	defer cancel()
 
 	// Create context for tagging OpenCensus metrics.
@@ -263,17 +297,168 @@ func (s *mmlogicAPI) GetPlayerPool(pool *pb.PlayerPool, stream pb.MmLogic_GetPla
 		"funcName":    funcName,
 	}).Info("attempting to retreive player pool from state storage")
 
+	fnStart := time.Now()
+	playerList, filteredResults, empty, err := s.poolMembers(ctx, pool)
+	if ctx.Err() != nil {
+		return aborted(fnCtx, pool.Name, ctx.Err())
+	}
+	if err != nil {
+		stats.Record(fnCtx, MlGrpcErrors.M(1))
+		return err
+	}
+
+	if empty {
+		// Fill in the stats for this player pool.
+		pool.Stats = &pb.Stats{Count: 0, Elapsed: time.Since(fnStart).Seconds()}
+
+		// Send the empty pool and exit.
+		if err = stream.Send(pool); err != nil {
+			stats.Record(fnCtx, MlGrpcErrors.M(1))
+			return status.Error(codes.Unavailable, err.Error())
+		}
+		stats.Record(fnCtx, MlGrpcRequests.M(1))
+		return nil
+	}
+
+	// Reformat the playerList as a gRPC PlayerPool message. Send partial results as we go.
+	// This is pretty agressive in the partial result 'page'
+	// sizes it sends, and that is partially because it assumes you're running
+	// everything on a local network.  If you aren't, you may need to tune this
+	// pageSize.
+	pageSize := s.cfg.GetInt("redis.results.pageSize")
+	pageCount := int(math.Ceil((float64(len(playerList)) / float64(pageSize)))) // Divides and rounds up on any remainder
+	//TODO: change if removing filtersets from rosters in favor of it being in pools
+	partialRoster := pb.Roster{Name: fmt.Sprintf("%v.partialRoster", pool.Name)}
+	pool.Stats = &pb.Stats{Count: int64(len(playerList)), Elapsed: time.Since(fnStart).Seconds()}
+	for i := 0; i < len(playerList); i++ {
+		// Add one additional player result to the partial pool.
+		partialRoster.Players = append(partialRoster.Players, poolPlayer(playerList[i], filteredResults))
+
+		// Check if we've filled in enough players to fill a page of results.
+		if ((i+1)%pageSize == 0) || i == (len(playerList)-1) {
+			if ctx.Err() != nil {
+				return aborted(fnCtx, pool.Name, ctx.Err())
+			}
+			if pool.IncludeProperties {
+				if err = s.fillProperties(ctx, partialRoster.Players, pool.PropertyPaths); err != nil {
+					mlLog.WithFields(log.Fields{"error": err.Error(), "pool": pool.Name}).Error("Failed to retrieve player properties")
+					stats.Record(fnCtx, MlGrpcErrors.M(1))
+					return status.Error(codes.Unavailable, err.Error())
+				}
+			}
+			pageName := fmt.Sprintf("%v.page%v/%v", pool.Name, i/pageSize+1, pageCount)
+			poolChunk := &pb.PlayerPool{
+				Name:    pageName,
+				Filters: pool.Filters,
+				Stats:   pool.Stats,
+				Roster:  &partialRoster,
+			}
+			if err = stream.Send(poolChunk); err != nil {
+				stats.Record(fnCtx, MlGrpcErrors.M(1))
+				return status.Error(codes.Unavailable, err.Error())
+			}
+			partialRoster.Players = []*pb.Player{}
+		}
+	}
+
+	mlLog.WithFields(log.Fields{"count": len(playerList), "pool": pool.Name}).Debug("player pool streaming complete")
+
+	stats.Record(fnCtx, MlGrpcRequests.M(1))
+	return nil
+}
+
+// WatchPlayerPool is this service's implementation of the gRPC call defined
+// in mmlogicapi/proto/mmlogic.proto
+// It recomputes the pool every 'playerPoolCache.watchInterval' milliseconds,
+// using cached filter results, and sends the players who joined and left it.
+func (s *mmlogicAPI) WatchPlayerPool(pool *pb.PlayerPool, stream pb.MmLogic_WatchPlayerPoolServer) error {
+	ctx := stream.Context()
+
+	// Create context for tagging OpenCensus metrics.
+	funcName := "WatchPlayerPool"
+	fnCtx, _ := tag.New(ctx, tag.Insert(KeyMethod, funcName))
+
+	wpLog := mlLog.WithFields(log.Fields{"pool": pool.Name, "funcName": funcName})
+	wpLog.WithFields(log.Fields{"filterCount": len(pool.Filters)}).Info("watching player pool")
+
+	interval := time.Duration(s.cfg.GetInt("playerPoolCache.watchInterval")) * time.Millisecond
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	members := make(map[string]bool)
+	for first := true; ; first = false {
+		start := time.Now()
+		playerList, filteredResults, _, err := s.poolMembers(ctx, pool)
+		if ctx.Err() != nil {
+			// The MMF is done watching.
+			wpLog.Debug("player pool watch closed")
+			stats.Record(fnCtx, MlGrpcRequests.M(1))
+			return nil
+		}
+		if err != nil {
+			stats.Record(fnCtx, MlGrpcErrors.M(1))
+			return err
+		}
+
+		delta := &pb.PlayerPoolDelta{Name: pool.Name}
+		current := make(map[string]bool, len(playerList))
+		for _, id := range playerList {
+			current[id] = true
+			if !members[id] {
+				delta.Added = append(delta.Added, poolPlayer(id, filteredResults))
+			}
+		}
+		for id := range members {
+			if !current[id] {
+				delta.Removed = append(delta.Removed, id)
+			}
+		}
+		members = current
+
+		if first || len(delta.Added) > 0 || len(delta.Removed) > 0 {
+			if pool.IncludeProperties && len(delta.Added) > 0 {
+				if err = s.fillProperties(ctx, delta.Added, pool.PropertyPaths); err != nil {
+					wpLog.WithFields(log.Fields{"error": err.Error()}).Error("Failed to retrieve player properties")
+					stats.Record(fnCtx, MlGrpcErrors.M(1))
+					return status.Error(codes.Unavailable, err.Error())
+				}
+			}
+			delta.Stats = &pb.Stats{Count: int64(len(playerList)), Elapsed: time.Since(start).Seconds()}
+			if err = stream.Send(delta); err != nil {
+				stats.Record(fnCtx, MlGrpcErrors.M(1))
+				return status.Error(codes.Unavailable, err.Error())
+			}
+			wpLog.WithFields(log.Fields{"added": len(delta.Added), "removed": len(delta.Removed)}).Debug("player pool delta sent")
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(interval):
+		}
+	}
+}
+
+// poolMembers returns the IDs of the players in the pool: those matching
+// every filter and the expression, less the players in any ignore list.  It
+// also returns the filter results keyed by attribute, so the players'
+// attribute values can be returned in the pool.  empty is set, and nothing
+// else computed, when a filter matches no players at all.  Errors are gRPC
+// status errors, except the context's error once it is cancelled.
+func (s *mmlogicAPI) poolMembers(ctx context.Context, pool *pb.PlayerPool) (playerList []string, filteredResults map[string]map[string]float64, empty bool, err error) {
 	// One working Roster per filter in the set.  Combined at the end.
 	filteredRosters := make(map[string][]string)
 	// Temp store the results so we can also populate some field values in the final return roster.
-	filteredResults := make(map[string]map[string]int64)
+	filteredResults = make(map[string]map[string]float64)
 	overlap := make([]string, 0)
-	fnStart := time.Now()
 
 	// Loop over all filters, get results, combine
 	for _, thisFilter := range pool.Filters {
 		filterStart := time.Now()
-		results, err := s.applyFilter(ctx, thisFilter)
+		results, err := s.cache.get(ctx, thisFilter, s.applyFilter)
+		if ctx.Err() != nil {
+			return nil, nil, false, ctx.Err()
+		}
 		thisFilter.Stats = &pb.Stats{Count: int64(len(results)), Elapsed: time.Since(filterStart).Seconds()}
 		mlLog.WithFields(log.Fields{
 			"count":      int64(len(results)),
@@ -281,6 +466,9 @@ func (s *mmlogicAPI) GetPlayerPool(pool *pb.PlayerPool, stream pb.MmLogic_GetPla
 			"filterName": thisFilter.Name,
 		}).Debug("Filter stats")
 
+		if err == errTooManyPlayers {
+			return nil, nil, false, status.Error(codes.ResourceExhausted, fmt.Sprintf("filter %v: %v", thisFilter.Name, err.Error()))
+		}
 		if err != nil {
 			mlLog.WithFields(log.Fields{"error": err.Error(), "filterName": thisFilter.Name}).Debug("Error applying filter")
 
@@ -294,17 +482,7 @@ func (s *mmlogicAPI) GetPlayerPool(pool *pb.PlayerPool, stream pb.MmLogic_GetPla
 					"filterName": thisFilter.Name,
 					"pool":       pool.Name,
 				}).Warn("returning empty pool")
-
-				// Fill in the stats for this player pool.
-				pool.Stats = &pb.Stats{Count: int64(len(results)), Elapsed: time.Since(filterStart).Seconds()}
-
-				// Send the empty pool and exit.
-				if err = stream.Send(pool); err != nil {
-					stats.Record(fnCtx, MlGrpcErrors.M(1))
-					return status.Error(codes.Unavailable, err.Error())
-				}
-				stats.Record(fnCtx, MlGrpcRequests.M(1))
-				return nil
+				return nil, filteredResults, true, nil
 			}
 
 		}
@@ -333,94 +511,139 @@ func (s *mmlogicAPI) GetPlayerPool(pool *pb.PlayerPool, stream pb.MmLogic_GetPla
 		//mlLog.WithFields(log.Fields{"count": len(overlap), "field": field}).Debug("Amount of overlap")
 	}
 
+	// Narrow the pool down to the players matching the expression, if any.
+	// Without filters, the expression alone selects the players.
+	if pool.Expression != nil {
+		if len(pool.Filters) == 0 {
+			overlap, err = s.evaluate(ctx, pool.Expression, filteredResults)
+		} else {
+			overlap, err = s.restrict(ctx, overlap, pool.Expression, filteredResults)
+		}
+		if ctx.Err() != nil {
+			return nil, nil, false, ctx.Err()
+		}
+		if err == errTooManyPlayers {
+			return nil, nil, false, status.Error(codes.ResourceExhausted, err.Error())
+		}
+		if err != nil {
+			mlLog.WithFields(log.Fields{"error": err.Error(), "pool": pool.Name}).Error("Invalid filter expression")
+			return nil, nil, false, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
 	// Get contents of all ignore lists and remove those players from the pool.
 	il, err := s.allIgnoreLists(ctx, &pb.IlInput{})
 	if err != nil {
 		mlLog.Error(err)
 	}
+	if ctx.Err() != nil {
+		return nil, nil, false, ctx.Err()
+	}
 	mlLog.WithFields(log.Fields{"count": len(overlap)}).Debug("Pool size before applying ignorelists")
 	mlLog.WithFields(log.Fields{"count": len(il)}).Debug("Ignorelist size")
-	playerList := set.Difference(overlap, il) // removes ignorelist from the Roster
+	playerList = set.Difference(overlap, il) // removes ignorelist from the Roster
 	mlLog.WithFields(log.Fields{"count": len(playerList)}).Debug("Final Pool size")
+	return playerList, filteredResults, false, nil
+}
 
-	// Reformat the playerList as a gRPC PlayerPool message. Send partial results as we go.
-	// This is pretty agressive in the partial result 'page'
-	// sizes it sends, and that is partially because it assumes you're running
-	// everything on a local network.  If you aren't, you may need to tune this
-	// pageSize.
-	pageSize := s.cfg.GetInt("redis.results.pageSize")
-	pageCount := int(math.Ceil((float64(len(playerList)) / float64(pageSize)))) // Divides and rounds up on any remainder
-	//TODO: change if removing filtersets from rosters in favor of it being in pools
-	partialRoster := pb.Roster{Name: fmt.Sprintf("%v.partialRoster", pool.Name)}
-	pool.Stats = &pb.Stats{Count: int64(len(playerList)), Elapsed: time.Since(fnStart).Seconds()}
-	for i := 0; i < len(playerList); i++ {
-		// Add one additional player result to the partial pool.
-		player := &pb.Player{Id: playerList[i], Attributes: []*pb.Player_Attribute{}}
-		// Collect all the filtered attributes into the player protobuf.
-		for attribute, fr := range filteredResults {
-			if value, ok := fr[playerList[i]]; ok {
-				player.Attributes = append(player.Attributes, &pb.Player_Attribute{Name: attribute, Value: value})
-			}
-		}
-		partialRoster.Players = append(partialRoster.Players, player)
-
-		// Check if we've filled in enough players to fill a page of results.
-		if ((i+1)%pageSize == 0) || i == (len(playerList)-1) {
-			pageName := fmt.Sprintf("%v.page%v/%v", pool.Name, i/pageSize+1, pageCount)
-			poolChunk := &pb.PlayerPool{
-				Name:    pageName,
-				Filters: pool.Filters,
-				Stats:   pool.Stats,
-				Roster:  &partialRoster,
-			}
-			if err = stream.Send(poolChunk); err != nil {
-				stats.Record(fnCtx, MlGrpcErrors.M(1))
-				return status.Error(codes.Unavailable, err.Error())
-			}
-			partialRoster.Players = []*pb.Player{}
+// poolPlayer returns a player in a pool, with the values of the filtered
+// attributes filled in.
+func poolPlayer(id string, filteredResults map[string]map[string]float64) *pb.Player {
+	player := &pb.Player{Id: id, Attributes: []*pb.Player_Attribute{}}
+	for attribute, fr := range filteredResults {
+		if value, ok := fr[id]; ok {
+			player.Attributes = append(player.Attributes, &pb.Player_Attribute{Name: attribute, Value: int64(value), FloatValue: value})
 		}
 	}
+	return player
+}
 
-	mlLog.WithFields(log.Fields{"count": len(playerList), "pool": pool.Name}).Debug("player pool streaming complete")
+// aborted records a player pool query abandoned because the MMF cancelled
+// the request or its deadline passed, and returns the matching gRPC error.
+func aborted(fnCtx context.Context, pool string, err error) error {
+	mlLog.WithFields(log.Fields{"error": err.Error(), "pool": pool}).Warn("Player pool query aborted")
+	stats.Record(fnCtx, MlGrpcErrors.M(1), MlAbortedQueries.M(1))
+	return status.FromContextError(err).Err()
+}
 
-	stats.Record(fnCtx, MlGrpcRequests.M(1))
+// fillProperties reads the properties of a page of players from state
+// storage in one batch.  If paths are given, the properties are trimmed to a
+// JSON object holding only those paths.
+func (s *mmlogicAPI) fillProperties(ctx context.Context, players []*pb.Player, paths []string) error {
+	ids := make([]string, len(players))
+	for i, player := range players {
+		ids[i] = player.Id
+	}
+	properties, err := s.store.RetrievePlayersField(ctx, "properties", ids)
+	if err != nil {
+		return err
+	}
+	for _, player := range players {
+		player.Properties = selectProperties(properties[player.Id], paths)
+	}
 	return nil
 }
 
+// selectProperties returns a JSON object holding only the input paths (in
+// dot notation) of the properties.  Paths missing from the properties are
+// left out.  Without paths, the properties are returned unchanged.
+func selectProperties(properties string, paths []string) string {
+	if len(paths) == 0 || properties == "" {
+		return properties
+	}
+	selected := "{}"
+	for _, path := range paths {
+		v := gjson.Get(properties, path)
+		if !v.Exists() {
+			continue
+		}
+		if out, err := sjson.SetRaw(selected, path, v.Raw); err == nil {
+			selected = out
+		}
+	}
+	return selected
+}
+
+// maxFilterPlayers is the most players a filter can match.  500,000 results
+// is an arbitrary number; OM doesn't encourage patterns where MMFs look at
+// this large of a pool.
+const maxFilterPlayers = 500000
+
+// errTooManyPlayers is returned by applyFilter for a filter matching more
+// than maxFilterPlayers players.  The pool request fails rather than
+// returning a partial pool.
+var errTooManyPlayers = errors.New("filter applies to too many players")
+
 // applyFilter is a sequential query of every entry in the Redis sorted set
 // that fall beween the minimum and maximum values passed in through the filter
 // argument.  This can be likely sped up later using concurrent access, but
 // with small enough player pools (less than the 'redis.queryArgs.count' config
 // parameter) the amount of work is identical, so this is fine as a starting point.
-// If the provided field is not indexed or the provided range is too large, a nil result
-// is returned and this filter should be disregarded when applying filter overlaps.
-func (s *mmlogicAPI) applyFilter(c context.Context, filter *pb.Filter) (map[string]int64, error) {
-
-	type pName string
-	pool := make(map[string]int64)
-
-	// Default maximum value is positive infinity (i.e. highest possible number in redis)
-	// https://redis.io/commands/zrangebyscore
-	maxv := strconv.FormatInt(filter.Maxv, 10) // Convert int64 to a string
-	if filter.Maxv == 0 {                      // No max specified, set to +inf
-		maxv = "+inf"
+// If the provided field is not indexed, a nil result is returned and this
+// filter matches no players; if the provided range is too large,
+// errTooManyPlayers is returned.
+func (s *mmlogicAPI) applyFilter(c context.Context, filter *pb.Filter) (map[string]float64, error) {
+
+	pool := make(map[string]float64)
+
+	// Numeric filters select a range of values, string and tags filters a set
+	// of values.  Default maximum value is positive infinity; state storage
+	// handles this, it is only formatted here for logging.
+	index := statestorage.FindIndex(s.cfg, filter.Attribute)
+	filterFields := log.Fields{"field": filter.Attribute, "type": index.Type}
+	if index.ByValue() {
+		filterFields["values"] = filter.Values
+	} else {
+		filterFields["minv"] = statestorage.FilterMin(index, filter)
+		filterFields["maxv"] = statestorage.FilterMax(index, filter)
 	}
 
 	mlLog.WithFields(log.Fields{"filterField": filter.Attribute}).Debug("In applyFilter")
 
-	// Get redis connection from pool
-	redisConn := s.pool.Get()
-	defer redisConn.Close()
-
 	// Check how many expected matches for this filter before we start retrieving.
-	cmd := "ZCOUNT"
-	count, err := redis.Int64(redisConn.Do(cmd, filter.Attribute, filter.Minv, maxv))
-	//DEBUG: count, err := redis.Int64(redisConn.Do(cmd, "BLARG", filter.Minv, maxv))
-	mlLog := mlLog.WithFields(log.Fields{
-		"query": cmd,
-		"field": filter.Attribute,
-		"minv":  filter.Minv,
-		"maxv":  maxv,
+	count, err := s.store.CountIndexRange(c, filter)
+	mlLog := mlLog.WithFields(filterFields).WithFields(log.Fields{
+		"query": "CountIndexRange",
 		"count": count,
 	})
 	if err != nil {
@@ -432,16 +655,9 @@ func (s *mmlogicAPI) applyFilter(c context.Context, filter *pb.Filter) (map[stri
 		err = errors.New("filter applies to no players")
 		mlLog.Error(err.Error())
 		return nil, err
-	} else if count > 500000 {
-		// 500,000 results is an arbitrary number; OM doesn't encourage
-		// patterns where MMFs look at this large of a pool.
-		err = errors.New("filter applies to too many players")
-		mlLog.Error(err.Error())
-		for i := 0; i < int(count); i++ {
-			// Send back an empty pool, used by the calling function to calculate the number of results
-			pool[strconv.Itoa(i)] = 0
-		}
-		return pool, err
+	} else if count > maxFilterPlayers {
+		mlLog.Error(errTooManyPlayers.Error())
+		return nil, errTooManyPlayers
 	} else if count < 100000 {
 		mlLog.Info("filter processed")
 	} else {
@@ -449,20 +665,21 @@ func (s *mmlogicAPI) applyFilter(c context.Context, filter *pb.Filter) (map[stri
 		mlLog.Warn("filter applies to a large number of players")
 	}
 
-	// Amount of results look okay and no redis error, begin
+	// Amount of results look okay and no state storage error, begin
 	// var init for player retrieval
-	cmd = "ZRANGEBYSCORE"
 	offset := 0
 
-	// Loop, retrieving players in chunks.
+	// Loop, retrieving players in chunks, until the pool is complete or the
+	// request is cancelled.
 	for len(pool) == offset {
-		results, err := redis.Int64Map(redisConn.Do(cmd, filter.Attribute, filter.Minv, maxv, "WITHSCORES", "LIMIT", offset, s.cfg.GetInt("redis.queryArgs.count")))
+		if err := c.Err(); err != nil {
+			mlLog.WithFields(log.Fields{"offset": offset, "error": err.Error()}).Debug("filter aborted")
+			return nil, err
+		}
+		results, err := s.store.RetrieveIndexRange(c, filter, offset, s.cfg.GetInt("redis.queryArgs.count"))
 		if err != nil {
 			mlLog.WithFields(log.Fields{
-				"query":  cmd,
-				"field":  filter.Attribute,
-				"minv":   filter.Minv,
-				"maxv":   maxv,
+				"query":  "RetrieveIndexRange",
 				"offset": offset,
 				"count":  s.cfg.GetInt("redis.queryArgs.count"),
 				"error":  err.Error(),
@@ -522,10 +739,6 @@ func (s *mmlogicAPI) ListIgnoredPlayers(c context.Context, olderThan *pb.IlInput
 	// TODO: is this supposed to able to take any list?
 	ilName := "proposed"
 
-	// Get redis connection from pool
-	redisConn := s.pool.Get()
-	defer redisConn.Close()
-
 	// Create context for tagging OpenCensus metrics.
 	funcName := "ListIgnoredPlayers"
 	fnCtx, _ := tag.New(c, tag.Insert(KeyMethod, funcName))
@@ -533,7 +746,7 @@ func (s *mmlogicAPI) ListIgnoredPlayers(c context.Context, olderThan *pb.IlInput
 	mlLog.WithFields(log.Fields{"ignorelist": ilName}).Info("Attempting to get ignorelist")
 
 	// retreive ignore list
-	il, err := ignorelist.Retrieve(redisConn, s.cfg, ilName)
+	il, err := statestorage.RetrieveIgnoreList(c, s.store, s.cfg, ilName)
 	if err != nil {
 		mlLog.WithFields(log.Fields{
 			"error":     err.Error(),
@@ -553,16 +766,11 @@ func (s *mmlogicAPI) ListIgnoredPlayers(c context.Context, olderThan *pb.IlInput
 
 // allIgnoreLists combines all the ignore lists and returns them.
 func (s *mmlogicAPI) allIgnoreLists(c context.Context, in *pb.IlInput) (allIgnored []string, err error) {
-	// Get redis connection from pool
-	redisConn := s.pool.Get()
-	defer redisConn.Close()
-
 	mlLog.Info("Attempting to get and combine ignorelists")
 
 	// Loop through all ignorelists configured in the config file.
 	for il := range s.cfg.GetStringMap("ignoreLists") {
-		ilCfg := s.cfg.Sub(fmt.Sprintf("ignoreLists.%v", il))
-		thisIl, err := ignorelist.Retrieve(redisConn, ilCfg, il)
+		thisIl, err := statestorage.RetrieveIgnoreList(c, s.store, s.cfg, il)
 		if err != nil {
 			return []string{}, err
 		}
@@ -0,0 +1,79 @@
+package apisrv
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage/memory"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSelectProperties(t *testing.T) {
+	properties := `{"mmr": {"rating": 1500, "sigma": 20}, "region": "us", "tags": ["a", "b"]}`
+	cases := []struct {
+		paths []string
+		want  string
+	}{
+		{nil, properties},
+		{[]string{"region"}, `{"region":"us"}`},
+		{[]string{"mmr.rating", "tags"}, `{"tags":["a", "b"],"mmr":{"rating":1500}}`},
+		{[]string{"missing"}, `{}`},
+	}
+	for _, c := range cases {
+		if got := selectProperties(properties, c.paths); got != c.want {
+			t.Errorf("selectProperties(%v) = %v, want %v", c.paths, got, c.want)
+		}
+	}
+}
+
+func TestReleaseProposal(t *testing.T) {
+	ctx := context.Background()
+	cfg := viper.New()
+	cfg.Set("playerIndices", []string{})
+	cfg.Set("ignoreLists.proposed.name", "proposed")
+	cfg.Set("queues.proposals.name", "proposalq")
+	store := memory.New(cfg)
+	s := &mmlogicAPI{cfg: cfg, store: store}
+	store.CreatePlayer(ctx, &pb.Player{Id: "a", Status: pb.PlayerStatus_QUEUED.String()})
+
+	// Approved results aren't proposals, and are left alone.
+	store.CreateMatchObject(ctx, &pb.MatchObject{Id: "mo.profile"})
+	if _, err := s.ReleaseProposal(ctx, &pb.MatchObject{Id: "mo.profile"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("ReleaseProposal(mo.profile) = %v, want InvalidArgument", err)
+	}
+	if err := store.RetrieveMatchObject(ctx, &pb.MatchObject{Id: "mo.profile"}); err != nil {
+		t.Errorf("got %v retrieving mo.profile, want it kept", err)
+	}
+
+	prop := &pb.MatchObject{Id: "proposal.1.mo.profile", Rosters: []*pb.Roster{{Players: []*pb.Player{{Id: "a"}}}}}
+	if _, err := s.CreateProposal(ctx, prop); err != nil {
+		t.Fatal(err)
+	}
+	// Retries succeed; other results under the same ID are refused.
+	if result, err := s.CreateProposal(ctx, prop); err != nil || !result.Success {
+		t.Errorf("retried CreateProposal = (%v, %v), want success", result, err)
+	}
+	other := &pb.MatchObject{Id: prop.Id, Rosters: []*pb.Roster{{Players: []*pb.Player{{Id: "b"}}}}}
+	if _, err := s.CreateProposal(ctx, other); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("CreateProposal with other results = %v, want AlreadyExists", err)
+	}
+	if _, err := s.ReleaseProposal(ctx, &pb.MatchObject{Id: prop.Id}); err != nil {
+		t.Fatal(err)
+	}
+	player := &pb.Player{Id: "a"}
+	store.RetrievePlayer(ctx, player)
+	if player.Status != pb.PlayerStatus_QUEUED.String() {
+		t.Errorf("got status %v, want QUEUED", player.Status)
+	}
+	if err := store.RetrieveMatchObject(ctx, &pb.MatchObject{Id: prop.Id}); err == nil {
+		t.Errorf("got %v kept, want it deleted", prop.Id)
+	}
+
+	// A released proposal's ID can be used again, by a requeued MMF run.
+	if _, err := s.CreateProposal(ctx, prop); err != nil {
+		t.Errorf("CreateProposal after release = %v, want nil", err)
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2019 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apisrv
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+)
+
+// filterCache holds the results of recently applied filters, keyed by filter
+// definition, for 'playerPoolCache.ttl' milliseconds.  MMFs that share
+// filters, and WatchPlayerPool streams, then scan each index once per TTL
+// instead of once per request.  Concurrent requests for a filter that isn't
+// cached wait for the first one to apply it.
+type filterCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+// cacheEntry is the result of applying one filter.  done is closed once
+// results and err are set.
+type cacheEntry struct {
+	done    chan struct{}
+	results map[string]float64
+	err     error
+	expires time.Time
+}
+
+// newFilterCache returns a cache holding filter results for ttl, or nil if
+// ttl isn't positive, which disables caching.
+func newFilterCache(ttl time.Duration) *filterCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &filterCache{ttl: ttl, entries: make(map[string]*cacheEntry)}
+}
+
+// filterKey identifies a filter by the players it selects; its name and
+// stats are ignored.
+func filterKey(filter *pb.Filter) string {
+	values := append([]string{}, filter.Values...)
+	sort.Strings(values)
+	return fmt.Sprintf("%v|%v|%v|%v|%v|%v", filter.Attribute, filter.Minv, filter.Maxv, filter.Fminv, filter.Fmaxv, strings.Join(values, ","))
+}
+
+// get returns the cached results of the filter, calling apply if they
+// aren't cached or have expired.  Failed results aren't cached.  The
+// returned map is shared and must not be modified.
+func (c *filterCache) get(ctx context.Context, filter *pb.Filter, apply func(context.Context, *pb.Filter) (map[string]float64, error)) (map[string]float64, error) {
+	if c == nil {
+		return apply(ctx, filter)
+	}
+
+	key := filterKey(filter)
+	now := time.Now()
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if !ok || (isDone(entry) && now.After(entry.expires)) {
+		c.sweep(now)
+		entry = &cacheEntry{done: make(chan struct{})}
+		c.entries[key] = entry
+		c.mu.Unlock()
+
+		entry.results, entry.err = apply(ctx, filter)
+		entry.expires = time.Now().Add(c.ttl)
+		if entry.err != nil {
+			c.mu.Lock()
+			if c.entries[key] == entry {
+				delete(c.entries, key)
+			}
+			c.mu.Unlock()
+		}
+		close(entry.done)
+		return entry.results, entry.err
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-entry.done:
+	}
+	if entry.err != nil {
+		// The request applying the filter failed, possibly because it was
+		// cancelled; apply it for this request instead.
+		return apply(ctx, filter)
+	}
+	return entry.results, nil
+}
+
+// sweep drops the expired entries.  c.mu must be held.
+func (c *filterCache) sweep(now time.Time) {
+	for key, entry := range c.entries {
+		if isDone(entry) && now.After(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// isDone reports whether the entry's filter has been applied.
+func isDone(entry *cacheEntry) bool {
+	select {
+	case <-entry.done:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,53 @@
+package apisrv
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+)
+
+func TestFilterCache(t *testing.T) {
+	ctx := context.Background()
+	calls := 0
+	fail := false
+	apply := func(ctx context.Context, filter *pb.Filter) (map[string]float64, error) {
+		calls++
+		if fail {
+			return nil, errors.New("state storage error")
+		}
+		return map[string]float64{"p1": float64(filter.Minv)}, nil
+	}
+	c := newFilterCache(50 * time.Millisecond)
+
+	// Filters selecting the same players share an entry, whatever their names.
+	c.get(ctx, &pb.Filter{Name: "a", Attribute: "mmr", Minv: 10, Values: []string{"x", "y"}}, apply)
+	c.get(ctx, &pb.Filter{Name: "b", Attribute: "mmr", Minv: 10, Values: []string{"y", "x"}}, apply)
+	if calls != 1 {
+		t.Errorf("identical filters applied %v times, want 1", calls)
+	}
+	c.get(ctx, &pb.Filter{Attribute: "mmr", Minv: 20}, apply)
+	if calls != 2 {
+		t.Errorf("different filter applied %v times in total, want 2", calls)
+	}
+
+	// Expired results are applied again, and errors aren't cached.
+	time.Sleep(60 * time.Millisecond)
+	fail = true
+	if _, err := c.get(ctx, &pb.Filter{Attribute: "mmr", Minv: 10}, apply); err == nil {
+		t.Error("got no error, want the apply error")
+	}
+	fail = false
+	if got, err := c.get(ctx, &pb.Filter{Attribute: "mmr", Minv: 10}, apply); err != nil || got["p1"] != 10 || calls != 4 {
+		t.Errorf("got (%v, %v) after %v calls, want map[p1:10] after 4", got, err, calls)
+	}
+
+	// A nil cache applies every filter.
+	var none *filterCache
+	none.get(ctx, &pb.Filter{Attribute: "mmr"}, apply)
+	if calls != 5 {
+		t.Errorf("nil cache applied the filter %v times in total, want 5", calls)
+	}
+}
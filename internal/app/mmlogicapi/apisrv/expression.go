@@ -0,0 +1,143 @@
+/*
+Copyright 2019 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apisrv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	"github.com/GoogleCloudPlatform/open-match/internal/set"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	errLoneNot    = errors.New("NOT must be in an AND with a filter that isn't a NOT, or at the top of a pool that has filters")
+	errNotOperand = errors.New("NOT must have exactly one child")
+	errNoFilter   = errors.New("FILTER expression has no filter")
+)
+
+// evaluate returns the IDs of the players matching the filter expression,
+// or the context's error once it is cancelled.  The results of every filter
+// applied are merged into results, keyed by attribute, so the players'
+// attribute values can be returned in the pool.
+func (s *mmlogicAPI) evaluate(ctx context.Context, expr *pb.FilterExpression, results map[string]map[string]float64) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	switch expr.Op {
+	case pb.FilterExpression_FILTER:
+		if expr.Filter == nil {
+			return nil, errNoFilter
+		}
+		return s.evaluateFilter(ctx, expr.Filter, results)
+
+	case pb.FilterExpression_OR:
+		matched := make([]string, 0)
+		for _, child := range expr.Children {
+			ids, err := s.evaluate(ctx, child, results)
+			if err != nil {
+				return nil, err
+			}
+			matched = set.Union(matched, ids)
+		}
+		return matched, nil
+
+	case pb.FilterExpression_AND:
+		// Start from the first child that selects players, then narrow it
+		// down with the rest.
+		for i, child := range expr.Children {
+			if child.Op == pb.FilterExpression_NOT {
+				continue
+			}
+			ids, err := s.evaluate(ctx, child, results)
+			if err != nil {
+				return nil, err
+			}
+			rest := append(append([]*pb.FilterExpression{}, expr.Children[:i]...), expr.Children[i+1:]...)
+			return s.restrict(ctx, ids, &pb.FilterExpression{Op: pb.FilterExpression_AND, Children: rest}, results)
+		}
+		return nil, errLoneNot
+
+	case pb.FilterExpression_NOT:
+		return nil, errLoneNot
+	}
+	return nil, fmt.Errorf("unknown filter expression operator %v", expr.Op)
+}
+
+// restrict returns the players in ids that also match the filter
+// expression.  Unlike evaluate, it accepts NOT expressions, which remove
+// players from ids.
+func (s *mmlogicAPI) restrict(ctx context.Context, ids []string, expr *pb.FilterExpression, results map[string]map[string]float64) ([]string, error) {
+	switch expr.Op {
+	case pb.FilterExpression_AND:
+		var err error
+		for _, child := range expr.Children {
+			if ids, err = s.restrict(ctx, ids, child, results); err != nil {
+				return nil, err
+			}
+		}
+		return ids, nil
+
+	case pb.FilterExpression_NOT:
+		if len(expr.Children) != 1 {
+			return nil, errNotOperand
+		}
+		excluded, err := s.evaluate(ctx, expr.Children[0], results)
+		if err != nil {
+			return nil, err
+		}
+		return set.Difference(ids, excluded), nil
+	}
+
+	matched, err := s.evaluate(ctx, expr, results)
+	if err != nil {
+		return nil, err
+	}
+	return set.Intersection(ids, matched), nil
+}
+
+// evaluateFilter applies one filter in an expression.  A filter that can't
+// be applied matches no players, the same as in the pool's list of filters,
+// except one matching too many players, which fails the whole expression.
+func (s *mmlogicAPI) evaluateFilter(ctx context.Context, filter *pb.Filter, results map[string]map[string]float64) ([]string, error) {
+	filterStart := time.Now()
+	filtered, err := s.cache.get(ctx, filter, s.applyFilter)
+	filter.Stats = &pb.Stats{Count: int64(len(filtered)), Elapsed: time.Since(filterStart).Seconds()}
+	if err == errTooManyPlayers {
+		return nil, err
+	}
+	if err != nil {
+		mlLog.WithFields(log.Fields{"error": err.Error(), "filterName": filter.Name}).Debug("Error applying filter")
+	}
+
+	// Filter results may be shared through the cache, so they are merged
+	// into a new map.
+	merged := make(map[string]float64, len(results[filter.Attribute])+len(filtered))
+	for id, value := range results[filter.Attribute] {
+		merged[id] = value
+	}
+	ids := make([]string, 0, len(filtered))
+	for id, value := range filtered {
+		merged[id] = value
+		ids = append(ids, id)
+	}
+	results[filter.Attribute] = merged
+	return ids, nil
+}
@@ -0,0 +1,110 @@
+package apisrv
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage/memory"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func leaf(attribute string, minv int64) *pb.FilterExpression {
+	return &pb.FilterExpression{Filter: &pb.Filter{Attribute: attribute, Minv: minv}}
+}
+
+func op(o pb.FilterExpression_Op, children ...*pb.FilterExpression) *pb.FilterExpression {
+	return &pb.FilterExpression{Op: o, Children: children}
+}
+
+func TestEvaluate(t *testing.T) {
+	ctx := context.Background()
+	cfg := viper.New()
+	cfg.Set("playerIndices", []string{"region.a", "region.b", "mode.demo"})
+	cfg.Set("redis.queryArgs.count", 100)
+	store := memory.New(cfg)
+	for id, props := range map[string]string{
+		"p1": `{"region": {"a": 1}}`,
+		"p2": `{"region": {"b": 1}}`,
+		"p3": `{"region": {"a": 1, "b": 1}, "mode": {"demo": 1}}`,
+		"p4": `{"mode": {"demo": 1}}`,
+	} {
+		store.CreatePlayer(ctx, &pb.Player{Id: id, Properties: props})
+	}
+	s := &mmlogicAPI{cfg: cfg, store: store}
+
+	and, or, not := pb.FilterExpression_AND, pb.FilterExpression_OR, pb.FilterExpression_NOT
+	cases := []struct {
+		desc string
+		expr *pb.FilterExpression
+		want []string
+	}{
+		{"filter", leaf("region.a", 0), []string{"p1", "p3"}},
+		{"or", op(or, leaf("region.a", 0), leaf("region.b", 0)), []string{"p1", "p2", "p3"}},
+		{"and", op(and, leaf("region.a", 0), leaf("region.b", 0)), []string{"p3"}},
+		{"and not", op(and, op(not, leaf("mode.demo", 0)), op(or, leaf("region.a", 0), leaf("region.b", 0))), []string{"p1", "p2"}},
+		{"empty filter", op(or, leaf("region.a", 5), leaf("region.b", 0)), []string{"p2", "p3"}},
+		{"lone not", op(not, leaf("mode.demo", 0)), nil},
+		{"not in or", op(or, leaf("region.a", 0), op(not, leaf("mode.demo", 0))), nil},
+	}
+	for _, c := range cases {
+		results := make(map[string]map[string]float64)
+		got, err := s.evaluate(ctx, c.expr, results)
+		sort.Strings(got)
+		if c.want == nil {
+			if err == nil {
+				t.Errorf("%v: got %v, want an error", c.desc, got)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%v: got (%v, %v), want %v", c.desc, got, err, c.want)
+		}
+	}
+
+	// At the top of a pool with filters, NOT excludes from the filtered players.
+	got, err := s.restrict(ctx, []string{"p1", "p3"}, op(not, leaf("mode.demo", 0)), make(map[string]map[string]float64))
+	if err != nil || !reflect.DeepEqual(got, []string{"p1"}) {
+		t.Errorf("restrict: got (%v, %v), want [p1]", got, err)
+	}
+
+	// Once the request is cancelled, no more filters are applied.
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := s.evaluate(cancelled, leaf("region.a", 0), make(map[string]map[string]float64)); err != context.Canceled {
+		t.Errorf("cancelled evaluate: got %v, want %v", err, context.Canceled)
+	}
+	if _, err := s.applyFilter(cancelled, &pb.Filter{Attribute: "region.a"}); err != context.Canceled {
+		t.Errorf("cancelled applyFilter: got %v, want %v", err, context.Canceled)
+	}
+}
+
+// hugeStore reports more matching players than a filter can return.
+type hugeStore struct {
+	*memory.StateStorage
+}
+
+func (hugeStore) CountIndexRange(ctx context.Context, filter *pb.Filter) (int64, error) {
+	return maxFilterPlayers + 1, nil
+}
+
+func TestEvaluateTooManyPlayers(t *testing.T) {
+	ctx := context.Background()
+	cfg := viper.New()
+	cfg.Set("playerIndices", []string{"region.a"})
+	s := &mmlogicAPI{cfg: cfg, store: hugeStore{memory.New(cfg)}}
+
+	// No player IDs are returned, and the error fails the pool.
+	got, err := s.evaluate(ctx, op(pb.FilterExpression_OR, leaf("region.a", 0)), make(map[string]map[string]float64))
+	if err != errTooManyPlayers || len(got) != 0 {
+		t.Errorf("evaluate: got (%v, %v), want no players and %v", got, err, errTooManyPlayers)
+	}
+	pool := &pb.PlayerPool{Name: "huge", Expression: leaf("region.a", 0)}
+	if _, _, _, err := s.poolMembers(ctx, pool); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("poolMembers: got %v, want ResourceExhausted", err)
+	}
+}
@@ -68,6 +68,12 @@ var (
 
 	// Failure instrumentation
 	MlFailures = stats.Int64("mmlogicapi/failures_total", "Number of Frontend API failures", "1")
+
+	// Query instrumentation
+	MlAbortedQueries = stats.Int64("mmlogicapi/aborted_queries_total", "Number of player pool queries aborted because the request was cancelled or timed out", "1")
+
+	// Proposal instrumentation
+	MlClaimConflicts = stats.Int64("mmlogicapi/claim_conflicts_total", "Number of proposals refused in strict mode because their players were already claimed", "1")
 )
 
 var (
@@ -127,6 +133,21 @@ var (
 		Description: "The number of failures",
 		Aggregation: view.Count(),
 	}
+
+	MlAbortedQueryCountView = &view.View{
+		Name:        "mmlogic/aborted_queries",
+		Measure:     MlAbortedQueries,
+		Description: "The number of player pool queries aborted by cancellation or deadline",
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{KeyMethod},
+	}
+
+	MlClaimConflictCountView = &view.View{
+		Name:        "mmlogic/claim_conflicts",
+		Measure:     MlClaimConflicts,
+		Description: "The number of proposals refused because their players were already claimed",
+		Aggregation: view.Count(),
+	}
 )
 
 // DefaultMmlogicAPIViews are the default mmlogic API OpenCensus measure views.
@@ -136,4 +157,6 @@ var DefaultMmlogicAPIViews = []*view.View{
 	MlErrorCountView,
 	MlLogCountView,
 	MlFailureCountView,
+	MlAbortedQueryCountView,
+	MlClaimConflictCountView,
 }
@@ -0,0 +1,293 @@
+/*
+Copyright 2019 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evaluator
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	"github.com/rs/xid"
+	"github.com/spf13/viper"
+	"github.com/tidwall/gjson"
+)
+
+// maxExactComponent is the largest group of conflicting proposals that is
+// searched exhaustively for the best set to approve.  Larger groups are
+// resolved greedily, highest score first.
+const maxExactComponent = 20
+
+// Proposal is a match proposed by an MMF, along with what the evaluator
+// needs to know about it.
+type Proposal struct {
+	// Key is the state storage key the MMF wrote the proposal to.  Keys look
+	// like this:
+	// proposal.1542600048.80e43fa085844eebbf53fc736150ef96.testprofile
+	// format:
+	// "proposal".timestamp.unique_matchobject_id.profile_name
+	Key string
+	// ResultsID is the key the Backend API is watching for the results,
+	// unique_matchobject_id.profile_name.
+	ResultsID string
+	// Timestamp is the epoch timestamp of the MMF run.
+	Timestamp int64
+	Match     *pb.MatchObject
+	PlayerIDs []string
+	Score     float64
+}
+
+// NewProposal parses the proposal key and collects the players in the
+// match's rosters.
+func NewProposal(key string, match *pb.MatchObject) (*Proposal, error) {
+	values := strings.Split(key, ".")
+	if len(values) != 4 || values[0] != "proposal" {
+		return nil, fmt.Errorf("malformed proposal key '%v'", key)
+	}
+	ts, err := strconv.ParseInt(values[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("malformed proposal key '%v': %v", key, err)
+	}
+
+	p := &Proposal{
+		Key:       key,
+		ResultsID: values[2] + "." + values[3],
+		Timestamp: ts,
+		Match:     match,
+	}
+	for _, roster := range match.Rosters {
+		for _, player := range roster.Players {
+			p.PlayerIDs = append(p.PlayerIDs, player.Id)
+		}
+	}
+	return p, nil
+}
+
+// Scorer returns how good a proposal is.  Among proposals that share
+// players, the set with the highest total score is approved.
+type Scorer func(p *Proposal) float64
+
+// NewScorer returns the scorer configured in evaluator.score:
+//
+//	players:  the number of players in the match (the default).
+//	waitTime: seconds since the Backend API request was made.
+//	property: the number at evaluator.scoreProperty in the match properties.
+func NewScorer(cfg *viper.Viper, now time.Time) (Scorer, error) {
+	switch cfg.GetString("evaluator.score") {
+	case "", "players":
+		return func(p *Proposal) float64 {
+			return float64(len(p.PlayerIDs))
+		}, nil
+	case "waitTime":
+		return func(p *Proposal) float64 {
+			// Request IDs are generated by the Backend API, and contain the
+			// time the request was made.
+			id, err := xid.FromString(strings.Split(p.ResultsID, ".")[0])
+			if err != nil {
+				return 0
+			}
+			return now.Sub(id.Time()).Seconds()
+		}, nil
+	case "property":
+		property := cfg.GetString("evaluator.scoreProperty")
+		return func(p *Proposal) float64 {
+			return gjson.Get(p.Match.Properties, property).Float()
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown evaluator score '%v'", cfg.GetString("evaluator.score"))
+}
+
+// Choose splits the proposals into those to approve and those to reject so
+// that no player is in more than one approved match.  Proposals are nodes
+// in a conflict graph, with an edge between two proposals that share a
+// player; each connected group of proposals is resolved independently by
+// picking the non-conflicting subset with the highest total Score.  Ties are
+// broken in favour of approving more matches, then older proposals.
+func Choose(proposals []*Proposal) (approved []*Proposal, rejected []*Proposal) {
+	// Proposal keys sort oldest first.
+	sorted := make([]*Proposal, len(proposals))
+	copy(sorted, proposals)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+
+	for _, component := range components(sorted) {
+		var chosen []bool
+		if len(component) <= maxExactComponent {
+			chosen = chooseExact(component)
+		} else {
+			chosen = chooseGreedy(component)
+		}
+		for i, p := range component {
+			if chosen[i] {
+				approved = append(approved, p)
+			} else {
+				rejected = append(rejected, p)
+			}
+		}
+	}
+	return approved, rejected
+}
+
+// conflicts returns true if the proposals share a player.
+func conflicts(a, b *Proposal) bool {
+	for _, x := range a.PlayerIDs {
+		for _, y := range b.PlayerIDs {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// components returns the connected groups of the conflict graph, keeping
+// the order of the proposals within each group.
+func components(proposals []*Proposal) [][]*Proposal {
+	// Union-find over proposal indices, joining proposals through the
+	// players they contain.
+	parent := make([]int, len(proposals))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	owner := make(map[string]int)
+	for i, p := range proposals {
+		for _, id := range p.PlayerIDs {
+			if j, ok := owner[id]; ok {
+				parent[find(i)] = find(j)
+			} else {
+				owner[id] = i
+			}
+		}
+	}
+
+	groups := make(map[int][]*Proposal)
+	order := make([]int, 0)
+	for i, p := range proposals {
+		root := find(i)
+		if _, ok := groups[root]; !ok {
+			order = append(order, root)
+		}
+		groups[root] = append(groups[root], p)
+	}
+	result := make([][]*Proposal, 0, len(order))
+	for _, root := range order {
+		result = append(result, groups[root])
+	}
+	return result
+}
+
+// chooseExact finds the best set of non-conflicting proposals with a
+// branch and bound search over every subset.
+func chooseExact(proposals []*Proposal) []bool {
+	n := len(proposals)
+	conflict := make([][]bool, n)
+	for i := range conflict {
+		conflict[i] = make([]bool, n)
+		for j := range conflict[i] {
+			conflict[i][j] = i != j && conflicts(proposals[i], proposals[j])
+		}
+	}
+	// Negative scores count as zero, so a proposal is never rejected unless
+	// it conflicts with one that is approved.  remaining[i] is the most
+	// score that proposals i.. could add.
+	remaining := make([]float64, n+1)
+	for i := n - 1; i >= 0; i-- {
+		remaining[i] = remaining[i+1] + positive(proposals[i].Score)
+	}
+
+	best := make([]bool, n)
+	bestScore, bestCount := -1.0, -1
+	current := make([]bool, n)
+
+	var search func(i int, score float64, count int)
+	search = func(i int, score float64, count int) {
+		if score+remaining[i] < bestScore {
+			return
+		}
+		if i == n {
+			if score > bestScore || (score == bestScore && count > bestCount) {
+				bestScore, bestCount = score, count
+				copy(best, current)
+			}
+			return
+		}
+		// Try approving proposal i first, so older proposals win ties.
+		ok := true
+		for j := 0; j < i; j++ {
+			if current[j] && conflict[i][j] {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			current[i] = true
+			search(i+1, score+positive(proposals[i].Score), count+1)
+			current[i] = false
+		}
+		search(i+1, score, count)
+	}
+	search(0, 0, 0)
+	return best
+}
+
+// chooseGreedy approves proposals in order of score, highest first,
+// skipping any that conflict with one already approved.
+func chooseGreedy(proposals []*Proposal) []bool {
+	order := make([]int, len(proposals))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return proposals[order[a]].Score > proposals[order[b]].Score
+	})
+
+	chosen := make([]bool, len(proposals))
+	claimed := make(map[string]bool)
+	for _, i := range order {
+		free := true
+		for _, id := range proposals[i].PlayerIDs {
+			if claimed[id] {
+				free = false
+				break
+			}
+		}
+		if !free {
+			continue
+		}
+		chosen[i] = true
+		for _, id := range proposals[i].PlayerIDs {
+			claimed[id] = true
+		}
+	}
+	return chosen
+}
+
+func positive(f float64) float64 {
+	if f > 0 {
+		return f
+	}
+	return 0
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2019 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package evaluator decides which of the matches proposed by MMFs are
+// returned to the Backend API.  MMFs run concurrently, so two proposals can
+// contain the same player; the evaluator approves a set of proposals in
+// which every player appears at most once, and rejects the rest.
+package evaluator
+
+import (
+	"context"
+	"time"
+
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// Logrus structured logging setup
+var (
+	evLogFields = log.Fields{
+		"app":       "openmatch",
+		"component": "evaluator",
+	}
+	evLog = log.WithFields(evLogFields)
+)
+
+// errRejected is returned to the Backend API for proposals that were
+// rejected more than evaluator.maxRequeues times.
+const errRejected = "proposal rejected by evaluator: player is in another match"
+
+// Evaluate reads every proposal in the proposal queue, chooses which to
+// approve, and applies the result to state storage.
+func Evaluate(ctx context.Context, cfg *viper.Viper, store statestorage.Service) error {
+	scorer, err := NewScorer(cfg, time.Now())
+	if err != nil {
+		return err
+	}
+	proposals, err := Load(ctx, cfg, store)
+	if err != nil {
+		return err
+	}
+	for _, p := range proposals {
+		p.Score = scorer(p)
+	}
+	approved, rejected := Choose(proposals)
+	Apply(ctx, cfg, store, approved, rejected)
+	return nil
+}
+
+// Load pops every proposal from the proposal queue and reads them from state
+// storage.  Proposals that can't be read are logged and skipped.
+func Load(ctx context.Context, cfg *viper.Viper, store statestorage.Service) ([]*Proposal, error) {
+	proposalq := cfg.GetString("queues.proposals.name")
+	numProposals, err := store.CountQueue(ctx, proposalq)
+	if err != nil {
+		return nil, err
+	}
+	if numProposals == 0 {
+		return nil, nil
+	}
+	keys, err := store.PopQueue(ctx, proposalq, numProposals)
+	if err != nil {
+		return nil, err
+	}
+
+	proposals := make([]*Proposal, 0, len(keys))
+	for _, key := range keys {
+		match := &pb.MatchObject{Id: key}
+		err := store.RetrieveMatchObject(ctx, match)
+		if err == statestorage.ErrNotFound {
+			evLog.WithFields(log.Fields{"proposal": key}).Info("Skipping withdrawn proposal")
+			continue
+		}
+		if err != nil {
+			evLog.WithFields(log.Fields{"proposal": key, "error": err.Error()}).Error("Failure retrieving proposal from state storage")
+			continue
+		}
+		p, err := NewProposal(key, match)
+		if err != nil {
+			evLog.WithFields(log.Fields{"proposal": key, "error": err.Error()}).Warn("Skipping proposal")
+			continue
+		}
+		proposals = append(proposals, p)
+	}
+	return proposals, nil
+}
+
+// Unload puts proposals returned by Load back in the proposal queue, so they
+// are evaluated next time if this evaluation failed.
+func Unload(ctx context.Context, cfg *viper.Viper, store statestorage.Service, proposals []*Proposal) {
+	proposalq := cfg.GetString("queues.proposals.name")
+	for _, p := range proposals {
+		if err := store.PushQueue(ctx, proposalq, p.Key); err != nil {
+			evLog.WithFields(log.Fields{"proposal": p.Key, "error": err.Error()}).Error("Failure returning proposal to the proposal queue")
+		}
+	}
+}
+
+// Apply writes approved proposals to the key the Backend API is watching.
+// The MMFs for rejected proposals are requeued to run again, up to
+// evaluator.maxRequeues times, after which an error is written to the
+// Backend API key instead so it doesn't wait for a result that will never
+// come.  Players in rejected proposals that aren't in an approved one are
+// released from the proposed ignorelist so they can be matched again.
+func Apply(ctx context.Context, cfg *viper.Viper, store statestorage.Service, approved []*Proposal, rejected []*Proposal) {
+	evLog.WithFields(log.Fields{
+		"approved": len(approved),
+		"rejected": len(rejected),
+	}).Info("Evaluated proposals")
+
+	// Approving a proposal moves its players' leases to the approved match,
+	// so releasing the rejected proposals they were also in keeps them
+	// ignored.
+	il := cfg.GetString("ignoreLists.proposed.name")
+	for _, p := range approved {
+		approve(ctx, store, il, p)
+	}
+	for _, p := range rejected {
+		reject(ctx, cfg, store, il, p)
+	}
+}
+
+// approve writes the proposal to the key the Backend API is watching, and
+// moves the lease on its players to that key.
+func approve(ctx context.Context, store statestorage.Service, il string, p *Proposal) {
+	pLog := evLog.WithFields(log.Fields{"proposal": p.Key, "resultsID": p.ResultsID, "score": p.Score})
+
+	// The match object was already written by the MMF, just change the key
+	// to what the Backend API is looking for.
+	p.Match.Id = p.ResultsID
+	if err := store.CreateMatchObject(ctx, p.Match); err != nil {
+		pLog.WithFields(log.Fields{"error": err.Error()}).Error("Failure writing approved proposal to state storage")
+		return
+	}
+	pLog.Info("Approved proposal")
+	if err := store.LeasePlayers(ctx, il, p.ResultsID, p.PlayerIDs); err != nil {
+		pLog.WithFields(log.Fields{"error": err.Error()}).Error("Failure leasing approved players")
+	} else if _, err := store.ReleaseLease(ctx, il, p.Key); err != nil {
+		pLog.WithFields(log.Fields{"error": err.Error()}).Warn("Failure releasing approved proposal's lease")
+	}
+	if err := statestorage.SetPlayerStatus(ctx, store, pb.PlayerStatus_MATCHED, p.PlayerIDs); err != nil {
+		pLog.WithFields(log.Fields{"error": err.Error()}).Warn("Failure setting player statuses")
+	}
+	store.DeleteMatchObject(ctx, p.Key)
+	store.DeleteCounter(ctx, requeueCounter(p))
+}
+
+// reject releases the proposal's lease on its players and either requeues
+// the MMF request or returns an error to the Backend API.
+func reject(ctx context.Context, cfg *viper.Viper, store statestorage.Service, il string, p *Proposal) {
+	pLog := evLog.WithFields(log.Fields{"proposal": p.Key, "resultsID": p.ResultsID, "score": p.Score})
+
+	free, err := store.ReleaseLease(ctx, il, p.Key)
+	if err != nil {
+		pLog.WithFields(log.Fields{"error": err.Error()}).Error("Failure releasing rejected players from the proposed ignorelist")
+	}
+	if len(free) > 0 {
+		if err := statestorage.SetPlayerStatus(ctx, store, pb.PlayerStatus_QUEUED, free); err != nil {
+			pLog.WithFields(log.Fields{"error": err.Error()}).Warn("Failure setting player statuses")
+		}
+	}
+	store.DeleteMatchObject(ctx, p.Key)
+
+	requeues, err := store.IncrementCounter(ctx, requeueCounter(p))
+	if err == nil && requeues <= int64(cfg.GetInt("evaluator.maxRequeues")) {
+		if err = store.PushQueue(ctx, cfg.GetString("queues.profiles.name"), p.ResultsID); err == nil {
+			pLog.WithFields(log.Fields{"requeues": requeues}).Info("Rejected proposal, requeued MMF request")
+			return
+		}
+	}
+	if err != nil {
+		pLog.WithFields(log.Fields{"error": err.Error()}).Error("Failure requeueing MMF request")
+	}
+
+	store.DeleteCounter(ctx, requeueCounter(p))
+	if err := store.CreateMatchObject(ctx, &pb.MatchObject{Id: p.ResultsID, Error: errRejected}); err != nil {
+		pLog.WithFields(log.Fields{"error": err.Error()}).Error("Failure writing evaluation result to state storage")
+		return
+	}
+	pLog.Info("Rejected proposal")
+}
+
+// requeueCounter is the counter tracking how many times the MMF request
+// that made the proposal has been requeued.
+func requeueCounter(p *Proposal) string {
+	return "requeues." + p.ResultsID
+}
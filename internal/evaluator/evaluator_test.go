@@ -0,0 +1,166 @@
+package evaluator
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage/memory"
+	"github.com/spf13/viper"
+)
+
+// proposal makes a synthetic proposal.  The name is used as the request ID.
+func proposal(name string, score float64, players ...string) *Proposal {
+	return &Proposal{
+		Key:       "proposal.1." + name + ".profile",
+		ResultsID: name + ".profile",
+		PlayerIDs: players,
+		Score:     score,
+	}
+}
+
+func names(proposals []*Proposal) string {
+	n := make([]string, 0, len(proposals))
+	for _, p := range proposals {
+		n = append(n, strings.Split(p.ResultsID, ".")[0])
+	}
+	sort.Strings(n)
+	return strings.Join(n, ",")
+}
+
+func TestChoose(t *testing.T) {
+	cases := []struct {
+		name      string
+		proposals []*Proposal
+		approved  string
+	}{
+		{
+			"no conflicts",
+			[]*Proposal{proposal("a", 1, "p1"), proposal("b", 1, "p2")},
+			"a,b",
+		},
+		{
+			"higher score wins",
+			[]*Proposal{proposal("a", 1, "p1", "p2"), proposal("b", 5, "p2", "p3")},
+			"b",
+		},
+		{
+			"two small matches beat one large one",
+			[]*Proposal{proposal("a", 3, "p1", "p2", "p3"), proposal("b", 2, "p1"), proposal("c", 2, "p3")},
+			"b,c",
+		},
+		{
+			"chain",
+			[]*Proposal{proposal("a", 1, "p1", "p2"), proposal("b", 1, "p2", "p3"), proposal("c", 1, "p3", "p4")},
+			"a,c",
+		},
+		{
+			"ties go to the older proposal",
+			[]*Proposal{proposal("b", 1, "p1"), proposal("a", 1, "p1")},
+			"a",
+		},
+		{
+			"zero scores still approve as many as possible",
+			[]*Proposal{proposal("a", 0, "p1"), proposal("b", 0, "p1", "p2"), proposal("c", 0, "p2")},
+			"a,c",
+		},
+	}
+
+	for _, c := range cases {
+		approved, rejected := Choose(c.proposals)
+		if got := names(approved); got != c.approved {
+			t.Errorf("%v: got %v approved, want %v", c.name, got, c.approved)
+		}
+		if len(approved)+len(rejected) != len(c.proposals) {
+			t.Errorf("%v: got %v approved and %v rejected, want %v total", c.name, len(approved), len(rejected), len(c.proposals))
+		}
+	}
+}
+
+func TestChooseLargeComponent(t *testing.T) {
+	// A long chain of proposals, each sharing a player with the next, is too
+	// big to search exhaustively; the greedy result must still be valid.
+	proposals := make([]*Proposal, 0)
+	for i := 0; i < maxExactComponent*2; i++ {
+		name := string(rune('a'+i%26)) + string(rune('a'+i/26))
+		proposals = append(proposals, proposal(name, float64(i%3), string(rune('A'+i)), string(rune('A'+i+1))))
+	}
+	approved, rejected := Choose(proposals)
+	if len(approved)+len(rejected) != len(proposals) {
+		t.Fatalf("got %v approved and %v rejected, want %v total", len(approved), len(rejected), len(proposals))
+	}
+	claimed := make(map[string]bool)
+	for _, p := range approved {
+		for _, id := range p.PlayerIDs {
+			if claimed[id] {
+				t.Errorf("player %v approved in more than one match", id)
+			}
+			claimed[id] = true
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	cfg := viper.New()
+	cfg.Set("queues.proposals.name", "proposalq")
+	cfg.Set("queues.profiles.name", "profileq")
+	cfg.Set("ignoreLists.proposed.name", "proposed")
+	cfg.Set("evaluator.maxRequeues", 1)
+	cfg.Set("playerIndices", []string{})
+	store := memory.New(cfg)
+	ctx := context.Background()
+	for _, id := range []string{"p1", "p2", "p3"} {
+		store.CreatePlayer(ctx, &pb.Player{Id: id, Status: pb.PlayerStatus_QUEUED.String()})
+	}
+
+	propose := func(key string, players ...string) {
+		roster := &pb.Roster{}
+		for _, id := range players {
+			roster.Players = append(roster.Players, &pb.Player{Id: id})
+		}
+		store.CreateMatchObject(ctx, &pb.MatchObject{Id: key, Rosters: []*pb.Roster{roster}})
+		store.LeasePlayers(ctx, "proposed", key, players)
+		store.PushQueue(ctx, "proposalq", key)
+	}
+
+	// b is rejected twice: first requeued, then returned as an error.
+	for i := 0; i < 2; i++ {
+		propose("proposal.1.a.profile", "p1", "p2")
+		propose("proposal.2.b.profile", "p2", "p3")
+		if err := Evaluate(ctx, cfg, store); err != nil {
+			t.Fatal(err)
+		}
+
+		a := &pb.MatchObject{Id: "a.profile"}
+		if err := store.RetrieveMatchObject(ctx, a); err != nil || len(a.Rosters) != 1 {
+			t.Errorf("run %v: got (%v, %v), want approved match a", i, a, err)
+		}
+		requeued, _ := store.PopQueue(ctx, "profileq", 10)
+		b := &pb.MatchObject{Id: "b.profile"}
+		err := store.RetrieveMatchObject(ctx, b)
+		if i == 0 && (!reflect.DeepEqual(requeued, []string{"b.profile"}) || err == nil) {
+			t.Errorf("run %v: got requeued %v and result (%v, %v), want b requeued", i, requeued, b, err)
+		}
+		if i == 1 && (len(requeued) != 0 || b.Error != errRejected) {
+			t.Errorf("run %v: got requeued %v and result %v, want rejection error for b", i, requeued, b)
+		}
+
+		ignored, _ := store.RetrieveIgnoreList(ctx, "proposed", 0, 1<<62)
+		sort.Strings(ignored)
+		if !reflect.DeepEqual(ignored, []string{"p1", "p2"}) {
+			t.Errorf("run %v: got ignored %v, want only approved players", i, ignored)
+		}
+		store.RemoveFromIgnoreList(ctx, "proposed", ignored)
+
+		for id, want := range map[string]pb.PlayerStatus{"p1": pb.PlayerStatus_MATCHED, "p3": pb.PlayerStatus_QUEUED} {
+			player := &pb.Player{Id: id}
+			store.RetrievePlayer(ctx, player)
+			if player.Status != want.String() {
+				t.Errorf("run %v: got %v status %v, want %v", i, id, player.Status, want)
+			}
+		}
+	}
+}
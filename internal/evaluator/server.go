@@ -0,0 +1,79 @@
+/*
+Copyright 2019 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evaluator
+
+import (
+	"io"
+	"time"
+
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements the Evaluator gRPC API using Choose.  It doesn't access
+// state storage; the MMForc reads the proposals and applies the results.
+type Server struct {
+	cfg *viper.Viper
+}
+
+// NewServer returns an Evaluator server that scores proposals using the
+// method set in cfg.
+func NewServer(cfg *viper.Viper) *Server {
+	return &Server{cfg: cfg}
+}
+
+// Evaluate is this service's implementation of the gRPC call defined in
+// api/protobuf-spec/evaluator.proto.
+func (s *Server) Evaluate(stream pb.Evaluator_EvaluateServer) error {
+	scorer, err := NewScorer(s.cfg, time.Now())
+	if err != nil {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	proposals := make([]*Proposal, 0)
+	for {
+		match, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		p, err := NewProposal(match.Id, match)
+		if err != nil {
+			evLog.WithFields(log.Fields{"proposal": match.Id, "error": err.Error()}).Warn("Skipping proposal")
+			continue
+		}
+		p.Score = scorer(p)
+		proposals = append(proposals, p)
+	}
+
+	approved, _ := Choose(proposals)
+	evLog.WithFields(log.Fields{
+		"proposals": len(proposals),
+		"approved":  len(approved),
+	}).Info("Evaluated proposals")
+	for _, p := range approved {
+		if err := stream.Send(p.Match); err != nil {
+			return err
+		}
+	}
+	return nil
+}
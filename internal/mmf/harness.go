@@ -1,14 +1,254 @@
+/*
+Copyright 2019 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mmf is a harness for writing matchmaking functions (MMFs) in Go.
+// The developer writes a single MatchFunction containing the matchmaking
+// logic; the harness takes care of everything else an MMF has to do:
+//
+//   - Read the profile written to state storage by the Backend API.
+//   - Retrieve the players in each of the profile's pools from the MMLogic API.
+//   - Write the resulting proposal (or error) back using the MMLogic API, which
+//     also removes the chosen players from consideration by other MMFs and
+//     notifies the MMForc the MMF has finished.
+//
+// The same MatchFunction can be run either as a Kubernetes Job using RunJob,
+// or as a long-running service implementing the Function gRPC API using
+// Serve.
 package mmf
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
 
-	api "github.com/GoogleCloudPlatform/open-match/internal/pb"
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
+	"go.opencensus.io/plugin/ocgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-func Run(fnArgs *api.Arguments, cfg *viper.Viper, mmlogic api.MmLogicClient) error {
-	log.Printf("Function called!\n")
-	log.Printf("args: %v", &fnArgs)
-	return nil
+// Logrus structured logging setup
+var (
+	mmfLogFields = log.Fields{
+		"app":       "openmatch",
+		"component": "mmf",
+	}
+	mmfLog = log.WithFields(mmfLogFields)
+)
+
+// ErrInsufficientPlayers can be returned by a MatchFunction when the pools
+// don't contain enough players to make a match.
+var ErrInsufficientPlayers = errors.New("insufficient_players")
+
+// MatchFunction contains the matchmaking logic.  It is given the profile
+// and the players in each of the profile's pools, keyed by pool name, and
+// returns the proposed match.  The harness sets the ID of the returned match
+// object; if it has no pools, the profile's pools are used.  Any error
+// returned is reported to the Backend API in place of a match.
+type MatchFunction func(ctx context.Context, profile *pb.MatchObject, pools map[string]*pb.PlayerPool) (*pb.MatchObject, error)
+
+// Run runs fn once for the MMF request req, using the MMLogic API to read
+// the profile and pools and write the results.  If profile is nil it is
+// read from state storage.  Errors from the matchmaking logic, or from
+// retrieving its inputs, are written to the request's error ID for the
+// Backend API to return; Run only returns an error if the results couldn't
+// be written, after releasing the proposal in case it was.
+func Run(ctx context.Context, mmlogic pb.MmLogicClient, req *pb.Request, profile *pb.MatchObject, fn MatchFunction) error {
+	runLog := mmfLog.WithFields(log.Fields{
+		"profileID":  req.ProfileId,
+		"proposalID": req.ProposalId,
+	})
+
+	proposal, err := run(ctx, mmlogic, req, profile, fn)
+	if err != nil {
+		runLog.WithFields(log.Fields{"error": err.Error()}).Info("MMF did not produce a proposal, writing error")
+		proposal = &pb.MatchObject{Id: req.ErrorId, Error: err.Error()}
+	} else {
+		runLog.Info("Writing proposal")
+	}
+
+	result, err := mmlogic.CreateProposal(ctx, proposal)
+	if status.Code(err) == codes.Aborted && proposal.Error == "" {
+		// In strict mode, another MMF claimed some of the players first;
+		// report that to the Backend API instead.
+		runLog.WithFields(log.Fields{"error": err.Error()}).Info("Proposal refused, writing error")
+		result, err = mmlogic.CreateProposal(ctx, &pb.MatchObject{Id: req.ErrorId, Error: status.Convert(err).Message()})
+	}
+	if err == nil && !result.Success {
+		err = errors.New(result.Error)
+	}
+	if status.Code(err) == codes.AlreadyExists {
+		// Different results with this ID were already written by another
+		// run; they aren't this run's to release.  Writing the same
+		// results again succeeds.
+		runLog.WithFields(log.Fields{"error": err.Error()}).Warn("Other results already written")
+		return err
+	}
+	if err != nil && proposal.Error == "" {
+		// The proposal may have been written before the call failed;
+		// withdraw it so its players are back in the pool straight away.
+		runLog.WithFields(log.Fields{"error": err.Error()}).Warn("Failure writing proposal, releasing it")
+		if _, rerr := mmlogic.ReleaseProposal(ctx, &pb.MatchObject{Id: proposal.Id}); rerr != nil {
+			runLog.WithFields(log.Fields{"error": rerr.Error()}).Error("Failure releasing proposal")
+		}
+	}
+	return err
+}
+
+// run gets the inputs to fn and returns the proposal it makes.
+func run(ctx context.Context, mmlogic pb.MmLogicClient, req *pb.Request, profile *pb.MatchObject, fn MatchFunction) (*pb.MatchObject, error) {
+	var err error
+	if profile == nil {
+		profile, err = mmlogic.GetProfile(ctx, &pb.MatchObject{Id: req.ProfileId})
+		if err != nil {
+			return nil, fmt.Errorf("failure retrieving profile: %v", err)
+		}
+	}
+
+	pools := make(map[string]*pb.PlayerPool)
+	for _, pool := range profile.Pools {
+		pools[pool.Name], err = playerPool(ctx, mmlogic, pool)
+		if err != nil {
+			return nil, fmt.Errorf("failure retrieving pool '%v': %v", pool.Name, err)
+		}
+		mmfLog.WithFields(log.Fields{
+			"pool":  pool.Name,
+			"count": len(pools[pool.Name].Roster.Players),
+		}).Debug("Retrieved player pool")
+	}
+
+	proposal, err := fn(ctx, profile, pools)
+	if err != nil {
+		return nil, err
+	}
+	if proposal == nil {
+		return nil, errors.New("matchmaking function returned no proposal")
+	}
+	proposal.Id = req.ProposalId
+	if len(proposal.Pools) == 0 {
+		proposal.Pools = profile.Pools
+	}
+	return proposal, nil
+}
+
+// playerPool retrieves all the players in pool.  The MMLogic API streams
+// the pool in chunks, which are combined into one roster here.
+func playerPool(ctx context.Context, mmlogic pb.MmLogicClient, pool *pb.PlayerPool) (*pb.PlayerPool, error) {
+	stream, err := mmlogic.GetPlayerPool(ctx, pool)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &pb.PlayerPool{
+		Name:    pool.Name,
+		Filters: pool.Filters,
+		Roster:  &pb.Roster{Name: pool.Name},
+	}
+	for {
+		partial, err := stream.Recv()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if partial.Filters != nil {
+			result.Filters = partial.Filters
+		}
+		if partial.Stats != nil {
+			result.Stats = partial.Stats
+		}
+		if partial.Roster != nil {
+			result.Roster.Players = append(result.Roster.Players, partial.Roster.Players...)
+		}
+	}
+}
+
+// RunJob runs fn once as a Kubernetes Job started by the MMForc.  The
+// request is read from the MMF_* environment variables the MMForc sets,
+// and the MMLogic API is found using the Kubernetes service environment
+// variables.
+func RunJob(fn MatchFunction) error {
+	req := &pb.Request{
+		ProfileId:  os.Getenv("MMF_PROFILE_ID"),
+		ProposalId: os.Getenv("MMF_PROPOSAL_ID"),
+		RequestId:  os.Getenv("MMF_REQUEST_ID"),
+		ErrorId:    os.Getenv("MMF_ERROR_ID"),
+		Timestamp:  os.Getenv("MMF_TIMESTAMP"),
+	}
+	addr := net.JoinHostPort(os.Getenv("OM_MMLOGICAPI_SERVICE_HOST"), os.Getenv("OM_MMLOGICAPI_SERVICE_PORT"))
+
+	conn, err := grpc.Dial(addr, grpc.WithInsecure(), grpc.WithStatsHandler(&ocgrpc.ClientHandler{}))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return Run(context.Background(), pb.NewMmLogicClient(conn), req, nil, fn)
+}
+
+// FunctionServer implements the Function gRPC API by running a
+// MatchFunction for each call.
+type FunctionServer struct {
+	mmlogic pb.MmLogicClient
+	fn      MatchFunction
+}
+
+// NewFunctionServer returns a Function server that runs fn, using mmlogic
+// to read its inputs and write its results.
+func NewFunctionServer(mmlogic pb.MmLogicClient, fn MatchFunction) *FunctionServer {
+	return &FunctionServer{mmlogic: mmlogic, fn: fn}
+}
+
+// Run is this service's implementation of the gRPC call defined in
+// api/protobuf-spec/function.proto.
+func (s *FunctionServer) Run(ctx context.Context, args *pb.Arguments) (*pb.Result, error) {
+	if args.Request == nil {
+		return &pb.Result{Success: false, Error: "no request in arguments"}, nil
+	}
+	if err := Run(ctx, s.mmlogic, args.Request, args.Matchobject, s.fn); err != nil {
+		return &pb.Result{Success: false, Error: err.Error()}, nil
+	}
+	return &pb.Result{Success: true}, nil
+}
+
+// Serve runs fn as a Function gRPC service on the port configured in
+// api.functions.port, using the MMLogic API configured in api.mmlogic.  It
+// only returns if the server stops.
+func Serve(cfg *viper.Viper, fn MatchFunction) error {
+	addr := net.JoinHostPort(cfg.GetString("api.mmlogic.hostname"), cfg.GetString("api.mmlogic.port"))
+	conn, err := grpc.Dial(addr, grpc.WithInsecure(), grpc.WithStatsHandler(&ocgrpc.ClientHandler{}))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ln, err := net.Listen("tcp", ":"+cfg.GetString("api.functions.port"))
+	if err != nil {
+		return err
+	}
+	mmfLog.WithFields(log.Fields{"port": cfg.GetString("api.functions.port")}).Info("Serving Function gRPC API")
+
+	srv := grpc.NewServer(grpc.StatsHandler(&ocgrpc.ServerHandler{}))
+	pb.RegisterFunctionServer(srv, NewFunctionServer(pb.NewMmLogicClient(conn), fn))
+	return srv.Serve(ln)
 }
@@ -0,0 +1,151 @@
+package mmf
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeMmLogic serves a single profile with one pool that arrives in two
+// chunks, and records the results written.
+type fakeMmLogic struct {
+	proposals []*pb.MatchObject
+	// claimed refuses proposals as a strict mode MMLogic API would.
+	claimed bool
+	// broken fails to write proposals; released records the proposals
+	// released after that.
+	broken   bool
+	released []string
+	// written holds results by ID; writing the same results again
+	// succeeds, but different ones are refused.
+	written map[string]*pb.MatchObject
+}
+
+func (s *fakeMmLogic) GetProfile(ctx context.Context, mo *pb.MatchObject) (*pb.MatchObject, error) {
+	return &pb.MatchObject{Id: mo.Id, Pools: []*pb.PlayerPool{{Name: "everyone"}}}, nil
+}
+
+func (s *fakeMmLogic) CreateProposal(ctx context.Context, mo *pb.MatchObject) (*pb.Result, error) {
+	if s.claimed && mo.Error == "" {
+		return nil, status.Error(codes.Aborted, "players already claimed: a")
+	}
+	if s.broken && mo.Error == "" {
+		return nil, status.Error(codes.Unknown, "state storage unavailable")
+	}
+	if prev, ok := s.written[mo.Id]; ok {
+		if !proto.Equal(prev, mo) {
+			return nil, status.Error(codes.AlreadyExists, "different results already submitted")
+		}
+		return &pb.Result{Success: true}, nil
+	}
+	s.written[mo.Id] = mo
+	s.proposals = append(s.proposals, mo)
+	return &pb.Result{Success: true}, nil
+}
+
+func (s *fakeMmLogic) GetPlayerPool(pool *pb.PlayerPool, stream pb.MmLogic_GetPlayerPoolServer) error {
+	for _, id := range []string{"a", "b"} {
+		chunk := &pb.PlayerPool{Name: pool.Name, Roster: &pb.Roster{Players: []*pb.Player{{Id: id}}}}
+		if err := stream.Send(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *fakeMmLogic) ReleaseProposal(ctx context.Context, mo *pb.MatchObject) (*pb.Result, error) {
+	s.released = append(s.released, mo.Id)
+	return &pb.Result{Success: true}, nil
+}
+
+func (s *fakeMmLogic) WatchPlayerPool(pool *pb.PlayerPool, stream pb.MmLogic_WatchPlayerPoolServer) error {
+	return nil
+}
+
+func (s *fakeMmLogic) GetAllIgnoredPlayers(ctx context.Context, in *pb.IlInput) (*pb.Roster, error) {
+	return &pb.Roster{}, nil
+}
+
+func (s *fakeMmLogic) ListIgnoredPlayers(ctx context.Context, in *pb.IlInput) (*pb.Roster, error) {
+	return &pb.Roster{}, nil
+}
+
+func TestRun(t *testing.T) {
+	fake := &fakeMmLogic{written: map[string]*pb.MatchObject{}}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	pb.RegisterMmLogicServer(srv, fake)
+	go srv.Serve(ln)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(ln.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	mmlogic := pb.NewMmLogicClient(conn)
+	req := &pb.Request{ProfileId: "profile", ProposalId: "proposal.1.mo.profile", ErrorId: "mo.profile"}
+
+	match := func(ctx context.Context, profile *pb.MatchObject, pools map[string]*pb.PlayerPool) (*pb.MatchObject, error) {
+		return &pb.MatchObject{Rosters: []*pb.Roster{pools["everyone"].Roster}}, nil
+	}
+	if err := Run(context.Background(), mmlogic, req, nil, match); err != nil {
+		t.Fatal(err)
+	}
+	got := fake.proposals[0]
+	if got.Id != req.ProposalId || len(got.Pools) != 1 || len(got.Rosters[0].Players) != 2 {
+		t.Errorf("got proposal %v, want both players in %v", got, req.ProposalId)
+	}
+
+	fail := func(ctx context.Context, profile *pb.MatchObject, pools map[string]*pb.PlayerPool) (*pb.MatchObject, error) {
+		return nil, ErrInsufficientPlayers
+	}
+	result, err := NewFunctionServer(mmlogic, fail).Run(context.Background(), &pb.Arguments{Request: req})
+	if err != nil || !result.Success {
+		t.Fatalf("got (%v, %v), want success writing error", result, err)
+	}
+	got = fake.proposals[1]
+	if got.Id != req.ErrorId || got.Error != ErrInsufficientPlayers.Error() {
+		t.Errorf("got %v, want error %v written to %v", got, ErrInsufficientPlayers, req.ErrorId)
+	}
+	fake.claimed, req.ErrorId = true, "mo.profile.2"
+	if err := Run(context.Background(), mmlogic, req, nil, match); err != nil {
+		t.Fatal(err)
+	}
+	got = fake.proposals[2]
+	if got.Id != req.ErrorId || got.Error != "players already claimed: a" {
+		t.Errorf("got %v, want claim error written to %v", got, req.ErrorId)
+	}
+	fake.claimed, fake.broken = false, true
+	if err := Run(context.Background(), mmlogic, req, nil, match); err == nil {
+		t.Error("got nil, want error writing proposal")
+	}
+	if len(fake.released) != 1 || fake.released[0] != req.ProposalId {
+		t.Errorf("got released %v, want %v", fake.released, req.ProposalId)
+	}
+
+	// Writing the same results again succeeds; different results already
+	// written by someone else are refused, and aren't released.
+	fake.broken = false
+	if err := Run(context.Background(), mmlogic, req, nil, match); err != nil {
+		t.Errorf("got %v writing the same results again, want nil", err)
+	}
+	first := func(ctx context.Context, profile *pb.MatchObject, pools map[string]*pb.PlayerPool) (*pb.MatchObject, error) {
+		return &pb.MatchObject{Rosters: []*pb.Roster{{Players: pools["everyone"].Roster.Players[:1]}}}, nil
+	}
+	if err := Run(context.Background(), mmlogic, req, nil, first); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("got %v, want AlreadyExists", err)
+	}
+	if len(fake.released) != 1 {
+		t.Errorf("got released %v, want only the first", fake.released)
+	}
+}
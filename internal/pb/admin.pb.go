@@ -0,0 +1,209 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: api/protobuf-spec/admin.proto
+
+package pb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+func init() { proto.RegisterFile("api/protobuf-spec/admin.proto", fileDescriptor_2aa62e1034e306be) }
+
+var fileDescriptor_2aa62e1034e306be = []byte{
+	// 209 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0xcf, 0xbf, 0x4a, 0x04, 0x31,
+	0x10, 0xc7, 0x71, 0x45, 0x14, 0xc9, 0x35, 0x12, 0xb1, 0x59, 0x10, 0xc4, 0xfe, 0x36, 0xa0, 0x1c,
+	0x6a, 0x79, 0x5a, 0xc8, 0x81, 0xc5, 0x71, 0xa5, 0xdd, 0x24, 0x99, 0xdb, 0x1d, 0x48, 0x32, 0x21,
+	0x7f, 0x40, 0x9f, 0xcb, 0x17, 0x94, 0x8d, 0xe0, 0x16, 0xda, 0x5c, 0xfb, 0x99, 0xef, 0xaf, 0x18,
+	0x71, 0x0d, 0x91, 0x54, 0x4c, 0x5c, 0x58, 0xd7, 0xfd, 0x32, 0x47, 0x34, 0x0a, 0xac, 0xa7, 0xd0,
+	0x37, 0x93, 0x27, 0x10, 0xa9, 0xbb, 0xf9, 0xdb, 0x78, 0xcc, 0x19, 0x06, 0xcc, 0x3f, 0xd9, 0xdd,
+	0xd7, 0xb1, 0x38, 0x5d, 0x4f, 0x33, 0xf9, 0x24, 0x16, 0x6f, 0x94, 0xcb, 0x26, 0x58, 0x32, 0x98,
+	0xe5, 0x65, 0xff, 0x5b, 0x6e, 0x82, 0xc5, 0x8f, 0xe9, 0xd6, 0xfd, 0x87, 0xb7, 0x47, 0x72, 0x25,
+	0xce, 0xd7, 0xd6, 0x36, 0x91, 0x57, 0x73, 0xb2, 0x75, 0xf0, 0x89, 0xa9, 0x71, 0x77, 0x31, 0xf3,
+	0x0e, 0x73, 0x75, 0xd3, 0xec, 0x51, 0x2c, 0x76, 0x58, 0x28, 0xe1, 0xa1, 0xcb, 0xe7, 0x87, 0xf7,
+	0xd5, 0x40, 0x65, 0xac, 0xba, 0x37, 0xec, 0xd5, 0x2b, 0xf3, 0xe0, 0xf0, 0xc5, 0x71, 0xb5, 0x5b,
+	0x07, 0x65, 0xcf, 0xc9, 0x2b, 0x8e, 0x18, 0x96, 0x1e, 0x8a, 0x19, 0x15, 0x85, 0x82, 0x29, 0x80,
+	0x53, 0x51, 0xeb, 0xb3, 0xf6, 0xf5, 0xfd, 0xf7, 0x00, 0x5a, 0x95, 0x07, 0x33, 0x3d, 0x01, 0x00,
+	0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// AdminClient is the client API for Admin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type AdminClient interface {
+	// ListIndices returns every entry in the registry, including retired
+	// indices.  The input is ignored.
+	ListIndices(ctx context.Context, in *IndexList, opts ...grpc.CallOption) (*IndexList, error)
+	// AddIndex adds a player index, or adds back a retired one.  Queued and
+	// proposed players already in state storage are backfilled into it once
+	// every API has reloaded the registry.  Adding an index that is already active with
+	// the same type does nothing.  Changing the type of an index fails with
+	// FAILED_PRECONDITION until it has been retired and its sorted sets
+	// deleted.
+	//
+	// INPUT: PlayerIndex with the attribute and, optionally, the type set.
+	AddIndex(ctx context.Context, in *PlayerIndex, opts ...grpc.CallOption) (*Result, error)
+	// RetireIndex stops indexing players under an index and filtering on it.
+	// Its sorted sets are deleted indexRegistry.gcDelay seconds later.
+	//
+	// INPUT: PlayerIndex with the attribute set.
+	RetireIndex(ctx context.Context, in *PlayerIndex, opts ...grpc.CallOption) (*Result, error)
+}
+
+type adminClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewAdminClient(cc *grpc.ClientConn) AdminClient {
+	return &adminClient{cc}
+}
+
+func (c *adminClient) ListIndices(ctx context.Context, in *IndexList, opts ...grpc.CallOption) (*IndexList, error) {
+	out := new(IndexList)
+	err := c.cc.Invoke(ctx, "/api.Admin/ListIndices", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) AddIndex(ctx context.Context, in *PlayerIndex, opts ...grpc.CallOption) (*Result, error) {
+	out := new(Result)
+	err := c.cc.Invoke(ctx, "/api.Admin/AddIndex", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) RetireIndex(ctx context.Context, in *PlayerIndex, opts ...grpc.CallOption) (*Result, error) {
+	out := new(Result)
+	err := c.cc.Invoke(ctx, "/api.Admin/RetireIndex", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServer is the server API for Admin service.
+type AdminServer interface {
+	// ListIndices returns every entry in the registry, including retired
+	// indices.  The input is ignored.
+	ListIndices(context.Context, *IndexList) (*IndexList, error)
+	// AddIndex adds a player index, or adds back a retired one.  Queued and
+	// proposed players already in state storage are backfilled into it once
+	// every API has reloaded the registry.  Adding an index that is already active with
+	// the same type does nothing.  Changing the type of an index fails with
+	// FAILED_PRECONDITION until it has been retired and its sorted sets
+	// deleted.
+	//
+	// INPUT: PlayerIndex with the attribute and, optionally, the type set.
+	AddIndex(context.Context, *PlayerIndex) (*Result, error)
+	// RetireIndex stops indexing players under an index and filtering on it.
+	// Its sorted sets are deleted indexRegistry.gcDelay seconds later.
+	//
+	// INPUT: PlayerIndex with the attribute set.
+	RetireIndex(context.Context, *PlayerIndex) (*Result, error)
+}
+
+func RegisterAdminServer(s *grpc.Server, srv AdminServer) {
+	s.RegisterService(&_Admin_serviceDesc, srv)
+}
+
+func _Admin_ListIndices_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IndexList)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ListIndices(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Admin/ListIndices",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ListIndices(ctx, req.(*IndexList))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_AddIndex_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PlayerIndex)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).AddIndex(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Admin/AddIndex",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).AddIndex(ctx, req.(*PlayerIndex))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_RetireIndex_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PlayerIndex)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).RetireIndex(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Admin/RetireIndex",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).RetireIndex(ctx, req.(*PlayerIndex))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Admin_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "api.Admin",
+	HandlerType: (*AdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListIndices",
+			Handler:    _Admin_ListIndices_Handler,
+		},
+		{
+			MethodName: "AddIndex",
+			Handler:    _Admin_AddIndex_Handler,
+		},
+		{
+			MethodName: "RetireIndex",
+			Handler:    _Admin_RetireIndex_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "api/protobuf-spec/admin.proto",
+}
@@ -25,22 +25,25 @@ const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 func init() { proto.RegisterFile("api/protobuf-spec/backend.proto", fileDescriptor_92161ae1f6f50f7a) }
 
 var fileDescriptor_92161ae1f6f50f7a = []byte{
-	// 240 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x91, 0x3f, 0x4b, 0x04, 0x31,
-	0x10, 0x47, 0xfd, 0x03, 0x0a, 0xd9, 0xc6, 0x0b, 0xd8, 0x5c, 0xa3, 0xd8, 0xdf, 0x46, 0x14, 0x51,
-	0x0b, 0x15, 0xef, 0x04, 0x1b, 0x45, 0xb1, 0xb4, 0x4b, 0x72, 0x73, 0x7b, 0xd1, 0x24, 0x13, 0x32,
-	0xb3, 0x5f, 0xcf, 0xcf, 0x26, 0xbb, 0x01, 0x5d, 0x71, 0x41, 0x6c, 0x1f, 0xef, 0xf1, 0x9b, 0x10,
-	0x71, 0xa0, 0x93, 0x53, 0x29, 0x23, 0xa3, 0x69, 0x57, 0x33, 0x4a, 0x60, 0x95, 0xd1, 0xf6, 0x1d,
-	0xe2, 0xb2, 0xee, 0xa9, 0xdc, 0xd6, 0xc9, 0x4d, 0x0f, 0x7f, 0x5b, 0x01, 0x88, 0x74, 0x03, 0x54,
-	0xb4, 0x93, 0x8f, 0x2d, 0xb1, 0x3b, 0x2f, 0xa1, 0xbc, 0x12, 0xd5, 0x22, 0x83, 0x66, 0x78, 0xd4,
-	0x6c, 0xd7, 0x72, 0xbf, 0xfe, 0x72, 0x7b, 0xf0, 0x64, 0xde, 0xc0, 0xf2, 0x74, 0x1c, 0x1f, 0x6d,
-	0xc8, 0x1b, 0x51, 0x3d, 0x38, 0xe2, 0x1e, 0x02, 0xfd, 0x37, 0x3f, 0xde, 0x94, 0x17, 0xa2, 0xba,
-	0x03, 0x0f, 0x7f, 0xec, 0xef, 0x7d, 0xe3, 0x17, 0xa0, 0xd6, 0x77, 0xd3, 0xd7, 0x62, 0x52, 0x2e,
-	0xbf, 0x25, 0x72, 0x4d, 0x0c, 0x10, 0xf9, 0xc7, 0x01, 0x03, 0x3c, 0xda, 0x5f, 0x8a, 0x49, 0x59,
-	0x1e, 0xf6, 0x43, 0x11, 0x89, 0x21, 0x8f, 0xa5, 0xf3, 0xf3, 0xd7, 0xb3, 0xc6, 0xf1, 0xba, 0x35,
-	0xb5, 0xc5, 0xa0, 0xee, 0x11, 0x1b, 0x0f, 0x0b, 0x8f, 0xed, 0xf2, 0xd9, 0x6b, 0x5e, 0x61, 0x0e,
-	0x0a, 0x13, 0xc4, 0x59, 0xe8, 0x5e, 0xa0, 0x5c, 0x64, 0xc8, 0x51, 0x7b, 0x95, 0x8c, 0xd9, 0xe9,
-	0x3f, 0xe0, 0xf4, 0x33, 0x00, 0x00, 0xff, 0xff, 0x33, 0x3a, 0x1e, 0x0d, 0xca, 0x01, 0x00, 0x00,
+	// 279 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x92, 0x41, 0x4b, 0xf3, 0x40,
+	0x10, 0x86, 0x1b, 0xbe, 0x0f, 0x85, 0x8d, 0x88, 0x59, 0xf0, 0xd2, 0x8b, 0xd2, 0x93, 0x97, 0x66,
+	0x8b, 0x22, 0xea, 0xc1, 0xaa, 0xad, 0x90, 0x8b, 0xa2, 0xf4, 0x22, 0x78, 0xdb, 0x4d, 0xa7, 0xe9,
+	0xea, 0x66, 0x77, 0xcd, 0x4c, 0x7e, 0x84, 0xff, 0x5a, 0x9a, 0x15, 0x8d, 0x18, 0x90, 0x5c, 0x1f,
+	0xde, 0xe7, 0x9d, 0x99, 0x64, 0xd9, 0x81, 0xf4, 0x5a, 0xf8, 0xca, 0x91, 0x53, 0xf5, 0x6a, 0x8c,
+	0x1e, 0x72, 0xa1, 0x64, 0xfe, 0x0a, 0x76, 0x99, 0x36, 0x94, 0xff, 0x93, 0x5e, 0x0f, 0x0f, 0x7f,
+	0xa7, 0x4a, 0x40, 0x94, 0x05, 0x60, 0x88, 0x1d, 0xbf, 0xff, 0x67, 0xdb, 0xb3, 0x20, 0xf2, 0x4b,
+	0x16, 0xcf, 0x2b, 0x90, 0x04, 0xf7, 0x92, 0xf2, 0x35, 0xdf, 0x4f, 0xbf, 0xb2, 0x0d, 0x78, 0x50,
+	0x2f, 0x90, 0xd3, 0xb0, 0x1b, 0x8f, 0x06, 0xfc, 0x8a, 0xc5, 0x77, 0x1a, 0xa9, 0x81, 0x80, 0x7d,
+	0xf5, 0x49, 0xc4, 0xa7, 0x6c, 0x67, 0x01, 0x6f, 0x35, 0x7c, 0x76, 0xf4, 0x5e, 0xe0, 0x9a, 0xed,
+	0x66, 0x10, 0xdc, 0x05, 0x60, 0x6d, 0xa8, 0x77, 0x43, 0xc6, 0x92, 0xa7, 0x0d, 0x68, 0x75, 0xf4,
+	0x3e, 0xe4, 0x28, 0x9a, 0x44, 0xfc, 0x9c, 0xc5, 0xb7, 0x60, 0xe0, 0x8f, 0x4f, 0xb9, 0xf7, 0x8d,
+	0xc3, 0xb0, 0xd1, 0x80, 0x4f, 0x59, 0x12, 0x7e, 0xc2, 0x0d, 0xa2, 0x2e, 0x6c, 0x09, 0xf6, 0xe7,
+	0x0a, 0x2d, 0xdc, 0xe9, 0x5f, 0xb0, 0x24, 0x4c, 0x6e, 0xfb, 0xed, 0xa0, 0x43, 0x82, 0xaa, 0x4b,
+	0x9d, 0x9d, 0x3d, 0x9f, 0x16, 0x9a, 0xd6, 0xb5, 0x4a, 0x73, 0x57, 0x8a, 0xcc, 0xb9, 0xc2, 0xc0,
+	0xdc, 0xb8, 0x7a, 0xf9, 0x68, 0x24, 0xad, 0x5c, 0x55, 0x0a, 0xe7, 0xc1, 0x8e, 0xcb, 0xcd, 0x05,
+	0x42, 0x5b, 0x82, 0xca, 0x4a, 0x23, 0xbc, 0x52, 0x5b, 0xcd, 0x5b, 0x3a, 0xf9, 0x18, 0x00, 0x57,
+	0x73, 0x42, 0x54, 0x95, 0x02, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -71,6 +74,26 @@ type BackendClient interface {
 	// Continually run MMF and stream MatchObjects that fit this profile until
 	// the backend client closes the connection.  Same inputs/outputs as CreateMatch.
 	ListMatches(ctx context.Context, in *MatchObject, opts ...grpc.CallOption) (Backend_ListMatchesClient, error)
+	// Asynchronous version of CreateMatch.  Queues the profile to be filled and
+	// returns immediately, without waiting for the MMF to run.
+	// INPUT: Same as CreateMatch.
+	// OUTPUT: MatchObject message with the 'id' field populated with the match
+	//   request ID.  Pass it to GetMatchResult or WatchMatchResults to get the
+	//   results.
+	RequestMatch(ctx context.Context, in *MatchObject, opts ...grpc.CallOption) (*MatchObject, error)
+	// Get the results of a match request made with RequestMatch, without
+	// waiting.  Returns NOT_FOUND if the results aren't available yet.
+	// INPUT: MatchObject message with the 'id' field set to the match request ID.
+	// OUTPUT: Same as CreateMatch.
+	GetMatchResult(ctx context.Context, in *MatchObject, opts ...grpc.CallOption) (*MatchObject, error)
+	// Stream the results of match requests made with RequestMatch as they
+	// become available.  Send a MatchObject message with the 'id' field set to
+	// the match request ID for every request to watch; each result is sent
+	// back once, with the same id.  Results that aren't available before the
+	// backend backoff deadline are sent back with the 'error' field set.  The
+	// stream ends once the client has closed its side and every result has
+	// been sent.
+	WatchMatchResults(ctx context.Context, opts ...grpc.CallOption) (Backend_WatchMatchResultsClient, error)
 	// Delete a MatchObject from state storage manually. (MatchObjects in state
 	// storage will also automatically expire after a while, defined in the config)
 	// INPUT: MatchObject message with the 'id' field populated.
@@ -89,12 +112,15 @@ type BackendClient interface {
 	//     player messages. All players from all rosters will be sent the assignment.
 	//     The only field in the Roster's Player messages used by CreateAssignments is
 	//     the id field.  All other fields in the Player messages are silently ignored.
+	//     If an id is a party (see CreateParty in frontend.proto), every member
+	//     of the party is also sent the assignment.
 	CreateAssignments(ctx context.Context, in *Assignments, opts ...grpc.CallOption) (*Result, error)
 	// Remove DGS connection info from state storage for players.
 	// INPUT: Roster message with the 'players' field populated.
 	//    The only field in the Roster's Player messages used by
 	//    DeleteAssignments is the 'id' field.  All others are silently ignored.  If
-	//    you need to delete multiple rosters, make multiple calls.
+	//    you need to delete multiple rosters, make multiple calls.  Assignments
+	//    are also removed from the members of any parties in the roster.
 	DeleteAssignments(ctx context.Context, in *Roster, opts ...grpc.CallOption) (*Result, error)
 }
 
@@ -147,6 +173,55 @@ func (x *backendListMatchesClient) Recv() (*MatchObject, error) {
 	return m, nil
 }
 
+func (c *backendClient) RequestMatch(ctx context.Context, in *MatchObject, opts ...grpc.CallOption) (*MatchObject, error) {
+	out := new(MatchObject)
+	err := c.cc.Invoke(ctx, "/api.Backend/RequestMatch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *backendClient) GetMatchResult(ctx context.Context, in *MatchObject, opts ...grpc.CallOption) (*MatchObject, error) {
+	out := new(MatchObject)
+	err := c.cc.Invoke(ctx, "/api.Backend/GetMatchResult", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *backendClient) WatchMatchResults(ctx context.Context, opts ...grpc.CallOption) (Backend_WatchMatchResultsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Backend_serviceDesc.Streams[1], "/api.Backend/WatchMatchResults", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &backendWatchMatchResultsClient{stream}
+	return x, nil
+}
+
+type Backend_WatchMatchResultsClient interface {
+	Send(*MatchObject) error
+	Recv() (*MatchObject, error)
+	grpc.ClientStream
+}
+
+type backendWatchMatchResultsClient struct {
+	grpc.ClientStream
+}
+
+func (x *backendWatchMatchResultsClient) Send(m *MatchObject) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *backendWatchMatchResultsClient) Recv() (*MatchObject, error) {
+	m := new(MatchObject)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *backendClient) DeleteMatch(ctx context.Context, in *MatchObject, opts ...grpc.CallOption) (*Result, error) {
 	out := new(Result)
 	err := c.cc.Invoke(ctx, "/api.Backend/DeleteMatch", in, out, opts...)
@@ -192,6 +267,26 @@ type BackendServer interface {
 	// Continually run MMF and stream MatchObjects that fit this profile until
 	// the backend client closes the connection.  Same inputs/outputs as CreateMatch.
 	ListMatches(*MatchObject, Backend_ListMatchesServer) error
+	// Asynchronous version of CreateMatch.  Queues the profile to be filled and
+	// returns immediately, without waiting for the MMF to run.
+	// INPUT: Same as CreateMatch.
+	// OUTPUT: MatchObject message with the 'id' field populated with the match
+	//   request ID.  Pass it to GetMatchResult or WatchMatchResults to get the
+	//   results.
+	RequestMatch(context.Context, *MatchObject) (*MatchObject, error)
+	// Get the results of a match request made with RequestMatch, without
+	// waiting.  Returns NOT_FOUND if the results aren't available yet.
+	// INPUT: MatchObject message with the 'id' field set to the match request ID.
+	// OUTPUT: Same as CreateMatch.
+	GetMatchResult(context.Context, *MatchObject) (*MatchObject, error)
+	// Stream the results of match requests made with RequestMatch as they
+	// become available.  Send a MatchObject message with the 'id' field set to
+	// the match request ID for every request to watch; each result is sent
+	// back once, with the same id.  Results that aren't available before the
+	// backend backoff deadline are sent back with the 'error' field set.  The
+	// stream ends once the client has closed its side and every result has
+	// been sent.
+	WatchMatchResults(Backend_WatchMatchResultsServer) error
 	// Delete a MatchObject from state storage manually. (MatchObjects in state
 	// storage will also automatically expire after a while, defined in the config)
 	// INPUT: MatchObject message with the 'id' field populated.
@@ -210,12 +305,15 @@ type BackendServer interface {
 	//     player messages. All players from all rosters will be sent the assignment.
 	//     The only field in the Roster's Player messages used by CreateAssignments is
 	//     the id field.  All other fields in the Player messages are silently ignored.
+	//     If an id is a party (see CreateParty in frontend.proto), every member
+	//     of the party is also sent the assignment.
 	CreateAssignments(context.Context, *Assignments) (*Result, error)
 	// Remove DGS connection info from state storage for players.
 	// INPUT: Roster message with the 'players' field populated.
 	//    The only field in the Roster's Player messages used by
 	//    DeleteAssignments is the 'id' field.  All others are silently ignored.  If
-	//    you need to delete multiple rosters, make multiple calls.
+	//    you need to delete multiple rosters, make multiple calls.  Assignments
+	//    are also removed from the members of any parties in the roster.
 	DeleteAssignments(context.Context, *Roster) (*Result, error)
 }
 
@@ -262,6 +360,68 @@ func (x *backendListMatchesServer) Send(m *MatchObject) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Backend_RequestMatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MatchObject)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BackendServer).RequestMatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Backend/RequestMatch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BackendServer).RequestMatch(ctx, req.(*MatchObject))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Backend_GetMatchResult_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MatchObject)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BackendServer).GetMatchResult(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Backend/GetMatchResult",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BackendServer).GetMatchResult(ctx, req.(*MatchObject))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Backend_WatchMatchResults_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(BackendServer).WatchMatchResults(&backendWatchMatchResultsServer{stream})
+}
+
+type Backend_WatchMatchResultsServer interface {
+	Send(*MatchObject) error
+	Recv() (*MatchObject, error)
+	grpc.ServerStream
+}
+
+type backendWatchMatchResultsServer struct {
+	grpc.ServerStream
+}
+
+func (x *backendWatchMatchResultsServer) Send(m *MatchObject) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *backendWatchMatchResultsServer) Recv() (*MatchObject, error) {
+	m := new(MatchObject)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func _Backend_DeleteMatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(MatchObject)
 	if err := dec(in); err != nil {
@@ -324,6 +484,14 @@ var _Backend_serviceDesc = grpc.ServiceDesc{
 			MethodName: "CreateMatch",
 			Handler:    _Backend_CreateMatch_Handler,
 		},
+		{
+			MethodName: "RequestMatch",
+			Handler:    _Backend_RequestMatch_Handler,
+		},
+		{
+			MethodName: "GetMatchResult",
+			Handler:    _Backend_GetMatchResult_Handler,
+		},
 		{
 			MethodName: "DeleteMatch",
 			Handler:    _Backend_DeleteMatch_Handler,
@@ -343,6 +511,12 @@ var _Backend_serviceDesc = grpc.ServiceDesc{
 			Handler:       _Backend_ListMatches_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "WatchMatchResults",
+			Handler:       _Backend_WatchMatchResults_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "api/protobuf-spec/backend.proto",
 }
@@ -0,0 +1,156 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: api/protobuf-spec/evaluator.proto
+
+package pb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+func init() { proto.RegisterFile("api/protobuf-spec/evaluator.proto", fileDescriptor_3e4c1f3651d4b73d) }
+
+var fileDescriptor_3e4c1f3651d4b73d = []byte{
+	// 171 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x52, 0x4c, 0x2c, 0xc8, 0xd4,
+	0x2f, 0x28, 0xca, 0x2f, 0xc9, 0x4f, 0x2a, 0x4d, 0xd3, 0x2d, 0x2e, 0x48, 0x4d, 0xd6, 0x4f, 0x2d,
+	0x4b, 0xcc, 0x29, 0x4d, 0x2c, 0xc9, 0x2f, 0xd2, 0x03, 0x8b, 0x0b, 0x31, 0x27, 0x16, 0x64, 0x4a,
+	0x29, 0x60, 0xaa, 0xcb, 0x4d, 0x2d, 0x2e, 0x4e, 0x4c, 0x4f, 0x2d, 0x86, 0x28, 0x33, 0xf2, 0xe6,
+	0xe2, 0x74, 0x85, 0xe9, 0x14, 0xb2, 0xe3, 0xe2, 0x80, 0x72, 0x52, 0x85, 0x44, 0xf5, 0xe0, 0x2a,
+	0x7d, 0x13, 0x4b, 0x92, 0x33, 0xfc, 0x93, 0xb2, 0x52, 0x93, 0x4b, 0xa4, 0xb0, 0x0b, 0x2b, 0x31,
+	0x68, 0x30, 0x1a, 0x30, 0x3a, 0x99, 0x47, 0x99, 0xa6, 0x67, 0x96, 0x64, 0x94, 0x26, 0xe9, 0x25,
+	0xe7, 0xe7, 0xea, 0xbb, 0xe7, 0xe7, 0xa7, 0xe7, 0xa4, 0x3a, 0xe7, 0xe4, 0x97, 0xa6, 0x04, 0xe4,
+	0x24, 0x96, 0xa4, 0xe5, 0x17, 0xe5, 0xea, 0xe7, 0x17, 0xa4, 0xe6, 0xe9, 0xe6, 0x82, 0x34, 0xea,
+	0x67, 0xe6, 0x95, 0xa4, 0x16, 0xe5, 0x25, 0xe6, 0xe8, 0x17, 0x24, 0x25, 0xb1, 0x81, 0x1d, 0x63,
+	0x0c, 0x18, 0x00, 0x03, 0xe2, 0xe2, 0x11, 0xd8, 0x00, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// EvaluatorClient is the client API for Evaluator service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type EvaluatorClient interface {
+	// The MMForc calls Evaluate() once for each evaluation, and streams every
+	// proposed MatchObject to the evaluator, with the id field set to the
+	// proposal's key.  Once all proposals have been sent, it closes its side of
+	// the stream.  The evaluator then streams back the proposals to approve,
+	// with the same ids; approved proposals can have their other fields
+	// modified.  Any proposal not streamed back is rejected.
+	Evaluate(ctx context.Context, opts ...grpc.CallOption) (Evaluator_EvaluateClient, error)
+}
+
+type evaluatorClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewEvaluatorClient(cc *grpc.ClientConn) EvaluatorClient {
+	return &evaluatorClient{cc}
+}
+
+func (c *evaluatorClient) Evaluate(ctx context.Context, opts ...grpc.CallOption) (Evaluator_EvaluateClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Evaluator_serviceDesc.Streams[0], "/api.Evaluator/Evaluate", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &evaluatorEvaluateClient{stream}
+	return x, nil
+}
+
+type Evaluator_EvaluateClient interface {
+	Send(*MatchObject) error
+	Recv() (*MatchObject, error)
+	grpc.ClientStream
+}
+
+type evaluatorEvaluateClient struct {
+	grpc.ClientStream
+}
+
+func (x *evaluatorEvaluateClient) Send(m *MatchObject) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *evaluatorEvaluateClient) Recv() (*MatchObject, error) {
+	m := new(MatchObject)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// EvaluatorServer is the server API for Evaluator service.
+type EvaluatorServer interface {
+	// The MMForc calls Evaluate() once for each evaluation, and streams every
+	// proposed MatchObject to the evaluator, with the id field set to the
+	// proposal's key.  Once all proposals have been sent, it closes its side of
+	// the stream.  The evaluator then streams back the proposals to approve,
+	// with the same ids; approved proposals can have their other fields
+	// modified.  Any proposal not streamed back is rejected.
+	Evaluate(Evaluator_EvaluateServer) error
+}
+
+func RegisterEvaluatorServer(s *grpc.Server, srv EvaluatorServer) {
+	s.RegisterService(&_Evaluator_serviceDesc, srv)
+}
+
+func _Evaluator_Evaluate_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(EvaluatorServer).Evaluate(&evaluatorEvaluateServer{stream})
+}
+
+type Evaluator_EvaluateServer interface {
+	Send(*MatchObject) error
+	Recv() (*MatchObject, error)
+	grpc.ServerStream
+}
+
+type evaluatorEvaluateServer struct {
+	grpc.ServerStream
+}
+
+func (x *evaluatorEvaluateServer) Send(m *MatchObject) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *evaluatorEvaluateServer) Recv() (*MatchObject, error) {
+	m := new(MatchObject)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+var _Evaluator_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "api.Evaluator",
+	HandlerType: (*EvaluatorServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Evaluate",
+			Handler:       _Evaluator_Evaluate_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "api/protobuf-spec/evaluator.proto",
+}
@@ -25,20 +25,24 @@ const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 func init() { proto.RegisterFile("api/protobuf-spec/frontend.proto", fileDescriptor_6805b20a50ffa9ae) }
 
 var fileDescriptor_6805b20a50ffa9ae = []byte{
-	// 201 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0xcf, 0x3d, 0x6b, 0xc3, 0x30,
-	0x10, 0xc6, 0x71, 0x9b, 0x42, 0x29, 0xa2, 0x43, 0xf1, 0xe8, 0xa9, 0x78, 0xb7, 0x55, 0xfa, 0x42,
-	0xf7, 0xba, 0xd4, 0xab, 0x29, 0x74, 0xe9, 0x76, 0xb2, 0xcf, 0xb6, 0x40, 0xd2, 0x09, 0xe9, 0x34,
-	0xf4, 0x3b, 0xf5, 0x43, 0x86, 0xd8, 0x21, 0x64, 0x08, 0x81, 0xac, 0x7f, 0x9e, 0xdf, 0xf0, 0x88,
-	0x47, 0xf0, 0x5a, 0xfa, 0x40, 0x4c, 0x2a, 0x4d, 0x75, 0xf4, 0x38, 0xc8, 0x29, 0x90, 0x63, 0x74,
-	0x63, 0xb3, 0xe6, 0xe2, 0x06, 0xbc, 0x2e, 0xcf, 0xcc, 0x2c, 0xc6, 0x08, 0x33, 0xc6, 0x6d, 0xf6,
-	0xfc, 0x9f, 0x8b, 0xbb, 0xaf, 0x83, 0x2c, 0x5e, 0xc5, 0x7d, 0x1b, 0x10, 0x18, 0x7b, 0x03, 0x7f,
-	0x18, 0x8a, 0x87, 0xe6, 0xb8, 0xde, 0x4a, 0x79, 0x52, 0xbe, 0x31, 0x26, 0xc3, 0x55, 0xb6, 0x57,
-	0x9f, 0x68, 0xf0, 0x6a, 0x25, 0x3a, 0xe4, 0x1f, 0x3f, 0x02, 0x63, 0xbc, 0x6c, 0xb6, 0x52, 0x65,
-	0x4f, 0xf9, 0xc7, 0xfb, 0xef, 0xdb, 0xac, 0x79, 0x49, 0xaa, 0x19, 0xc8, 0xca, 0x8e, 0x68, 0x36,
-	0xd8, 0x1a, 0x4a, 0x63, 0x6f, 0x80, 0x27, 0x0a, 0x56, 0x92, 0x47, 0x57, 0x5b, 0xe0, 0x61, 0x91,
-	0xda, 0x31, 0x06, 0x07, 0x46, 0x7a, 0xa5, 0x6e, 0xd7, 0xbb, 0x2f, 0xbb, 0x00, 0x00, 0x00, 0xff,
-	0xff, 0xe8, 0x9b, 0x69, 0x06, 0x39, 0x01, 0x00, 0x00,
+	// 265 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x92, 0x41, 0x4b, 0xc3, 0x40,
+	0x10, 0x85, 0x2b, 0x8a, 0xe8, 0x54, 0xb1, 0xe6, 0x98, 0x93, 0xf4, 0xde, 0xac, 0xc6, 0xaa, 0x78,
+	0xb5, 0x62, 0x3d, 0x96, 0x82, 0x17, 0x6f, 0x93, 0x64, 0x92, 0x06, 0x36, 0x99, 0x65, 0x77, 0x72,
+	0xe8, 0x3f, 0xf2, 0x67, 0x4a, 0x9b, 0x50, 0x16, 0x29, 0x92, 0xf6, 0xfa, 0x31, 0x1f, 0xef, 0xf1,
+	0x18, 0xb8, 0x43, 0x53, 0x2a, 0x63, 0x59, 0x38, 0x69, 0xf2, 0x89, 0x33, 0x94, 0xaa, 0xdc, 0x72,
+	0x2d, 0x54, 0x67, 0xd1, 0x16, 0x07, 0xa7, 0x68, 0xca, 0x70, 0xcf, 0x59, 0x45, 0xce, 0x61, 0x41,
+	0xae, 0x3d, 0x8b, 0x7f, 0xce, 0xe0, 0xe2, 0xa3, 0x33, 0x83, 0x29, 0x5c, 0xcd, 0x2c, 0xa1, 0xd0,
+	0x42, 0xe3, 0x9a, 0x6c, 0x30, 0x8a, 0x76, 0xd7, 0x2d, 0x09, 0x3d, 0xb2, 0x24, 0xd7, 0x68, 0x19,
+	0x0f, 0x36, 0xd6, 0x97, 0xc9, 0x8e, 0xb0, 0xde, 0x49, 0xd3, 0x81, 0xd6, 0x33, 0x5c, 0xfb, 0x0d,
+	0x9d, 0xaf, 0x2d, 0xd9, 0x09, 0xd9, 0xf0, 0xf6, 0xaf, 0xe6, 0x5a, 0xcf, 0x4f, 0xeb, 0xed, 0xc5,
+	0x30, 0xec, 0xf2, 0xd0, 0xca, 0x3a, 0xb8, 0xf1, 0x4a, 0x6e, 0xc0, 0xde, 0x8e, 0x31, 0x0c, 0xbb,
+	0xac, 0xfe, 0xce, 0x14, 0x60, 0x4e, 0xd2, 0xce, 0xe8, 0xfe, 0xdf, 0xa2, 0x25, 0xe3, 0xc1, 0xfd,
+	0x49, 0xf0, 0x00, 0x97, 0x9f, 0x84, 0x56, 0x12, 0x42, 0xe9, 0x39, 0xe0, 0x2b, 0x8c, 0x76, 0xca,
+	0x61, 0x5b, 0xbc, 0xbd, 0x7c, 0x3f, 0x15, 0xa5, 0xac, 0x9a, 0x24, 0x4a, 0xb9, 0x52, 0x73, 0xe6,
+	0x42, 0xd3, 0x4c, 0x73, 0x93, 0x2d, 0x34, 0x4a, 0xce, 0xb6, 0x52, 0x6c, 0xa8, 0x9e, 0x54, 0x28,
+	0xe9, 0x4a, 0x95, 0xb5, 0x90, 0xad, 0x51, 0x2b, 0x93, 0x24, 0xe7, 0xdb, 0x57, 0x7b, 0xfc, 0x1d,
+	0x00, 0x36, 0x0a, 0xf8, 0x2d, 0xb5, 0x02, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -63,6 +67,18 @@ type FrontendClient interface {
 	// OUTPUT: Result message denoting success or failure (and an error if
 	// necessary)
 	CreatePlayer(ctx context.Context, in *Player, opts ...grpc.CallOption) (*Result, error)
+	// UpdatePlayer replaces the properties of a player who is already waiting
+	// for a match, for example to widen their acceptable latency or change
+	// their selected game mode.  Only the indices whose values changed are
+	// updated, atomically, and the player keeps their place in line (their
+	// original creation time).  Players who have already been removed from
+	// the indices, for example because they were matched, are not added back.
+	// INPUT: Player message with these fields populated:
+	//  - id
+	//  - properties
+	// OUTPUT: Result message denoting success or failure (and an error if
+	// necessary).  Fails with NOT_FOUND if the player doesn't exist.
+	UpdatePlayer(ctx context.Context, in *Player, opts ...grpc.CallOption) (*Result, error)
 	// DeletePlayer removes the player from state storage by doing the
 	// following:
 	//  1) Delete player from configured indices.  This effectively removes the
@@ -77,18 +93,70 @@ type FrontendClient interface {
 	// OUTPUT: Result message denoting success or failure (and an error if
 	// necessary)
 	DeletePlayer(ctx context.Context, in *Player, opts ...grpc.CallOption) (*Result, error)
+	// CreatePlayers does the same as CreatePlayer for every player in the
+	// roster, writing them to state storage in as few round trips as
+	// possible.  Use it to absorb bursts of players, such as after a login
+	// queue opens.
+	// INPUT: Roster message with the 'players' field populated, each with the
+	// same fields as CreatePlayer.
+	// OUTPUT: Results message with a Result for each player, in the same
+	// order as the roster.  The call itself only fails if the request could
+	// not be processed at all.
+	CreatePlayers(ctx context.Context, in *Roster, opts ...grpc.CallOption) (*Results, error)
+	// DeletePlayers does the same as DeletePlayer for every player in the
+	// roster.
+	// INPUT: Roster message with the 'players' field populated.  The only
+	// field used in each Player message is 'id'.
+	// OUTPUT: Results message with a Result for each player, in the same
+	// order as the roster.
+	DeletePlayers(ctx context.Context, in *Roster, opts ...grpc.CallOption) (*Results, error)
+	// CreateParty puts the party in state storage and indexes it as a single
+	// unit, so MMFs see the party in place of its members.  The party's
+	// properties are its input properties with these added:
+	//  - For every configured index, the aggregate of the members' values
+	//    (see 'parties.aggregate' in the matchmaker config).  This means
+	//    the same filters work for parties and single players.
+	//  - 'party.size': the number of members.
+	//  - 'party.avg.<index>' and 'party.max.<index>': the average and
+	//    maximum of the members' values for each configured index.
+	// The members must already have been created with CreatePlayer and
+	// can't be in another party; otherwise the call fails with NOT_FOUND or
+	// FAILED_PRECONDITION and nothing is changed.  Members are removed from
+	// the player indices so they aren't matched individually as well.
+	// Members keep calling GetUpdates with their own ID, and receive the
+	// assignment made to the party.
+	// INPUT: Party message with these fields populated:
+	//  - id
+	//  - members
+	//  - properties (optional)
+	// OUTPUT: Result message denoting success or failure (and an error if
+	// necessary)
+	CreateParty(ctx context.Context, in *Party, opts ...grpc.CallOption) (*Result, error)
+	// DeleteParty removes the party from matchmaking and state storage in
+	// the same way as DeletePlayer.  Members are not re-indexed; call
+	// CreatePlayer for any that should be matched individually.
+	// INPUT: Party message with the 'id' field populated.
+	// OUTPUT: Result message denoting success or failure (and an error if
+	// necessary)
+	DeleteParty(ctx context.Context, in *Party, opts ...grpc.CallOption) (*Result, error)
 	// GetUpdates streams matchmaking results from Open Match for the
 	// provided player ID.
 	// INPUT: Player message with the 'id' field populated.
 	// OUTPUT: a stream of player objects with one or more of the following
 	// fields populated, if an update to that field is seen in state storage:
 	//  - 'assignment': string that usually contains game server connection information.
-	//  - 'status': string to communicate current matchmaking status to the client.
+	//  - 'status': the player's place in the matchmaking lifecycle, the name
+	//    of a PlayerStatus value (see messages.proto).  Use it to show the
+	//    player what is happening, e.g. QUEUED: 'finding match', MATCHED:
+	//    'match found', ASSIGNED: 'connecting'.
 	//  - 'error': string to pass along error information to the client.
 	//
 	// During normal operation, the expectation is that the 'assignment' field
 	// will be updated by a Backend process calling the 'CreateAssignments' Backend API
-	// endpoint.  'Status' and 'Error' are free for developers to use as they see fit.
+	// endpoint.  'Error' is free for developers to use as they see fit.
+	// If the server stops watching before the player is assigned, the stream
+	// ends with DEADLINE_EXCEEDED and the client can call GetUpdates again;
+	// players who stay away are expired by the expiry sweeper.
 	// Even if you had multiple players enter a matchmaking request as a group, the
 	// Backend API 'CreateAssignments' call will write the results to state
 	// storage separately under each player's ID. OM expects you to make all game
@@ -103,6 +171,26 @@ type FrontendClient interface {
 	// NOTE: Just bear in mind that every update will send egress traffic from
 	//  Open Match to game clients! Frugality is recommended.
 	GetUpdates(ctx context.Context, in *Player, opts ...grpc.CallOption) (Frontend_GetUpdatesClient, error)
+	// Heartbeat tells Open Match the player's client is still waiting for a
+	// match.  Players who haven't sent a heartbeat for
+	// 'playerExpiry.idleTime' seconds are expired (see the expiry sweeper in
+	// the README).  With the default 'watch' liveness, an open GetUpdates
+	// stream also keeps the player alive, so only clients that don't hold a
+	// stream per player, such as gateways, need to call it.  With
+	// 'heartbeat' liveness, only heartbeats do.
+	// INPUT: Player message with the 'id' field populated.
+	// OUTPUT: Result message denoting success or failure (and an error if
+	// necessary).  Fails with NOT_FOUND if the player doesn't exist, and
+	// with FAILED_PRECONDITION if they're already ASSIGNED, EXPIRED or
+	// CANCELLED.
+	Heartbeat(ctx context.Context, in *Player, opts ...grpc.CallOption) (*Result, error)
+	// HeartbeatPlayers does the same as Heartbeat for every player in the
+	// roster, in a single round trip to state storage.
+	// INPUT: Roster message with the 'players' field populated.  The only
+	// field used in each Player message is 'id'.
+	// OUTPUT: Results message with a Result for each player, in the same
+	// order as the roster.
+	HeartbeatPlayers(ctx context.Context, in *Roster, opts ...grpc.CallOption) (*Results, error)
 }
 
 type frontendClient struct {
@@ -122,6 +210,15 @@ func (c *frontendClient) CreatePlayer(ctx context.Context, in *Player, opts ...g
 	return out, nil
 }
 
+func (c *frontendClient) UpdatePlayer(ctx context.Context, in *Player, opts ...grpc.CallOption) (*Result, error) {
+	out := new(Result)
+	err := c.cc.Invoke(ctx, "/api.Frontend/UpdatePlayer", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *frontendClient) DeletePlayer(ctx context.Context, in *Player, opts ...grpc.CallOption) (*Result, error) {
 	out := new(Result)
 	err := c.cc.Invoke(ctx, "/api.Frontend/DeletePlayer", in, out, opts...)
@@ -131,6 +228,42 @@ func (c *frontendClient) DeletePlayer(ctx context.Context, in *Player, opts ...g
 	return out, nil
 }
 
+func (c *frontendClient) CreatePlayers(ctx context.Context, in *Roster, opts ...grpc.CallOption) (*Results, error) {
+	out := new(Results)
+	err := c.cc.Invoke(ctx, "/api.Frontend/CreatePlayers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *frontendClient) DeletePlayers(ctx context.Context, in *Roster, opts ...grpc.CallOption) (*Results, error) {
+	out := new(Results)
+	err := c.cc.Invoke(ctx, "/api.Frontend/DeletePlayers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *frontendClient) CreateParty(ctx context.Context, in *Party, opts ...grpc.CallOption) (*Result, error) {
+	out := new(Result)
+	err := c.cc.Invoke(ctx, "/api.Frontend/CreateParty", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *frontendClient) DeleteParty(ctx context.Context, in *Party, opts ...grpc.CallOption) (*Result, error) {
+	out := new(Result)
+	err := c.cc.Invoke(ctx, "/api.Frontend/DeleteParty", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *frontendClient) GetUpdates(ctx context.Context, in *Player, opts ...grpc.CallOption) (Frontend_GetUpdatesClient, error) {
 	stream, err := c.cc.NewStream(ctx, &_Frontend_serviceDesc.Streams[0], "/api.Frontend/GetUpdates", opts...)
 	if err != nil {
@@ -163,6 +296,24 @@ func (x *frontendGetUpdatesClient) Recv() (*Player, error) {
 	return m, nil
 }
 
+func (c *frontendClient) Heartbeat(ctx context.Context, in *Player, opts ...grpc.CallOption) (*Result, error) {
+	out := new(Result)
+	err := c.cc.Invoke(ctx, "/api.Frontend/Heartbeat", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *frontendClient) HeartbeatPlayers(ctx context.Context, in *Roster, opts ...grpc.CallOption) (*Results, error) {
+	out := new(Results)
+	err := c.cc.Invoke(ctx, "/api.Frontend/HeartbeatPlayers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // FrontendServer is the server API for Frontend service.
 type FrontendServer interface {
 	// CreatePlayer will put the player  in state storage, and then look
@@ -175,6 +326,18 @@ type FrontendServer interface {
 	// OUTPUT: Result message denoting success or failure (and an error if
 	// necessary)
 	CreatePlayer(context.Context, *Player) (*Result, error)
+	// UpdatePlayer replaces the properties of a player who is already waiting
+	// for a match, for example to widen their acceptable latency or change
+	// their selected game mode.  Only the indices whose values changed are
+	// updated, atomically, and the player keeps their place in line (their
+	// original creation time).  Players who have already been removed from
+	// the indices, for example because they were matched, are not added back.
+	// INPUT: Player message with these fields populated:
+	//  - id
+	//  - properties
+	// OUTPUT: Result message denoting success or failure (and an error if
+	// necessary).  Fails with NOT_FOUND if the player doesn't exist.
+	UpdatePlayer(context.Context, *Player) (*Result, error)
 	// DeletePlayer removes the player from state storage by doing the
 	// following:
 	//  1) Delete player from configured indices.  This effectively removes the
@@ -189,18 +352,70 @@ type FrontendServer interface {
 	// OUTPUT: Result message denoting success or failure (and an error if
 	// necessary)
 	DeletePlayer(context.Context, *Player) (*Result, error)
+	// CreatePlayers does the same as CreatePlayer for every player in the
+	// roster, writing them to state storage in as few round trips as
+	// possible.  Use it to absorb bursts of players, such as after a login
+	// queue opens.
+	// INPUT: Roster message with the 'players' field populated, each with the
+	// same fields as CreatePlayer.
+	// OUTPUT: Results message with a Result for each player, in the same
+	// order as the roster.  The call itself only fails if the request could
+	// not be processed at all.
+	CreatePlayers(context.Context, *Roster) (*Results, error)
+	// DeletePlayers does the same as DeletePlayer for every player in the
+	// roster.
+	// INPUT: Roster message with the 'players' field populated.  The only
+	// field used in each Player message is 'id'.
+	// OUTPUT: Results message with a Result for each player, in the same
+	// order as the roster.
+	DeletePlayers(context.Context, *Roster) (*Results, error)
+	// CreateParty puts the party in state storage and indexes it as a single
+	// unit, so MMFs see the party in place of its members.  The party's
+	// properties are its input properties with these added:
+	//  - For every configured index, the aggregate of the members' values
+	//    (see 'parties.aggregate' in the matchmaker config).  This means
+	//    the same filters work for parties and single players.
+	//  - 'party.size': the number of members.
+	//  - 'party.avg.<index>' and 'party.max.<index>': the average and
+	//    maximum of the members' values for each configured index.
+	// The members must already have been created with CreatePlayer and
+	// can't be in another party; otherwise the call fails with NOT_FOUND or
+	// FAILED_PRECONDITION and nothing is changed.  Members are removed from
+	// the player indices so they aren't matched individually as well.
+	// Members keep calling GetUpdates with their own ID, and receive the
+	// assignment made to the party.
+	// INPUT: Party message with these fields populated:
+	//  - id
+	//  - members
+	//  - properties (optional)
+	// OUTPUT: Result message denoting success or failure (and an error if
+	// necessary)
+	CreateParty(context.Context, *Party) (*Result, error)
+	// DeleteParty removes the party from matchmaking and state storage in
+	// the same way as DeletePlayer.  Members are not re-indexed; call
+	// CreatePlayer for any that should be matched individually.
+	// INPUT: Party message with the 'id' field populated.
+	// OUTPUT: Result message denoting success or failure (and an error if
+	// necessary)
+	DeleteParty(context.Context, *Party) (*Result, error)
 	// GetUpdates streams matchmaking results from Open Match for the
 	// provided player ID.
 	// INPUT: Player message with the 'id' field populated.
 	// OUTPUT: a stream of player objects with one or more of the following
 	// fields populated, if an update to that field is seen in state storage:
 	//  - 'assignment': string that usually contains game server connection information.
-	//  - 'status': string to communicate current matchmaking status to the client.
+	//  - 'status': the player's place in the matchmaking lifecycle, the name
+	//    of a PlayerStatus value (see messages.proto).  Use it to show the
+	//    player what is happening, e.g. QUEUED: 'finding match', MATCHED:
+	//    'match found', ASSIGNED: 'connecting'.
 	//  - 'error': string to pass along error information to the client.
 	//
 	// During normal operation, the expectation is that the 'assignment' field
 	// will be updated by a Backend process calling the 'CreateAssignments' Backend API
-	// endpoint.  'Status' and 'Error' are free for developers to use as they see fit.
+	// endpoint.  'Error' is free for developers to use as they see fit.
+	// If the server stops watching before the player is assigned, the stream
+	// ends with DEADLINE_EXCEEDED and the client can call GetUpdates again;
+	// players who stay away are expired by the expiry sweeper.
 	// Even if you had multiple players enter a matchmaking request as a group, the
 	// Backend API 'CreateAssignments' call will write the results to state
 	// storage separately under each player's ID. OM expects you to make all game
@@ -215,6 +430,26 @@ type FrontendServer interface {
 	// NOTE: Just bear in mind that every update will send egress traffic from
 	//  Open Match to game clients! Frugality is recommended.
 	GetUpdates(*Player, Frontend_GetUpdatesServer) error
+	// Heartbeat tells Open Match the player's client is still waiting for a
+	// match.  Players who haven't sent a heartbeat for
+	// 'playerExpiry.idleTime' seconds are expired (see the expiry sweeper in
+	// the README).  With the default 'watch' liveness, an open GetUpdates
+	// stream also keeps the player alive, so only clients that don't hold a
+	// stream per player, such as gateways, need to call it.  With
+	// 'heartbeat' liveness, only heartbeats do.
+	// INPUT: Player message with the 'id' field populated.
+	// OUTPUT: Result message denoting success or failure (and an error if
+	// necessary).  Fails with NOT_FOUND if the player doesn't exist, and
+	// with FAILED_PRECONDITION if they're already ASSIGNED, EXPIRED or
+	// CANCELLED.
+	Heartbeat(context.Context, *Player) (*Result, error)
+	// HeartbeatPlayers does the same as Heartbeat for every player in the
+	// roster, in a single round trip to state storage.
+	// INPUT: Roster message with the 'players' field populated.  The only
+	// field used in each Player message is 'id'.
+	// OUTPUT: Results message with a Result for each player, in the same
+	// order as the roster.
+	HeartbeatPlayers(context.Context, *Roster) (*Results, error)
 }
 
 func RegisterFrontendServer(s *grpc.Server, srv FrontendServer) {
@@ -239,6 +474,24 @@ func _Frontend_CreatePlayer_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Frontend_UpdatePlayer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Player)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FrontendServer).UpdatePlayer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Frontend/UpdatePlayer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FrontendServer).UpdatePlayer(ctx, req.(*Player))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Frontend_DeletePlayer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(Player)
 	if err := dec(in); err != nil {
@@ -257,6 +510,78 @@ func _Frontend_DeletePlayer_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Frontend_CreatePlayers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Roster)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FrontendServer).CreatePlayers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Frontend/CreatePlayers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FrontendServer).CreatePlayers(ctx, req.(*Roster))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Frontend_DeletePlayers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Roster)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FrontendServer).DeletePlayers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Frontend/DeletePlayers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FrontendServer).DeletePlayers(ctx, req.(*Roster))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Frontend_CreateParty_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Party)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FrontendServer).CreateParty(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Frontend/CreateParty",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FrontendServer).CreateParty(ctx, req.(*Party))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Frontend_DeleteParty_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Party)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FrontendServer).DeleteParty(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Frontend/DeleteParty",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FrontendServer).DeleteParty(ctx, req.(*Party))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Frontend_GetUpdates_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(Player)
 	if err := stream.RecvMsg(m); err != nil {
@@ -278,6 +603,42 @@ func (x *frontendGetUpdatesServer) Send(m *Player) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Frontend_Heartbeat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Player)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FrontendServer).Heartbeat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Frontend/Heartbeat",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FrontendServer).Heartbeat(ctx, req.(*Player))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Frontend_HeartbeatPlayers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Roster)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FrontendServer).HeartbeatPlayers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Frontend/HeartbeatPlayers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FrontendServer).HeartbeatPlayers(ctx, req.(*Roster))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Frontend_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "api.Frontend",
 	HandlerType: (*FrontendServer)(nil),
@@ -286,10 +647,38 @@ var _Frontend_serviceDesc = grpc.ServiceDesc{
 			MethodName: "CreatePlayer",
 			Handler:    _Frontend_CreatePlayer_Handler,
 		},
+		{
+			MethodName: "UpdatePlayer",
+			Handler:    _Frontend_UpdatePlayer_Handler,
+		},
 		{
 			MethodName: "DeletePlayer",
 			Handler:    _Frontend_DeletePlayer_Handler,
 		},
+		{
+			MethodName: "CreatePlayers",
+			Handler:    _Frontend_CreatePlayers_Handler,
+		},
+		{
+			MethodName: "DeletePlayers",
+			Handler:    _Frontend_DeletePlayers_Handler,
+		},
+		{
+			MethodName: "CreateParty",
+			Handler:    _Frontend_CreateParty_Handler,
+		},
+		{
+			MethodName: "DeleteParty",
+			Handler:    _Frontend_DeleteParty_Handler,
+		},
+		{
+			MethodName: "Heartbeat",
+			Handler:    _Frontend_Heartbeat_Handler,
+		},
+		{
+			MethodName: "HeartbeatPlayers",
+			Handler:    _Frontend_HeartbeatPlayers_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
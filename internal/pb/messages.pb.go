@@ -20,6 +20,91 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+// Open Match's internal representation and wire protocol format for "Players".
+// In order to enter matchmaking using the Frontend API, your client code should generate
+// a consistent (same result for each client every time they launch) with an ID and
+// properties filled in (for more details about valid values for these fields,
+// see the documentation).
+// PlayerStatus is where a player is in the matchmaking lifecycle.  Open
+// Match writes the name of the current status to Player.status as the
+// player moves through the lifecycle, and the Frontend API streams each
+// change on GetUpdates:
+//
+//	QUEUED -> PROPOSED -> MATCHED -> ASSIGNED
+//
+// A player returns to QUEUED if the evaluator rejects the match they were
+// proposed for.  EXPIRED and CANCELLED end the lifecycle at any point.
+type PlayerStatus int32
+
+const (
+	PlayerStatus_UNSET     PlayerStatus = 0
+	PlayerStatus_QUEUED    PlayerStatus = 1
+	PlayerStatus_PROPOSED  PlayerStatus = 2
+	PlayerStatus_MATCHED   PlayerStatus = 3
+	PlayerStatus_ASSIGNED  PlayerStatus = 4
+	PlayerStatus_EXPIRED   PlayerStatus = 5
+	PlayerStatus_CANCELLED PlayerStatus = 6
+)
+
+var PlayerStatus_name = map[int32]string{
+	0: "UNSET",
+	1: "QUEUED",
+	2: "PROPOSED",
+	3: "MATCHED",
+	4: "ASSIGNED",
+	5: "EXPIRED",
+	6: "CANCELLED",
+}
+
+var PlayerStatus_value = map[string]int32{
+	"UNSET":     0,
+	"QUEUED":    1,
+	"PROPOSED":  2,
+	"MATCHED":   3,
+	"ASSIGNED":  4,
+	"EXPIRED":   5,
+	"CANCELLED": 6,
+}
+
+func (x PlayerStatus) String() string {
+	return proto.EnumName(PlayerStatus_name, int32(x))
+}
+
+func (PlayerStatus) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_ec5e45ff8e70c33d, []int{0}
+}
+
+type FilterExpression_Op int32
+
+const (
+	FilterExpression_FILTER FilterExpression_Op = 0
+	FilterExpression_AND    FilterExpression_Op = 1
+	FilterExpression_OR     FilterExpression_Op = 2
+	FilterExpression_NOT    FilterExpression_Op = 3
+)
+
+var FilterExpression_Op_name = map[int32]string{
+	0: "FILTER",
+	1: "AND",
+	2: "OR",
+	3: "NOT",
+}
+
+var FilterExpression_Op_value = map[string]int32{
+	"FILTER": 0,
+	"AND":    1,
+	"OR":     2,
+	"NOT":    3,
+}
+
+func (x FilterExpression_Op) String() string {
+	return proto.EnumName(FilterExpression_Op_name, int32(x))
+}
+
+func (FilterExpression_Op) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_ec5e45ff8e70c33d, []int{4, 0}
+}
+
 // Open Match's internal representation and wire protocol format for "MatchObjects".
 // In order to request a match using the Backend API, your backend code should generate
 // a new MatchObject with an ID and properties filled in (for more details about valid
@@ -165,6 +250,9 @@ type Filter struct {
 	Maxv                 int64    `protobuf:"varint,3,opt,name=maxv,proto3" json:"maxv,omitempty"`
 	Minv                 int64    `protobuf:"varint,4,opt,name=minv,proto3" json:"minv,omitempty"`
 	Stats                *Stats   `protobuf:"bytes,5,opt,name=stats,proto3" json:"stats,omitempty"`
+	Fmaxv                float64  `protobuf:"fixed64,6,opt,name=fmaxv,proto3" json:"fmaxv,omitempty"`
+	Fminv                float64  `protobuf:"fixed64,7,opt,name=fminv,proto3" json:"fminv,omitempty"`
+	Values               []string `protobuf:"bytes,8,rep,name=values,proto3" json:"values,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -230,6 +318,27 @@ func (m *Filter) GetStats() *Stats {
 	return nil
 }
 
+func (m *Filter) GetFmaxv() float64 {
+	if m != nil {
+		return m.Fmaxv
+	}
+	return 0
+}
+
+func (m *Filter) GetFminv() float64 {
+	if m != nil {
+		return m.Fminv
+	}
+	return 0
+}
+
+func (m *Filter) GetValues() []string {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
 // Holds statistics
 type Stats struct {
 	Count                int64    `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
@@ -284,21 +393,88 @@ func (m *Stats) GetElapsed() float64 {
 // PlayerPools contain a number of fields, but many gRPC calls that take a
 // PlayerPool as input only require a few of them to be filled in.  Check the
 // gRPC function in question for more details.
+// A FilterExpression combines filters with boolean logic.  It is a tree
+// whose leaves are filters:
+//   - FILTER: players matching 'filter'.
+//   - AND: players matching every child.
+//   - OR: players matching any child.
+//   - NOT: players not matching its one child.  NOT can only exclude players
+//     from a set that is already being selected, so it must be a child of an
+//     AND that has at least one other child that isn't a NOT, or at the top
+//     of a PlayerPool expression when the pool also has filters.
+type FilterExpression struct {
+	Op                   FilterExpression_Op `protobuf:"varint,1,opt,name=op,proto3,enum=messages.FilterExpression_Op" json:"op,omitempty"`
+	Filter               *Filter             `protobuf:"bytes,2,opt,name=filter,proto3" json:"filter,omitempty"`
+	Children             []*FilterExpression `protobuf:"bytes,3,rep,name=children,proto3" json:"children,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
+}
+
+func (m *FilterExpression) Reset()         { *m = FilterExpression{} }
+func (m *FilterExpression) String() string { return proto.CompactTextString(m) }
+func (*FilterExpression) ProtoMessage()    {}
+func (*FilterExpression) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ec5e45ff8e70c33d, []int{4}
+}
+
+func (m *FilterExpression) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FilterExpression.Unmarshal(m, b)
+}
+func (m *FilterExpression) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FilterExpression.Marshal(b, m, deterministic)
+}
+func (m *FilterExpression) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FilterExpression.Merge(m, src)
+}
+func (m *FilterExpression) XXX_Size() int {
+	return xxx_messageInfo_FilterExpression.Size(m)
+}
+func (m *FilterExpression) XXX_DiscardUnknown() {
+	xxx_messageInfo_FilterExpression.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FilterExpression proto.InternalMessageInfo
+
+func (m *FilterExpression) GetOp() FilterExpression_Op {
+	if m != nil {
+		return m.Op
+	}
+	return FilterExpression_FILTER
+}
+
+func (m *FilterExpression) GetFilter() *Filter {
+	if m != nil {
+		return m.Filter
+	}
+	return nil
+}
+
+func (m *FilterExpression) GetChildren() []*FilterExpression {
+	if m != nil {
+		return m.Children
+	}
+	return nil
+}
+
 type PlayerPool struct {
-	Name                 string    `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Filters              []*Filter `protobuf:"bytes,2,rep,name=filters,proto3" json:"filters,omitempty"`
-	Roster               *Roster   `protobuf:"bytes,3,opt,name=roster,proto3" json:"roster,omitempty"`
-	Stats                *Stats    `protobuf:"bytes,4,opt,name=stats,proto3" json:"stats,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
-	XXX_unrecognized     []byte    `json:"-"`
-	XXX_sizecache        int32     `json:"-"`
+	Name                 string            `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Filters              []*Filter         `protobuf:"bytes,2,rep,name=filters,proto3" json:"filters,omitempty"`
+	Roster               *Roster           `protobuf:"bytes,3,opt,name=roster,proto3" json:"roster,omitempty"`
+	Stats                *Stats            `protobuf:"bytes,4,opt,name=stats,proto3" json:"stats,omitempty"`
+	Expression           *FilterExpression `protobuf:"bytes,5,opt,name=expression,proto3" json:"expression,omitempty"`
+	IncludeProperties    bool              `protobuf:"varint,6,opt,name=include_properties,json=includeProperties,proto3" json:"include_properties,omitempty"`
+	PropertyPaths        []string          `protobuf:"bytes,7,rep,name=property_paths,json=propertyPaths,proto3" json:"property_paths,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
 }
 
 func (m *PlayerPool) Reset()         { *m = PlayerPool{} }
 func (m *PlayerPool) String() string { return proto.CompactTextString(m) }
 func (*PlayerPool) ProtoMessage()    {}
 func (*PlayerPool) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ec5e45ff8e70c33d, []int{4}
+	return fileDescriptor_ec5e45ff8e70c33d, []int{5}
 }
 
 func (m *PlayerPool) XXX_Unmarshal(b []byte) error {
@@ -347,11 +523,91 @@ func (m *PlayerPool) GetStats() *Stats {
 	return nil
 }
 
-// Open Match's internal representation and wire protocol format for "Players".
-// In order to enter matchmaking using the Frontend API, your client code should generate
-// a consistent (same result for each client every time they launch) with an ID and
-// properties filled in (for more details about valid values for these fields,
-// see the documentation).
+func (m *PlayerPool) GetExpression() *FilterExpression {
+	if m != nil {
+		return m.Expression
+	}
+	return nil
+}
+
+func (m *PlayerPool) GetIncludeProperties() bool {
+	if m != nil {
+		return m.IncludeProperties
+	}
+	return false
+}
+
+func (m *PlayerPool) GetPropertyPaths() []string {
+	if m != nil {
+		return m.PropertyPaths
+	}
+	return nil
+}
+
+// A change to a player pool, sent by WatchPlayerPool.
+type PlayerPoolDelta struct {
+	Name                 string    `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Added                []*Player `protobuf:"bytes,2,rep,name=added,proto3" json:"added,omitempty"`
+	Removed              []string  `protobuf:"bytes,3,rep,name=removed,proto3" json:"removed,omitempty"`
+	Stats                *Stats    `protobuf:"bytes,4,opt,name=stats,proto3" json:"stats,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *PlayerPoolDelta) Reset()         { *m = PlayerPoolDelta{} }
+func (m *PlayerPoolDelta) String() string { return proto.CompactTextString(m) }
+func (*PlayerPoolDelta) ProtoMessage()    {}
+func (*PlayerPoolDelta) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ec5e45ff8e70c33d, []int{6}
+}
+
+func (m *PlayerPoolDelta) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PlayerPoolDelta.Unmarshal(m, b)
+}
+func (m *PlayerPoolDelta) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PlayerPoolDelta.Marshal(b, m, deterministic)
+}
+func (m *PlayerPoolDelta) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PlayerPoolDelta.Merge(m, src)
+}
+func (m *PlayerPoolDelta) XXX_Size() int {
+	return xxx_messageInfo_PlayerPoolDelta.Size(m)
+}
+func (m *PlayerPoolDelta) XXX_DiscardUnknown() {
+	xxx_messageInfo_PlayerPoolDelta.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PlayerPoolDelta proto.InternalMessageInfo
+
+func (m *PlayerPoolDelta) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *PlayerPoolDelta) GetAdded() []*Player {
+	if m != nil {
+		return m.Added
+	}
+	return nil
+}
+
+func (m *PlayerPoolDelta) GetRemoved() []string {
+	if m != nil {
+		return m.Removed
+	}
+	return nil
+}
+
+func (m *PlayerPoolDelta) GetStats() *Stats {
+	if m != nil {
+		return m.Stats
+	}
+	return nil
+}
+
 // Players contain a number of fields, but the gRPC calls that take a
 // Player as input only require a few of them to be filled in.  Check the
 // gRPC function in question for more details.
@@ -363,6 +619,7 @@ type Player struct {
 	Assignment           string              `protobuf:"bytes,5,opt,name=assignment,proto3" json:"assignment,omitempty"`
 	Status               string              `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`
 	Error                string              `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`
+	Members              []string            `protobuf:"bytes,8,rep,name=members,proto3" json:"members,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
 	XXX_unrecognized     []byte              `json:"-"`
 	XXX_sizecache        int32               `json:"-"`
@@ -372,7 +629,7 @@ func (m *Player) Reset()         { *m = Player{} }
 func (m *Player) String() string { return proto.CompactTextString(m) }
 func (*Player) ProtoMessage()    {}
 func (*Player) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ec5e45ff8e70c33d, []int{5}
+	return fileDescriptor_ec5e45ff8e70c33d, []int{7}
 }
 
 func (m *Player) XXX_Unmarshal(b []byte) error {
@@ -442,9 +699,17 @@ func (m *Player) GetError() string {
 	return ""
 }
 
+func (m *Player) GetMembers() []string {
+	if m != nil {
+		return m.Members
+	}
+	return nil
+}
+
 type Player_Attribute struct {
 	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
 	Value                int64    `protobuf:"varint,2,opt,name=value,proto3" json:"value,omitempty"`
+	FloatValue           float64  `protobuf:"fixed64,3,opt,name=float_value,json=floatValue,proto3" json:"float_value,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -454,7 +719,7 @@ func (m *Player_Attribute) Reset()         { *m = Player_Attribute{} }
 func (m *Player_Attribute) String() string { return proto.CompactTextString(m) }
 func (*Player_Attribute) ProtoMessage()    {}
 func (*Player_Attribute) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ec5e45ff8e70c33d, []int{5, 0}
+	return fileDescriptor_ec5e45ff8e70c33d, []int{7, 0}
 }
 
 func (m *Player_Attribute) XXX_Unmarshal(b []byte) error {
@@ -489,6 +754,187 @@ func (m *Player_Attribute) GetValue() int64 {
 	return 0
 }
 
+func (m *Player_Attribute) GetFloatValue() float64 {
+	if m != nil {
+		return m.FloatValue
+	}
+	return 0
+}
+
+// A Party is a group of players who want to be matched together.  It is
+// indexed as a single unit: its properties are the party's own properties,
+// plus aggregates of its members' indexed attributes (see CreateParty in
+// frontend.proto).  MMFs receive a party from GetPlayerPool as a Player with
+// the party's ID, and assignments made to that ID are copied to every
+// member.
+type Party struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Properties           string   `protobuf:"bytes,2,opt,name=properties,proto3" json:"properties,omitempty"`
+	Members              []string `protobuf:"bytes,3,rep,name=members,proto3" json:"members,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Party) Reset()         { *m = Party{} }
+func (m *Party) String() string { return proto.CompactTextString(m) }
+func (*Party) ProtoMessage()    {}
+func (*Party) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ec5e45ff8e70c33d, []int{8}
+}
+
+func (m *Party) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Party.Unmarshal(m, b)
+}
+func (m *Party) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Party.Marshal(b, m, deterministic)
+}
+func (m *Party) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Party.Merge(m, src)
+}
+func (m *Party) XXX_Size() int {
+	return xxx_messageInfo_Party.Size(m)
+}
+func (m *Party) XXX_DiscardUnknown() {
+	xxx_messageInfo_Party.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Party proto.InternalMessageInfo
+
+func (m *Party) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Party) GetProperties() string {
+	if m != nil {
+		return m.Properties
+	}
+	return ""
+}
+
+func (m *Party) GetMembers() []string {
+	if m != nil {
+		return m.Members
+	}
+	return nil
+}
+
+// A PlayerIndex is an entry in the player index registry (see admin.proto).
+// Entries are kept after an index is retired, so it isn't added back from
+// an API's playerIndices config when that API restarts.
+type PlayerIndex struct {
+	Attribute            string   `protobuf:"bytes,1,opt,name=attribute,proto3" json:"attribute,omitempty"`
+	Type                 string   `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Added                int64    `protobuf:"varint,3,opt,name=added,proto3" json:"added,omitempty"`
+	Retired              int64    `protobuf:"varint,4,opt,name=retired,proto3" json:"retired,omitempty"`
+	Collected            int64    `protobuf:"varint,5,opt,name=collected,proto3" json:"collected,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PlayerIndex) Reset()         { *m = PlayerIndex{} }
+func (m *PlayerIndex) String() string { return proto.CompactTextString(m) }
+func (*PlayerIndex) ProtoMessage()    {}
+func (*PlayerIndex) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ec5e45ff8e70c33d, []int{9}
+}
+
+func (m *PlayerIndex) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PlayerIndex.Unmarshal(m, b)
+}
+func (m *PlayerIndex) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PlayerIndex.Marshal(b, m, deterministic)
+}
+func (m *PlayerIndex) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PlayerIndex.Merge(m, src)
+}
+func (m *PlayerIndex) XXX_Size() int {
+	return xxx_messageInfo_PlayerIndex.Size(m)
+}
+func (m *PlayerIndex) XXX_DiscardUnknown() {
+	xxx_messageInfo_PlayerIndex.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PlayerIndex proto.InternalMessageInfo
+
+func (m *PlayerIndex) GetAttribute() string {
+	if m != nil {
+		return m.Attribute
+	}
+	return ""
+}
+
+func (m *PlayerIndex) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *PlayerIndex) GetAdded() int64 {
+	if m != nil {
+		return m.Added
+	}
+	return 0
+}
+
+func (m *PlayerIndex) GetRetired() int64 {
+	if m != nil {
+		return m.Retired
+	}
+	return 0
+}
+
+func (m *PlayerIndex) GetCollected() int64 {
+	if m != nil {
+		return m.Collected
+	}
+	return 0
+}
+
+type IndexList struct {
+	Indices              []*PlayerIndex `protobuf:"bytes,1,rep,name=indices,proto3" json:"indices,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *IndexList) Reset()         { *m = IndexList{} }
+func (m *IndexList) String() string { return proto.CompactTextString(m) }
+func (*IndexList) ProtoMessage()    {}
+func (*IndexList) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ec5e45ff8e70c33d, []int{10}
+}
+
+func (m *IndexList) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_IndexList.Unmarshal(m, b)
+}
+func (m *IndexList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_IndexList.Marshal(b, m, deterministic)
+}
+func (m *IndexList) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_IndexList.Merge(m, src)
+}
+func (m *IndexList) XXX_Size() int {
+	return xxx_messageInfo_IndexList.Size(m)
+}
+func (m *IndexList) XXX_DiscardUnknown() {
+	xxx_messageInfo_IndexList.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_IndexList proto.InternalMessageInfo
+
+func (m *IndexList) GetIndices() []*PlayerIndex {
+	if m != nil {
+		return m.Indices
+	}
+	return nil
+}
+
 // Simple message to return success/failure and error status.
 type Result struct {
 	Success              bool     `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
@@ -502,7 +948,7 @@ func (m *Result) Reset()         { *m = Result{} }
 func (m *Result) String() string { return proto.CompactTextString(m) }
 func (*Result) ProtoMessage()    {}
 func (*Result) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ec5e45ff8e70c33d, []int{6}
+	return fileDescriptor_ec5e45ff8e70c33d, []int{11}
 }
 
 func (m *Result) XXX_Unmarshal(b []byte) error {
@@ -537,6 +983,46 @@ func (m *Result) GetError() string {
 	return ""
 }
 
+// Results of a batch call, one for each input in the same order.
+type Results struct {
+	Results              []*Result `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *Results) Reset()         { *m = Results{} }
+func (m *Results) String() string { return proto.CompactTextString(m) }
+func (*Results) ProtoMessage()    {}
+func (*Results) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ec5e45ff8e70c33d, []int{12}
+}
+
+func (m *Results) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Results.Unmarshal(m, b)
+}
+func (m *Results) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Results.Marshal(b, m, deterministic)
+}
+func (m *Results) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Results.Merge(m, src)
+}
+func (m *Results) XXX_Size() int {
+	return xxx_messageInfo_Results.Size(m)
+}
+func (m *Results) XXX_DiscardUnknown() {
+	xxx_messageInfo_Results.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Results proto.InternalMessageInfo
+
+func (m *Results) GetResults() []*Result {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
 // IlInput is an empty message reserved for future use.
 type IlInput struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -548,7 +1034,7 @@ func (m *IlInput) Reset()         { *m = IlInput{} }
 func (m *IlInput) String() string { return proto.CompactTextString(m) }
 func (*IlInput) ProtoMessage()    {}
 func (*IlInput) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ec5e45ff8e70c33d, []int{7}
+	return fileDescriptor_ec5e45ff8e70c33d, []int{13}
 }
 
 func (m *IlInput) XXX_Unmarshal(b []byte) error {
@@ -581,7 +1067,7 @@ func (m *Assignments) Reset()         { *m = Assignments{} }
 func (m *Assignments) String() string { return proto.CompactTextString(m) }
 func (*Assignments) ProtoMessage()    {}
 func (*Assignments) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ec5e45ff8e70c33d, []int{8}
+	return fileDescriptor_ec5e45ff8e70c33d, []int{14}
 }
 
 func (m *Assignments) XXX_Unmarshal(b []byte) error {
@@ -634,7 +1120,7 @@ func (m *Request) Reset()         { *m = Request{} }
 func (m *Request) String() string { return proto.CompactTextString(m) }
 func (*Request) ProtoMessage()    {}
 func (*Request) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ec5e45ff8e70c33d, []int{9}
+	return fileDescriptor_ec5e45ff8e70c33d, []int{15}
 }
 
 func (m *Request) XXX_Unmarshal(b []byte) error {
@@ -704,7 +1190,7 @@ func (m *Arguments) Reset()         { *m = Arguments{} }
 func (m *Arguments) String() string { return proto.CompactTextString(m) }
 func (*Arguments) ProtoMessage()    {}
 func (*Arguments) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ec5e45ff8e70c33d, []int{10}
+	return fileDescriptor_ec5e45ff8e70c33d, []int{16}
 }
 
 func (m *Arguments) XXX_Unmarshal(b []byte) error {
@@ -740,14 +1226,22 @@ func (m *Arguments) GetMatchobject() *MatchObject {
 }
 
 func init() {
+	proto.RegisterEnum("messages.PlayerStatus", PlayerStatus_name, PlayerStatus_value)
+	proto.RegisterEnum("messages.FilterExpression_Op", FilterExpression_Op_name, FilterExpression_Op_value)
 	proto.RegisterType((*MatchObject)(nil), "messages.MatchObject")
 	proto.RegisterType((*Roster)(nil), "messages.Roster")
 	proto.RegisterType((*Filter)(nil), "messages.Filter")
 	proto.RegisterType((*Stats)(nil), "messages.Stats")
+	proto.RegisterType((*FilterExpression)(nil), "messages.FilterExpression")
 	proto.RegisterType((*PlayerPool)(nil), "messages.PlayerPool")
+	proto.RegisterType((*PlayerPoolDelta)(nil), "messages.PlayerPoolDelta")
 	proto.RegisterType((*Player)(nil), "messages.Player")
 	proto.RegisterType((*Player_Attribute)(nil), "messages.Player.Attribute")
+	proto.RegisterType((*Party)(nil), "messages.Party")
+	proto.RegisterType((*PlayerIndex)(nil), "messages.PlayerIndex")
+	proto.RegisterType((*IndexList)(nil), "messages.IndexList")
 	proto.RegisterType((*Result)(nil), "messages.Result")
+	proto.RegisterType((*Results)(nil), "messages.Results")
 	proto.RegisterType((*IlInput)(nil), "messages.IlInput")
 	proto.RegisterType((*Assignments)(nil), "messages.Assignments")
 	proto.RegisterType((*Request)(nil), "messages.Request")
@@ -757,46 +1251,75 @@ func init() {
 func init() { proto.RegisterFile("api/protobuf-spec/messages.proto", fileDescriptor_ec5e45ff8e70c33d) }
 
 var fileDescriptor_ec5e45ff8e70c33d = []byte{
-	// 655 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x54, 0xcd, 0x6e, 0xd4, 0x3c,
-	0x14, 0x55, 0xe6, 0x27, 0x69, 0x6e, 0xa4, 0xef, 0x03, 0xab, 0xa0, 0x50, 0xf1, 0x53, 0x45, 0x42,
-	0x1a, 0x15, 0x75, 0x46, 0x1a, 0x54, 0x15, 0xb1, 0x1b, 0x90, 0x80, 0x59, 0x20, 0x2a, 0xb3, 0x82,
-	0x0d, 0xf2, 0x24, 0x9e, 0x69, 0x90, 0x13, 0xa7, 0xb6, 0x33, 0x82, 0x47, 0x60, 0xc1, 0x23, 0xb0,
-	0xe0, 0x59, 0x78, 0x31, 0xe4, 0xeb, 0x64, 0x12, 0x4a, 0x0b, 0x62, 0xe7, 0x7b, 0xee, 0xb5, 0x7d,
-	0xce, 0x3d, 0xd7, 0x86, 0x43, 0x56, 0xe5, 0xb3, 0x4a, 0x49, 0x23, 0x57, 0xf5, 0xfa, 0x58, 0x57,
-	0x3c, 0x9d, 0x15, 0x5c, 0x6b, 0xb6, 0xe1, 0x7a, 0x8a, 0x30, 0xd9, 0x6b, 0xe3, 0xe4, 0x87, 0x07,
-	0xd1, 0x6b, 0x66, 0xd2, 0xf3, 0x37, 0xab, 0x8f, 0x3c, 0x35, 0xe4, 0x3f, 0x18, 0xe4, 0x59, 0xec,
-	0x1d, 0x7a, 0x93, 0x90, 0x0e, 0xf2, 0x8c, 0xdc, 0x07, 0xa8, 0x94, 0xac, 0xb8, 0x32, 0x39, 0xd7,
-	0xf1, 0x00, 0xf1, 0x1e, 0x42, 0xf6, 0x61, 0xcc, 0x95, 0x92, 0x2a, 0x1e, 0x62, 0xca, 0x05, 0xe4,
-	0x08, 0x02, 0x25, 0xb5, 0xe1, 0x4a, 0xc7, 0xa3, 0xc3, 0xe1, 0x24, 0x9a, 0xdf, 0x98, 0xee, 0x18,
-	0x50, 0x4c, 0xd0, 0xb6, 0x80, 0x1c, 0xc1, 0xb8, 0x92, 0x52, 0xe8, 0x78, 0x8c, 0x95, 0xfb, 0x5d,
-	0xe5, 0x99, 0x60, 0x9f, 0xb9, 0x3a, 0x93, 0x52, 0x50, 0x57, 0x42, 0x6e, 0x83, 0xaf, 0x0d, 0x33,
-	0xb5, 0x8e, 0x7d, 0xbc, 0xae, 0x89, 0x92, 0x57, 0xe0, 0xbb, 0x63, 0x09, 0x81, 0x51, 0xc9, 0x0a,
-	0xde, 0x28, 0xc0, 0xb5, 0x65, 0x53, 0xe1, 0x51, 0x56, 0xc0, 0x25, 0x36, 0xee, 0x0e, 0xda, 0x16,
-	0x24, 0x5f, 0x3c, 0xf0, 0x5f, 0xe4, 0xe2, 0xba, 0xa3, 0xee, 0x42, 0xc8, 0x8c, 0x51, 0xf9, 0xaa,
-	0x36, 0xbc, 0xe9, 0x46, 0x07, 0xd8, 0x1d, 0x05, 0xfb, 0xb4, 0xc5, 0x5e, 0x0c, 0x29, 0xae, 0x11,
-	0xcb, 0xcb, 0x6d, 0x3c, 0x6a, 0xb0, 0xbc, 0xdc, 0x92, 0x87, 0x30, 0xb6, 0xc4, 0xad, 0x64, 0x6f,
-	0x12, 0xcd, 0xff, 0xef, 0xe8, 0xbc, 0xb5, 0x30, 0x75, 0xd9, 0xe4, 0x14, 0xc6, 0x18, 0xdb, 0x26,
-	0xa7, 0xb2, 0x2e, 0x0d, 0x52, 0x19, 0x52, 0x17, 0x90, 0x18, 0x02, 0x2e, 0x58, 0xa5, 0x79, 0x86,
-	0x4c, 0x3c, 0xda, 0x86, 0xc9, 0x37, 0x0f, 0xa0, 0x6b, 0xde, 0x75, 0x3d, 0x59, 0xa3, 0xcc, 0x2b,
-	0x7a, 0xe2, 0xf4, 0xd3, 0xb6, 0x80, 0x4c, 0xc0, 0x77, 0x66, 0xa1, 0xb0, 0xab, 0xcc, 0x6c, 0xf2,
-	0x9d, 0xb0, 0xd1, 0x1f, 0x85, 0x7d, 0x1d, 0x80, 0xef, 0xf8, 0xfd, 0xf3, 0xbc, 0x11, 0x18, 0xd9,
-	0x51, 0x68, 0xc6, 0x0d, 0xd7, 0xe4, 0x29, 0xc0, 0xce, 0x83, 0x76, 0xe0, 0x0e, 0x2e, 0x5b, 0x3c,
-	0x5d, 0xb4, 0x25, 0xb4, 0x57, 0x6d, 0xef, 0x63, 0x5a, 0xe7, 0x9b, 0xb2, 0xe0, 0xa5, 0x41, 0x3f,
-	0x42, 0xda, 0x43, 0xae, 0x9b, 0xb8, 0x6e, 0xee, 0x83, 0xde, 0xdc, 0x1f, 0x9c, 0x40, 0xb8, 0xe8,
-	0x4f, 0xc3, 0x6f, 0x6d, 0xdf, 0x87, 0xf1, 0x96, 0x89, 0xda, 0xcd, 0xce, 0x90, 0xba, 0x20, 0x79,
-	0x02, 0x3e, 0xe5, 0xba, 0x16, 0xe8, 0xa9, 0xae, 0xd3, 0x94, 0x6b, 0x8d, 0xdb, 0xf6, 0x68, 0x1b,
-	0x76, 0x17, 0x0e, 0x7a, 0x17, 0x26, 0x21, 0x04, 0x4b, 0xb1, 0x2c, 0xab, 0xda, 0x24, 0xef, 0x20,
-	0x5a, 0xec, 0x78, 0xeb, 0xfe, 0x13, 0xf4, 0xfe, 0xf6, 0x04, 0x7f, 0x6d, 0x02, 0x5c, 0x6e, 0x42,
-	0xf2, 0xdd, 0x83, 0x80, 0xf2, 0x8b, 0x9a, 0x6b, 0x43, 0xee, 0xa1, 0x41, 0xeb, 0x5c, 0xf0, 0x0f,
-	0x3b, 0xe3, 0xc2, 0x06, 0x59, 0x66, 0xe4, 0x01, 0x44, 0xd6, 0x2d, 0xa9, 0x99, 0xb0, 0xf9, 0x9e,
-	0x81, 0x16, 0x5a, 0x66, 0x76, 0xbf, 0x72, 0x47, 0xd9, 0xbc, 0xb3, 0x31, 0x6c, 0x90, 0x65, 0x46,
-	0xee, 0xc0, 0x1e, 0x2a, 0xb3, 0xc9, 0x11, 0x26, 0x03, 0x8c, 0x97, 0x99, 0x7d, 0x7b, 0x26, 0x2f,
-	0xb8, 0x36, 0xac, 0xa8, 0x1a, 0xa7, 0x3a, 0x20, 0xb9, 0x80, 0x70, 0xa1, 0x36, 0xb5, 0x13, 0xff,
-	0x08, 0x82, 0xe6, 0x48, 0x64, 0x18, 0xcd, 0x6f, 0xf6, 0xc4, 0xbb, 0x04, 0x6d, 0x2b, 0xc8, 0x29,
-	0x44, 0x85, 0xfd, 0x01, 0x25, 0xfe, 0x80, 0x48, 0x39, 0x9a, 0xdf, 0xea, 0x36, 0xf4, 0xbe, 0x47,
-	0xda, 0xaf, 0x7c, 0x76, 0xfa, 0xfe, 0x64, 0x93, 0x9b, 0xf3, 0x7a, 0x35, 0x4d, 0x65, 0x31, 0x7b,
-	0x29, 0xe5, 0x46, 0xf0, 0xe7, 0x42, 0xd6, 0xd9, 0x99, 0x60, 0x66, 0x2d, 0x55, 0x31, 0x93, 0x15,
-	0x2f, 0x8f, 0x71, 0xcb, 0x2c, 0x2f, 0x0d, 0x57, 0x25, 0x13, 0xb3, 0x6a, 0xb5, 0xf2, 0xf1, 0x17,
-	0x7e, 0xfc, 0x33, 0x00, 0x00, 0xff, 0xff, 0x25, 0x28, 0xbd, 0xef, 0xa9, 0x05, 0x00, 0x00,
+	// 1109 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x56, 0xdd, 0x6e, 0x9c, 0x46,
+	0x14, 0x0e, 0xb0, 0x0b, 0xcb, 0xd9, 0xc4, 0x21, 0x23, 0x37, 0xa2, 0x56, 0xd3, 0x5a, 0x48, 0xa9,
+	0x2c, 0x57, 0xf6, 0x4a, 0xae, 0x52, 0x57, 0x55, 0x6f, 0xb6, 0x5e, 0x92, 0xac, 0xe4, 0x78, 0x37,
+	0xb3, 0x76, 0xd4, 0xf6, 0x66, 0xc5, 0xc2, 0xac, 0x4d, 0x04, 0x0c, 0x61, 0x06, 0xcb, 0x7e, 0x88,
+	0xf6, 0x1d, 0xfa, 0x04, 0x7d, 0x88, 0xde, 0xf4, 0xa6, 0xea, 0x2b, 0x55, 0x33, 0x03, 0x0b, 0xde,
+	0xd8, 0x69, 0x72, 0xc7, 0xf9, 0xce, 0xc7, 0xe1, 0xfc, 0x7c, 0x73, 0x06, 0xd8, 0x0e, 0xf2, 0x78,
+	0x90, 0x17, 0x94, 0xd3, 0x45, 0xb9, 0xdc, 0x63, 0x39, 0x09, 0x07, 0x29, 0x61, 0x2c, 0x38, 0x27,
+	0x6c, 0x5f, 0xc2, 0xa8, 0x57, 0xdb, 0xde, 0x5f, 0x1a, 0xf4, 0x5f, 0x05, 0x3c, 0xbc, 0x98, 0x2c,
+	0xde, 0x92, 0x90, 0xa3, 0x0d, 0xd0, 0xe3, 0xc8, 0xd5, 0xb6, 0xb5, 0x1d, 0x1b, 0xeb, 0x71, 0x84,
+	0xbe, 0x04, 0xc8, 0x0b, 0x9a, 0x93, 0x82, 0xc7, 0x84, 0xb9, 0xba, 0xc4, 0x5b, 0x08, 0xda, 0x84,
+	0x2e, 0x29, 0x0a, 0x5a, 0xb8, 0x86, 0x74, 0x29, 0x03, 0xed, 0x82, 0x55, 0x50, 0xc6, 0x49, 0xc1,
+	0xdc, 0xce, 0xb6, 0xb1, 0xd3, 0x3f, 0x70, 0xf6, 0x57, 0x19, 0x60, 0xe9, 0xc0, 0x35, 0x01, 0xed,
+	0x42, 0x37, 0xa7, 0x34, 0x61, 0x6e, 0x57, 0x32, 0x37, 0x1b, 0xe6, 0x34, 0x09, 0xae, 0x49, 0x31,
+	0xa5, 0x34, 0xc1, 0x8a, 0x82, 0x1e, 0x83, 0xc9, 0x78, 0xc0, 0x4b, 0xe6, 0x9a, 0xf2, 0x73, 0x95,
+	0xe5, 0xbd, 0x04, 0x53, 0x85, 0x45, 0x08, 0x3a, 0x59, 0x90, 0x92, 0xaa, 0x02, 0xf9, 0x2c, 0xb2,
+	0xc9, 0x65, 0x28, 0x51, 0xc0, 0x5a, 0x36, 0xea, 0x1b, 0xb8, 0x26, 0x78, 0xff, 0x68, 0x60, 0x3e,
+	0x8f, 0x93, 0xbb, 0x42, 0x7d, 0x01, 0x76, 0xc0, 0x79, 0x11, 0x2f, 0x4a, 0x4e, 0xaa, 0x6e, 0x34,
+	0x80, 0x78, 0x23, 0x0d, 0xae, 0x2e, 0x65, 0x2f, 0x0c, 0x2c, 0x9f, 0x25, 0x16, 0x67, 0x97, 0x6e,
+	0xa7, 0xc2, 0xe2, 0xec, 0x12, 0x3d, 0x85, 0xae, 0x48, 0x5c, 0x94, 0xac, 0xed, 0xf4, 0x0f, 0x1e,
+	0x36, 0xe9, 0xcc, 0x04, 0x8c, 0x95, 0x57, 0xf4, 0x76, 0x29, 0xe3, 0x89, 0x62, 0x35, 0xac, 0x0c,
+	0x85, 0x8a, 0x88, 0x56, 0x8d, 0x8a, 0x90, 0x8f, 0xc1, 0xbc, 0x0c, 0x92, 0x92, 0x30, 0xb7, 0xb7,
+	0x6d, 0x88, 0xce, 0x28, 0xcb, 0x3b, 0x84, 0xee, 0xac, 0x0e, 0x16, 0xd2, 0x32, 0xe3, 0xb2, 0x1c,
+	0x03, 0x2b, 0x03, 0xb9, 0x60, 0x91, 0x24, 0xc8, 0x19, 0x89, 0x64, 0x35, 0x1a, 0xae, 0x4d, 0xef,
+	0x5f, 0x0d, 0x1c, 0xd5, 0x08, 0xff, 0x2a, 0x2f, 0x08, 0x63, 0x31, 0xcd, 0xd0, 0x1e, 0xe8, 0x34,
+	0x97, 0x11, 0x36, 0x0e, 0x9e, 0x34, 0x59, 0xaf, 0xf3, 0xf6, 0x27, 0x39, 0xd6, 0x69, 0x8e, 0x76,
+	0xc0, 0x5c, 0x4a, 0x97, 0x0c, 0x7e, 0xa3, 0xef, 0xea, 0x15, 0x5c, 0xf9, 0xd1, 0x77, 0xd0, 0x0b,
+	0x2f, 0xe2, 0x24, 0x2a, 0x48, 0xe6, 0x1a, 0x72, 0x46, 0x5b, 0x77, 0x87, 0xc7, 0x2b, 0xae, 0xb7,
+	0x0b, 0xfa, 0x24, 0x47, 0x00, 0xe6, 0xf3, 0xf1, 0xf1, 0xa9, 0x8f, 0x9d, 0x7b, 0xc8, 0x02, 0x63,
+	0x78, 0x32, 0x72, 0x34, 0x64, 0x82, 0x3e, 0xc1, 0x8e, 0x2e, 0x80, 0x93, 0xc9, 0xa9, 0x63, 0x78,
+	0x7f, 0xea, 0x00, 0x8d, 0xa4, 0xee, 0x52, 0x8a, 0x4a, 0xe8, 0x16, 0xa5, 0x54, 0x19, 0xd7, 0x04,
+	0x51, 0x9c, 0x92, 0xb0, 0x1c, 0xf7, 0x6d, 0x12, 0xaf, 0xfc, 0xcd, 0xb8, 0x3b, 0x1f, 0x1c, 0xf7,
+	0x0f, 0x00, 0x64, 0x55, 0x63, 0x25, 0x8d, 0x0f, 0x75, 0xa1, 0xc5, 0x46, 0x7b, 0x80, 0xe2, 0x2c,
+	0x4c, 0xca, 0x88, 0xcc, 0x5b, 0xc7, 0x55, 0xe8, 0xa6, 0x87, 0x1f, 0x55, 0x9e, 0xe9, 0xca, 0x81,
+	0x9e, 0xc2, 0x46, 0x45, 0xbb, 0x9e, 0xe7, 0x01, 0xbf, 0x60, 0xae, 0x25, 0x55, 0xf3, 0xa0, 0x46,
+	0xa7, 0x02, 0xf4, 0x7e, 0xd7, 0xe0, 0x61, 0xd3, 0xb1, 0x11, 0x49, 0x78, 0x70, 0x6b, 0xdb, 0xbe,
+	0x86, 0x6e, 0x10, 0x45, 0x24, 0x7a, 0xbf, 0x69, 0xd5, 0xf1, 0x52, 0x6e, 0xa1, 0xb6, 0x82, 0xa4,
+	0xf4, 0x92, 0x44, 0x72, 0xc8, 0x36, 0xae, 0xcd, 0x8f, 0x6c, 0x91, 0xf7, 0xb7, 0x0e, 0xa6, 0x0a,
+	0xf9, 0xc9, 0x8b, 0x0a, 0x41, 0x47, 0xec, 0x90, 0x6a, 0x4f, 0xc9, 0x67, 0xd1, 0xf1, 0xd5, 0xe1,
+	0xad, 0x37, 0xd5, 0xd6, 0x7a, 0xf2, 0xfb, 0xc3, 0x9a, 0x82, 0x5b, 0x6c, 0xf1, 0xbd, 0x80, 0xb1,
+	0xf8, 0x3c, 0x4b, 0x49, 0xc6, 0xe5, 0xb4, 0x6c, 0xdc, 0x42, 0xee, 0x5a, 0x55, 0xcd, 0xc2, 0xb4,
+	0xda, 0x0b, 0xd3, 0x05, 0x2b, 0x25, 0xe9, 0x82, 0x14, 0xf5, 0xf9, 0xad, 0xcd, 0xad, 0x37, 0x60,
+	0x0f, 0xdb, 0x0b, 0xe6, 0xbd, 0xe6, 0x6f, 0x42, 0x57, 0x9e, 0x75, 0x59, 0xb3, 0x81, 0x95, 0x81,
+	0xbe, 0x82, 0xfe, 0x32, 0xa1, 0x01, 0x9f, 0x2b, 0x9f, 0x21, 0x0f, 0x37, 0x48, 0xe8, 0x8d, 0x40,
+	0xbc, 0xd7, 0xd0, 0x9d, 0x06, 0x05, 0xbf, 0xfe, 0xe4, 0x46, 0xb6, 0x52, 0x35, 0x6e, 0xa4, 0xea,
+	0xfd, 0xa6, 0x41, 0x5f, 0xf5, 0x6c, 0x9c, 0x45, 0xe4, 0xea, 0xe6, 0xb2, 0xd4, 0x6e, 0x59, 0x96,
+	0xfc, 0x3a, 0xaf, 0xb7, 0xa8, 0x7c, 0x16, 0xb5, 0x28, 0x21, 0xa9, 0x0d, 0xda, 0x96, 0x0d, 0x8f,
+	0x0b, 0x12, 0x55, 0x5b, 0xb4, 0x36, 0xc5, 0x17, 0x42, 0x9a, 0x24, 0x24, 0xe4, 0x24, 0x92, 0x33,
+	0x30, 0x70, 0x03, 0x78, 0x3f, 0x82, 0x2d, 0x13, 0x39, 0x8e, 0x19, 0x47, 0x03, 0xb0, 0xe2, 0x2c,
+	0x8a, 0x43, 0xc2, 0x5c, 0x4d, 0x0e, 0xfa, 0xb3, 0xf5, 0x41, 0x4b, 0x2e, 0xae, 0x59, 0xde, 0xf7,
+	0x60, 0x62, 0xc2, 0xca, 0x44, 0x2e, 0x49, 0x56, 0x86, 0x21, 0x61, 0x4c, 0x56, 0xd1, 0xc3, 0xb5,
+	0xd9, 0x0c, 0x53, 0x6f, 0x0d, 0xd3, 0x7b, 0x06, 0x96, 0x7a, 0x93, 0xc9, 0x8b, 0x50, 0x3d, 0xba,
+	0xda, 0xfa, 0xd9, 0x50, 0x1c, 0x5c, 0x13, 0x3c, 0x1b, 0xac, 0x71, 0x32, 0xce, 0xf2, 0x92, 0x7b,
+	0xbf, 0x40, 0x7f, 0xb8, 0x92, 0x12, 0x6b, 0x5f, 0xa7, 0xda, 0xff, 0x5d, 0xa7, 0x37, 0x75, 0x09,
+	0xeb, 0xba, 0xf4, 0xfe, 0xd0, 0x44, 0x76, 0xef, 0x4a, 0xc2, 0x38, 0x7a, 0x22, 0x47, 0xbd, 0x8c,
+	0x13, 0x32, 0x5f, 0x49, 0xc0, 0xae, 0x90, 0x71, 0x24, 0x34, 0x24, 0xe6, 0x4e, 0x59, 0x90, 0x08,
+	0x7f, 0x4b, 0x0a, 0x02, 0x1a, 0x47, 0xe2, 0xfd, 0x42, 0x85, 0x9a, 0xc7, 0x6a, 0x66, 0x36, 0xb6,
+	0x2b, 0x64, 0x1c, 0xa1, 0xcf, 0xa1, 0x27, 0x1b, 0x32, 0x8f, 0xd5, 0xe0, 0x6c, 0x6c, 0x49, 0x7b,
+	0x2c, 0x07, 0xc7, 0xe3, 0x94, 0x30, 0x1e, 0xa4, 0x79, 0x75, 0x78, 0x1a, 0xc0, 0x7b, 0x07, 0xf6,
+	0xb0, 0x38, 0x2f, 0x55, 0xf1, 0xdf, 0x80, 0x55, 0x85, 0x94, 0x19, 0xf6, 0x0f, 0x1e, 0xb5, 0x5b,
+	0x28, 0x1d, 0xb8, 0x66, 0xa0, 0x43, 0xe8, 0xa7, 0xe2, 0x6f, 0x86, 0xca, 0xbf, 0x99, 0xea, 0xda,
+	0x69, 0x4d, 0xba, 0xf5, 0xab, 0x83, 0xdb, 0xcc, 0xdd, 0xb7, 0x70, 0x5f, 0xa9, 0x60, 0xa6, 0x8e,
+	0xa9, 0x0d, 0xdd, 0xb3, 0x93, 0x99, 0x7f, 0xea, 0xdc, 0x13, 0xb7, 0xcb, 0xeb, 0x33, 0xff, 0xcc,
+	0x17, 0x97, 0xca, 0x7d, 0xe8, 0x4d, 0xf1, 0x64, 0x3a, 0x99, 0xf9, 0x23, 0x47, 0x47, 0x7d, 0xb0,
+	0x5e, 0x0d, 0x4f, 0x8f, 0x5e, 0xfa, 0x23, 0xc7, 0x10, 0xae, 0xe1, 0x6c, 0x36, 0x7e, 0x71, 0xe2,
+	0x8f, 0x9c, 0x8e, 0x70, 0xf9, 0x3f, 0x4f, 0xc7, 0xd8, 0x1f, 0x39, 0x5d, 0xf4, 0x00, 0xec, 0xa3,
+	0xe1, 0xc9, 0x91, 0x7f, 0x7c, 0xec, 0x8f, 0x1c, 0xf3, 0xa7, 0xc3, 0x5f, 0x9f, 0x9d, 0xc7, 0xfc,
+	0xa2, 0x5c, 0xec, 0x87, 0x34, 0x1d, 0xbc, 0xa0, 0xf4, 0x3c, 0x21, 0x47, 0x09, 0x2d, 0xa3, 0x69,
+	0x12, 0xf0, 0x25, 0x2d, 0xd2, 0x01, 0xcd, 0x49, 0xb6, 0x27, 0xd3, 0x1b, 0xc4, 0x19, 0x27, 0x45,
+	0x16, 0x24, 0x83, 0x7c, 0xb1, 0x30, 0xe5, 0xdf, 0xdb, 0xb7, 0xff, 0x0d, 0x00, 0xb2, 0x3e, 0x8f,
+	0x6c, 0xe1, 0x09, 0x00, 0x00,
 }
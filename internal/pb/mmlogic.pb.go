@@ -25,24 +25,25 @@ const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 func init() { proto.RegisterFile("api/protobuf-spec/mmlogic.proto", fileDescriptor_5b986081864e12b4) }
 
 var fileDescriptor_5b986081864e12b4 = []byte{
-	// 263 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x91, 0x41, 0x4b, 0xf4, 0x30,
-	0x10, 0x40, 0xf7, 0xfb, 0x04, 0x85, 0x80, 0xa2, 0x61, 0xbd, 0xf4, 0xa2, 0xec, 0x7d, 0x1b, 0x51,
-	0xc4, 0x83, 0x8a, 0xe8, 0x1e, 0x4a, 0x61, 0x17, 0x8b, 0x47, 0x6f, 0x69, 0x9d, 0x76, 0x23, 0x93,
-	0x4e, 0x48, 0x26, 0x07, 0x7f, 0x9e, 0xff, 0x4c, 0xd2, 0x05, 0x8b, 0x50, 0x2f, 0x5e, 0x5f, 0xde,
-	0x9b, 0x09, 0x89, 0x38, 0xd3, 0xce, 0x28, 0xe7, 0x89, 0xa9, 0x8e, 0xed, 0x32, 0x38, 0x68, 0x94,
-	0xb5, 0x48, 0x9d, 0x69, 0xf2, 0x81, 0xca, 0x3d, 0xed, 0x4c, 0x76, 0x3e, 0x61, 0x41, 0x08, 0xba,
-	0x83, 0xb0, 0xd3, 0x2e, 0x3f, 0xff, 0x8b, 0x83, 0x8d, 0x5d, 0xa7, 0x50, 0xde, 0x09, 0x51, 0x00,
-	0x57, 0x9e, 0x5a, 0x83, 0x20, 0x4f, 0xf3, 0x6f, 0x75, 0xa3, 0xb9, 0xd9, 0x3e, 0xd7, 0xef, 0xd0,
-	0x70, 0x36, 0x8d, 0x17, 0x33, 0x79, 0x2b, 0x8e, 0x56, 0x1e, 0x34, 0x43, 0xe5, 0xc9, 0x51, 0xd0,
-	0xf8, 0xdb, 0x84, 0xe3, 0x11, 0xbf, 0x40, 0x88, 0x98, 0xe2, 0x07, 0x71, 0x98, 0x56, 0xa3, 0xfe,
-	0x00, 0x5f, 0x11, 0xa1, 0x9c, 0x8f, 0xd2, 0x48, 0xb3, 0x49, 0xba, 0x98, 0x5d, 0xfc, 0x93, 0xf7,
-	0x62, 0x5e, 0x00, 0x3f, 0x22, 0x96, 0x5d, 0x4f, 0x1e, 0xde, 0x76, 0xc7, 0x41, 0x9e, 0x8c, 0x45,
-	0x89, 0x65, 0xef, 0xe2, 0xcf, 0xfd, 0x14, 0x18, 0xfc, 0x70, 0x79, 0xb9, 0x36, 0x81, 0xff, 0x14,
-	0x3f, 0xdd, 0xbc, 0x5e, 0x77, 0x86, 0xb7, 0xb1, 0xce, 0x1b, 0xb2, 0xaa, 0x20, 0xea, 0x10, 0x56,
-	0x48, 0x31, 0xcd, 0xe1, 0x96, 0xbc, 0x55, 0xe4, 0xa0, 0x5f, 0xda, 0xf4, 0x06, 0xca, 0xf4, 0x0c,
-	0xbe, 0xd7, 0xa8, 0x5c, 0x5d, 0xef, 0x0f, 0x7f, 0x70, 0xf5, 0x15, 0x00, 0x00, 0xff, 0xff, 0xf5,
-	0xe5, 0x29, 0xa6, 0xcd, 0x01, 0x00, 0x00,
+	// 288 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x92, 0xc1, 0x4a, 0x33, 0x31,
+	0x14, 0x85, 0xfb, 0x53, 0xf8, 0x85, 0x80, 0x56, 0x43, 0x5d, 0xd8, 0x8d, 0xd2, 0x7d, 0x1b, 0x51,
+	0xc4, 0x85, 0x15, 0xd1, 0x8a, 0x65, 0xa0, 0xc5, 0xa1, 0x1b, 0xc1, 0x5d, 0x66, 0xbc, 0x33, 0x8d,
+	0xdc, 0xcc, 0x0d, 0xc9, 0x9d, 0x85, 0x4f, 0xe8, 0x6b, 0x49, 0xa6, 0xe0, 0x20, 0x8c, 0x08, 0xdd,
+	0x7e, 0x9c, 0xef, 0x24, 0x27, 0x44, 0x9c, 0x6a, 0x67, 0x94, 0xf3, 0xc4, 0x94, 0xd5, 0xc5, 0x24,
+	0x38, 0xc8, 0x95, 0xb5, 0x48, 0xa5, 0xc9, 0xa7, 0x0d, 0x95, 0x7d, 0xed, 0xcc, 0xe8, 0xac, 0x23,
+	0x05, 0x21, 0xe8, 0x12, 0xc2, 0x36, 0x76, 0xf1, 0xd9, 0x17, 0x7b, 0x2b, 0xbb, 0x8c, 0xa2, 0x9c,
+	0x09, 0xb1, 0x00, 0x4e, 0x3d, 0x15, 0x06, 0x41, 0x1e, 0x4f, 0xbf, 0xa3, 0x2b, 0xcd, 0xf9, 0xe6,
+	0x39, 0x7b, 0x87, 0x9c, 0x47, 0xdd, 0x78, 0xdc, 0x93, 0x37, 0xe2, 0x60, 0xee, 0x41, 0x33, 0xa4,
+	0x9e, 0x1c, 0x05, 0x8d, 0xbf, 0x35, 0x1c, 0xb6, 0x78, 0x0d, 0xa1, 0xc6, 0x28, 0xcf, 0xc4, 0x60,
+	0x0d, 0x08, 0x3a, 0xec, 0x64, 0xdf, 0x89, 0xfd, 0x78, 0x71, 0xd4, 0x1f, 0xe0, 0x53, 0x22, 0x94,
+	0xc3, 0x36, 0xd4, 0xd2, 0x51, 0x27, 0x1d, 0xf7, 0xce, 0xff, 0xc9, 0x27, 0x31, 0x78, 0x89, 0x67,
+	0xfc, 0x59, 0x71, 0xd2, 0x45, 0x1f, 0x01, 0x59, 0x37, 0x3d, 0xb7, 0x62, 0xb8, 0x00, 0xbe, 0x47,
+	0x4c, 0xca, 0x8a, 0x3c, 0xbc, 0x6d, 0x33, 0x41, 0x1e, 0xb5, 0x5a, 0x82, 0x49, 0xe5, 0xea, 0x9f,
+	0x3b, 0x28, 0x30, 0xf8, 0xe6, 0x09, 0xe5, 0xd2, 0x04, 0xde, 0x49, 0x7e, 0xb8, 0x7e, 0xbd, 0x2a,
+	0x0d, 0x6f, 0xea, 0x6c, 0x9a, 0x93, 0x55, 0x0b, 0xa2, 0x12, 0x61, 0x8e, 0x54, 0xc7, 0x1e, 0x2e,
+	0xc8, 0x5b, 0x45, 0x0e, 0xaa, 0x89, 0x8d, 0x3b, 0x95, 0xa9, 0x18, 0x7c, 0xa5, 0x51, 0xb9, 0x2c,
+	0xfb, 0xdf, 0xfc, 0x84, 0xcb, 0xaf, 0x01, 0x00, 0xda, 0x03, 0xbb, 0x74, 0x53, 0x02, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -93,15 +94,47 @@ type MmLogicClient interface {
 	//        will populate the pools with stats about how many players the filters
 	//        matched and how long the filters took to run, which will be sent out
 	//        the backend api along with your match results.
+	// The results are written, queued for the evaluator and counted as one
+	// atomic operation.  Calls repeating the same results under the same id
+	// succeed without writing them again, so a failed call can safely be
+	// retried; calls with different results under an id already written fail
+	// with the ALREADY_EXISTS code, and change nothing.  The id can be used
+	// again once the results are deleted, as the evaluator does with the
+	// proposals it processes and ReleaseProposal does.
+	// If 'proposalLeases.strict' is set in the config, proposals with players
+	// already on the proposed ignorelist for another proposal or match, and
+	// added within the ignorelist's duration, fail with the ABORTED code, and
+	// a message listing those players, and nothing is written.
 	// OUTPUT: a Result message with a boolean success value and an error string
 	// if an error was encountered
 	CreateProposal(ctx context.Context, in *MatchObject, opts ...grpc.CallOption) (*Result, error)
+	// ReleaseProposal withdraws a proposal made with CreateProposal, for
+	// example when the MMF fails after making it.  The proposal is deleted, so
+	// the evaluator skips it, and its players are removed from the proposed
+	// ignorelist, so they are back in every player pool straight away.
+	// Players a later proposal has added to the ignorelist again stay there.
+	// The evaluator does the same for the proposals it rejects, and the Go MMF
+	// harness calls it when writing a proposal fails.
+	// INPUT: a MatchObject message with the proposal's id.  IDs that aren't
+	// proposal IDs ('proposal.<timestamp>.<match object>.<profile>') fail
+	// with INVALID_ARGUMENT.
+	// OUTPUT: a Result message with a boolean success value and an error string
+	// if an error was encountered
+	ReleaseProposal(ctx context.Context, in *MatchObject, opts ...grpc.CallOption) (*Result, error)
 	// Player listing and filtering functions
 	//
 	// RetrievePlayerPool gets the list of players that match every Filter in the
 	// PlayerPool, .excluding players in any configured ignore lists.  It
-	// combines the results, and returns the resulting player pool.
+	// combines the results, and returns the resulting player pool.  Pools with
+	// a filter matching more than 500,000 players fail with the
+	// RESOURCE_EXHAUSTED code.
 	GetPlayerPool(ctx context.Context, in *PlayerPool, opts ...grpc.CallOption) (MmLogic_GetPlayerPoolClient, error)
+	// WatchPlayerPool streams changes to a player pool until the stream is
+	// closed.  The first PlayerPoolDelta has every player in the pool; each one
+	// after that has the players who joined and left the pool since the last.
+	// The pool is recomputed every 'playerPoolCache.watchInterval'
+	// milliseconds, and a delta is only sent when it changed.
+	WatchPlayerPool(ctx context.Context, in *PlayerPool, opts ...grpc.CallOption) (MmLogic_WatchPlayerPoolClient, error)
 	// Ignore List functions
 	//
 	// IlInput is an empty message reserved for future use.
@@ -137,6 +170,15 @@ func (c *mmLogicClient) CreateProposal(ctx context.Context, in *MatchObject, opt
 	return out, nil
 }
 
+func (c *mmLogicClient) ReleaseProposal(ctx context.Context, in *MatchObject, opts ...grpc.CallOption) (*Result, error) {
+	out := new(Result)
+	err := c.cc.Invoke(ctx, "/api.MmLogic/ReleaseProposal", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *mmLogicClient) GetPlayerPool(ctx context.Context, in *PlayerPool, opts ...grpc.CallOption) (MmLogic_GetPlayerPoolClient, error) {
 	stream, err := c.cc.NewStream(ctx, &_MmLogic_serviceDesc.Streams[0], "/api.MmLogic/GetPlayerPool", opts...)
 	if err != nil {
@@ -169,6 +211,38 @@ func (x *mmLogicGetPlayerPoolClient) Recv() (*PlayerPool, error) {
 	return m, nil
 }
 
+func (c *mmLogicClient) WatchPlayerPool(ctx context.Context, in *PlayerPool, opts ...grpc.CallOption) (MmLogic_WatchPlayerPoolClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_MmLogic_serviceDesc.Streams[1], "/api.MmLogic/WatchPlayerPool", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &mmLogicWatchPlayerPoolClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type MmLogic_WatchPlayerPoolClient interface {
+	Recv() (*PlayerPoolDelta, error)
+	grpc.ClientStream
+}
+
+type mmLogicWatchPlayerPoolClient struct {
+	grpc.ClientStream
+}
+
+func (x *mmLogicWatchPlayerPoolClient) Recv() (*PlayerPoolDelta, error) {
+	m := new(PlayerPoolDelta)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *mmLogicClient) GetAllIgnoredPlayers(ctx context.Context, in *IlInput, opts ...grpc.CallOption) (*Roster, error) {
 	out := new(Roster)
 	err := c.cc.Invoke(ctx, "/api.MmLogic/GetAllIgnoredPlayers", in, out, opts...)
@@ -225,15 +299,47 @@ type MmLogicServer interface {
 	//        will populate the pools with stats about how many players the filters
 	//        matched and how long the filters took to run, which will be sent out
 	//        the backend api along with your match results.
+	// The results are written, queued for the evaluator and counted as one
+	// atomic operation.  Calls repeating the same results under the same id
+	// succeed without writing them again, so a failed call can safely be
+	// retried; calls with different results under an id already written fail
+	// with the ALREADY_EXISTS code, and change nothing.  The id can be used
+	// again once the results are deleted, as the evaluator does with the
+	// proposals it processes and ReleaseProposal does.
+	// If 'proposalLeases.strict' is set in the config, proposals with players
+	// already on the proposed ignorelist for another proposal or match, and
+	// added within the ignorelist's duration, fail with the ABORTED code, and
+	// a message listing those players, and nothing is written.
 	// OUTPUT: a Result message with a boolean success value and an error string
 	// if an error was encountered
 	CreateProposal(context.Context, *MatchObject) (*Result, error)
+	// ReleaseProposal withdraws a proposal made with CreateProposal, for
+	// example when the MMF fails after making it.  The proposal is deleted, so
+	// the evaluator skips it, and its players are removed from the proposed
+	// ignorelist, so they are back in every player pool straight away.
+	// Players a later proposal has added to the ignorelist again stay there.
+	// The evaluator does the same for the proposals it rejects, and the Go MMF
+	// harness calls it when writing a proposal fails.
+	// INPUT: a MatchObject message with the proposal's id.  IDs that aren't
+	// proposal IDs ('proposal.<timestamp>.<match object>.<profile>') fail
+	// with INVALID_ARGUMENT.
+	// OUTPUT: a Result message with a boolean success value and an error string
+	// if an error was encountered
+	ReleaseProposal(context.Context, *MatchObject) (*Result, error)
 	// Player listing and filtering functions
 	//
 	// RetrievePlayerPool gets the list of players that match every Filter in the
 	// PlayerPool, .excluding players in any configured ignore lists.  It
-	// combines the results, and returns the resulting player pool.
+	// combines the results, and returns the resulting player pool.  Pools with
+	// a filter matching more than 500,000 players fail with the
+	// RESOURCE_EXHAUSTED code.
 	GetPlayerPool(*PlayerPool, MmLogic_GetPlayerPoolServer) error
+	// WatchPlayerPool streams changes to a player pool until the stream is
+	// closed.  The first PlayerPoolDelta has every player in the pool; each one
+	// after that has the players who joined and left the pool since the last.
+	// The pool is recomputed every 'playerPoolCache.watchInterval'
+	// milliseconds, and a delta is only sent when it changed.
+	WatchPlayerPool(*PlayerPool, MmLogic_WatchPlayerPoolServer) error
 	// Ignore List functions
 	//
 	// IlInput is an empty message reserved for future use.
@@ -283,6 +389,24 @@ func _MmLogic_CreateProposal_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MmLogic_ReleaseProposal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MatchObject)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MmLogicServer).ReleaseProposal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.MmLogic/ReleaseProposal",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MmLogicServer).ReleaseProposal(ctx, req.(*MatchObject))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _MmLogic_GetPlayerPool_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(PlayerPool)
 	if err := stream.RecvMsg(m); err != nil {
@@ -304,6 +428,27 @@ func (x *mmLogicGetPlayerPoolServer) Send(m *PlayerPool) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _MmLogic_WatchPlayerPool_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(PlayerPool)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MmLogicServer).WatchPlayerPool(m, &mmLogicWatchPlayerPoolServer{stream})
+}
+
+type MmLogic_WatchPlayerPoolServer interface {
+	Send(*PlayerPoolDelta) error
+	grpc.ServerStream
+}
+
+type mmLogicWatchPlayerPoolServer struct {
+	grpc.ServerStream
+}
+
+func (x *mmLogicWatchPlayerPoolServer) Send(m *PlayerPoolDelta) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _MmLogic_GetAllIgnoredPlayers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(IlInput)
 	if err := dec(in); err != nil {
@@ -352,6 +497,10 @@ var _MmLogic_serviceDesc = grpc.ServiceDesc{
 			MethodName: "CreateProposal",
 			Handler:    _MmLogic_CreateProposal_Handler,
 		},
+		{
+			MethodName: "ReleaseProposal",
+			Handler:    _MmLogic_ReleaseProposal_Handler,
+		},
 		{
 			MethodName: "GetAllIgnoredPlayers",
 			Handler:    _MmLogic_GetAllIgnoredPlayers_Handler,
@@ -367,6 +516,11 @@ var _MmLogic_serviceDesc = grpc.ServiceDesc{
 			Handler:       _MmLogic_GetPlayerPool_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "WatchPlayerPool",
+			Handler:       _MmLogic_WatchPlayerPool_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "api/protobuf-spec/mmlogic.proto",
 }
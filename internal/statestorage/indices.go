@@ -0,0 +1,312 @@
+/*
+Copyright 2019 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statestorage
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	"github.com/spf13/viper"
+	"github.com/tidwall/gjson"
+)
+
+// IndexType is the kind of value a player index holds.
+type IndexType string
+
+// Index types.  A player index is configured as its attribute, optionally
+// followed by a colon and one of these types; the default is int.
+//   - int and float indices hold a number, and are filtered by range.
+//   - string indices hold a single string, and are filtered by equality
+//     with any of a set of values.
+//   - tags indices hold a JSON array of strings, and are filtered by
+//     containing any of a set of values.
+const (
+	IntIndex    IndexType = "int"
+	FloatIndex  IndexType = "float"
+	StringIndex IndexType = "string"
+	TagsIndex   IndexType = "tags"
+)
+
+// Index is a player index: the attribute it reads from the player's
+// properties and the type of value it holds.
+type Index struct {
+	Attribute string
+	Type      IndexType
+}
+
+// ParseIndex parses a player index from the config, e.g. 'mmr.rating',
+// 'latency.us-east:float' or 'mode:string'.
+func ParseIndex(s string) (Index, error) {
+	i := strings.LastIndex(s, ":")
+	if i < 0 {
+		return Index{Attribute: s, Type: IntIndex}, nil
+	}
+	index := Index{Attribute: s[:i], Type: IndexType(s[i+1:])}
+	switch index.Type {
+	case IntIndex, FloatIndex, StringIndex, TagsIndex:
+		return index, nil
+	}
+	return index, fmt.Errorf("player index '%v' has unknown type '%v'", index.Attribute, index.Type)
+}
+
+// String formats the index the way it is written in the config.
+func (i Index) String() string {
+	if i.Type == IntIndex || i.Type == "" {
+		return i.Attribute
+	}
+	return i.Attribute + ":" + string(i.Type)
+}
+
+// ByValue is true for string and tags indices, which keep one sorted set of
+// players per value instead of one sorted set ordered by value.
+func (i Index) ByValue() bool {
+	return i.Type == StringIndex || i.Type == TagsIndex
+}
+
+// Key returns the name of the sorted set holding players indexed under the
+// value.  Numeric indices have a single sorted set named after the
+// attribute, and the value is ignored.
+func (i Index) Key(value string) string {
+	if !i.ByValue() {
+		return i.Attribute
+	}
+	return i.Attribute + "=" + value
+}
+
+// Values returns the strings the player's properties are indexed under for a
+// string or tags index.  Tags are read from a JSON array; a single string is
+// treated as one tag.
+func (i Index) Values(properties string) []string {
+	v := gjson.Get(properties, i.Attribute)
+	if !v.Exists() {
+		return nil
+	}
+	if i.Type == TagsIndex && v.IsArray() {
+		values := make([]string, 0)
+		for _, tag := range v.Array() {
+			values = append(values, tag.String())
+		}
+		return values
+	}
+	if v.IsArray() || v.IsObject() {
+		return nil
+	}
+	return []string{v.String()}
+}
+
+// MetaIndices are the Open Match internal metadata indices every player is
+// added to.  'created' is used to calculate how long a player has been
+// waiting for a match, 'accessed' is used to determine when a player needs to
+// be expired out of state storage.
+var MetaIndices = []string{
+	"OM_METADATA.created",
+	"OM_METADATA.accessed",
+}
+
+// metaIndices are the MetaIndices as Index values.
+func metaIndices() []Index {
+	indices := make([]Index, len(MetaIndices))
+	for i, attribute := range MetaIndices {
+		indices[i] = Index{Attribute: attribute, Type: IntIndex}
+	}
+	return indices
+}
+
+// Indices returns the user-defined player indices: the index registry's once
+// it has been loaded for the config (see WatchIndices), otherwise the
+// playerIndices config.  See the playerindices package for guidelines on
+// choosing indices.
+func Indices(cfg *viper.Viper) ([]Index, error) {
+	if r, ok := registries.Load(cfg); ok {
+		return append([]Index(nil), r.(*registry).current...), nil
+	}
+	if !cfg.IsSet("playerIndices") {
+		return nil, errors.New("Failure to get list of indices")
+	}
+	return parseIndices(cfg.GetStringSlice("playerIndices"))
+}
+
+// IndicesWithMeta returns the user-defined player indices followed by the
+// metadata indices.
+func IndicesWithMeta(cfg *viper.Viper) ([]Index, error) {
+	indices, err := Indices(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return append(indices, metaIndices()...), nil
+}
+
+// PreviousIndices returns indices that have been removed from the config or
+// retired from the index registry but may still contain players, so they can
+// be cleaned up when deleting players.  Entries that don't parse are skipped.
+func PreviousIndices(cfg *viper.Viper) []Index {
+	indices := make([]Index, 0)
+	if r, ok := registries.Load(cfg); ok {
+		indices = append(indices, r.(*registry).retired...)
+	}
+	for _, s := range cfg.GetStringSlice("previousPlayerIndices") {
+		if index, err := ParseIndex(s); err == nil {
+			indices = append(indices, index)
+		}
+	}
+	return indices
+}
+
+// FindIndex returns the configured index for the attribute.  Attributes that
+// aren't configured, like the metadata indices, are treated as int indices.
+func FindIndex(cfg *viper.Viper, attribute string) Index {
+	indices, _ := Indices(cfg)
+	for _, index := range indices {
+		if index.Attribute == attribute {
+			return index
+		}
+	}
+	return Index{Attribute: attribute, Type: IntIndex}
+}
+
+func parseIndices(entries []string) ([]Index, error) {
+	indices := make([]Index, 0, len(entries))
+	for _, s := range entries {
+		index, err := ParseIndex(s)
+		if err != nil {
+			return nil, err
+		}
+		indices = append(indices, index)
+	}
+	return indices, nil
+}
+
+// IndexValues returns the sorted sets the player should be in for each of
+// the given indices, with the player's score in each.  Metadata indices are
+// set to the current epoch timestamp.  User-defined indices are read from the
+// player's JSON properties using dot notation; attributes missing from the
+// properties are left out of the result.  Players in a string or tags index
+// are added to the sorted set for each of their values, with the current
+// epoch timestamp as their score.
+func IndexValues(player *pb.Player, indices []Index, now time.Time) map[string]float64 {
+	values := make(map[string]float64, len(indices))
+	for _, index := range indices {
+		// Metadata indices are always the current epoch timestamp.
+		if strings.HasPrefix(index.Attribute, "OM_METADATA") {
+			values[index.Attribute] = float64(now.Unix())
+			continue
+		}
+
+		if index.ByValue() {
+			for _, value := range index.Values(player.Properties) {
+				values[index.Key(value)] = float64(now.Unix())
+			}
+			continue
+		}
+
+		// NOTE: This gjson call has issues with JSON keys containing meta characters (dot, slash, etc).
+		//   End result is that you shouldn't use meta characters in your JSON property keys!
+		v := gjson.Get(player.Properties, index.Attribute)
+
+		// If this attribute wasn't provided in the JSON, continue to the
+		// next attribute to index.
+		if !v.Exists() {
+			continue
+		}
+		if index.Type == FloatIndex {
+			values[index.Attribute] = v.Float()
+		} else {
+			values[index.Attribute] = float64(v.Int())
+		}
+	}
+	return values
+}
+
+// UserIndexKeys returns the sorted sets of the user-defined indices among
+// the index values, leaving out the metadata indices.
+func UserIndexKeys(values map[string]float64) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if !strings.HasPrefix(key, "OM_METADATA") {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// IndexChanges compares a player's old and new properties and returns the
+// sorted sets whose scores changed or that the player should be added to,
+// with their new scores, and the sorted sets the player should be removed
+// from because the value is no longer in their properties.
+func IndexChanges(oldProperties string, newProperties string, indices []Index) (set map[string]float64, removed []string) {
+	// Metadata indices don't depend on the properties, and must be left alone.
+	user := make([]Index, 0, len(indices))
+	for _, index := range indices {
+		if !strings.HasPrefix(index.Attribute, "OM_METADATA") {
+			user = append(user, index)
+		}
+	}
+
+	now := time.Now()
+	oldValues := IndexValues(&pb.Player{Properties: oldProperties}, user, now)
+	newValues := IndexValues(&pb.Player{Properties: newProperties}, user, now)
+
+	set = make(map[string]float64)
+	for key, value := range newValues {
+		if old, ok := oldValues[key]; !ok || old != value {
+			set[key] = value
+		}
+	}
+	for key := range oldValues {
+		if _, ok := newValues[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+	return set, removed
+}
+
+// FilterMax returns the maximum value of a numeric filter, which is positive
+// infinity if it isn't set.
+func FilterMax(index Index, filter *pb.Filter) float64 {
+	if index.Type == FloatIndex {
+		if filter.Fmaxv == 0 {
+			return math.Inf(1)
+		}
+		return filter.Fmaxv
+	}
+	if filter.Maxv == 0 {
+		return math.Inf(1)
+	}
+	return float64(filter.Maxv)
+}
+
+// FilterMin returns the minimum value of a numeric filter.
+func FilterMin(index Index, filter *pb.Filter) float64 {
+	if index.Type == FloatIndex {
+		return filter.Fminv
+	}
+	return float64(filter.Minv)
+}
+
+// FilterKeys returns the sorted sets holding the players that match a string
+// or tags filter: one for each of the filter's values.
+func FilterKeys(index Index, filter *pb.Filter) []string {
+	keys := make([]string, 0, len(filter.Values))
+	for _, value := range filter.Values {
+		keys = append(keys, index.Key(value))
+	}
+	return keys
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2019 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statestorage
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// Proposal leases tie the players a proposal adds to the proposed ignorelist
+// to the proposal, so they return to the pool as soon as the proposal is
+// rejected or withdrawn, instead of when the ignorelist's duration runs out.
+// CreateProposal leases the players under the proposal's key; the evaluator
+// moves the lease of an approved proposal to its results key, and releases
+// the lease of a rejected one.
+//
+// Leases nobody releases, such as those of approved matches or of proposals
+// lost by a failed evaluation, are reaped once they are older than
+// 'proposalLeases.ttl' seconds, which defaults to the proposed ignorelist's
+// duration.
+
+var (
+	// Logrus structured logging setup
+	leLogFields = log.Fields{
+		"app":       "openmatch",
+		"component": "statestorage",
+	}
+	leLog = log.WithFields(leLogFields)
+)
+
+// Default for the proposalLeases.reapInterval config, in seconds.
+const defaultReapInterval = 60
+
+// ReapLeases releases stale proposal leases every
+// 'proposalLeases.reapInterval' seconds until the context is cancelled.
+func ReapLeases(ctx context.Context, cfg *viper.Viper, store Service) {
+	interval := time.Duration(cfg.GetInt("proposalLeases.reapInterval")) * time.Second
+	if interval <= 0 {
+		interval = defaultReapInterval * time.Second
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+		ReapStaleLeases(ctx, cfg, store, time.Now())
+	}
+}
+
+// ReapStaleLeases releases the leases on the proposed ignorelist that are
+// older than 'proposalLeases.ttl' seconds at the input time, and returns the
+// number of leases released.
+func ReapStaleLeases(ctx context.Context, cfg *viper.Viper, store Service, now time.Time) int {
+	il := cfg.GetString("ignoreLists.proposed.name")
+	ttl := cfg.GetInt64("proposalLeases.ttl")
+	if ttl <= 0 {
+		ttl = cfg.GetInt64("ignoreLists.proposed.duration")
+	}
+	if ttl <= 0 {
+		// Proposed players are ignored forever; so are their leases.
+		return 0
+	}
+
+	rlLog := leLog.WithFields(log.Fields{"ignorelist": il})
+	leases, err := store.RetrieveLeases(ctx, il, now.Unix()-ttl)
+	if err != nil {
+		rlLog.WithFields(log.Fields{"error": err.Error()}).Error("Failed to retrieve stale leases")
+		return 0
+	}
+	reaped := 0
+	for _, lease := range leases {
+		if _, err := store.ReleaseLease(ctx, il, lease); err != nil {
+			rlLog.WithFields(log.Fields{"error": err.Error(), "lease": lease}).Error("Failed to release stale lease")
+			continue
+		}
+		reaped++
+	}
+	if reaped > 0 {
+		rlLog.WithFields(log.Fields{"count": reaped}).Info("Released stale leases")
+	}
+	return reaped
+}
@@ -0,0 +1,949 @@
+/*
+Package memory is an in-process implementation of the Open Match state
+storage.  It keeps everything in maps guarded by a single lock, so it is only
+suitable for tests and for running all of Open Match in a single binary
+during development.
+
+Copyright 2019 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage"
+	"github.com/cenkalti/backoff"
+	"github.com/golang/protobuf/proto"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// Logrus structured logging setup
+var (
+	mLogFields = log.Fields{
+		"app":       "openmatch",
+		"component": "statestorage",
+	}
+	mLog = log.WithFields(mLogFields)
+)
+
+// StateStorage is the in-memory implementation of statestorage.Service.
+//
+// Player indices and ignorelists are both modeled as sorted sets (member ->
+// score), exactly like they are in Redis, so an ignorelist can be configured
+// to read a player index (as the default 'expired' ignorelist does).  Queues
+// are modeled as unordered sets.
+type StateStorage struct {
+	cfg *viper.Viper
+
+	mu           sync.RWMutex
+	players      map[string]*pb.Player
+	matchObjects map[string]*pb.MatchObject
+	sortedSets   map[string]map[string]float64
+	queues       map[string]map[string]struct{}
+	counters     map[string]int64
+	registry     map[string]*pb.PlayerIndex
+	// indexed holds the user-defined index sorted sets each indexed player
+	// is in, like the player's 'OM_INDEXED' field in Redis.
+	indexed map[string][]string
+	// parties holds the party each player joined, like the player's
+	// 'OM_PARTY' field in Redis.
+	parties map[string]string
+	// leases holds the players in each lease on an ignorelist (ignorelist
+	// -> lease ID -> players), and owners the lease owning each player's
+	// entry (ignorelist -> player -> lease ID).  Grant times are kept in
+	// the 'OM_LEASES.<ignorelist>' sorted set, as in Redis.
+	leases map[string]map[string][]string
+	owners map[string]map[string]string
+	// submitted holds the IDs of the results written with SubmitProposal,
+	// so retries are ignored, mapped to when they were written and their
+	// digest.  Like the Redis markers, entries expire after
+	// 'redis.expirations.matchobject' seconds.
+	submitted map[string]submission
+
+	// waiters holds the channels of the watchers of each player or match
+	// object, like the subscriptions of the Redis keyspace notifier.
+	waiters map[string]map[chan struct{}]struct{}
+}
+
+// submission records results written with SubmitProposal.
+type submission struct {
+	at     time.Time
+	digest string
+}
+
+// Compile-time check that StateStorage satisfies the interface.
+var _ statestorage.Service = (*StateStorage)(nil)
+
+// New returns an empty in-memory state storage.
+func New(cfg *viper.Viper) *StateStorage {
+	return &StateStorage{
+		cfg:          cfg,
+		players:      make(map[string]*pb.Player),
+		matchObjects: make(map[string]*pb.MatchObject),
+		sortedSets:   make(map[string]map[string]float64),
+		queues:       make(map[string]map[string]struct{}),
+		counters:     make(map[string]int64),
+		registry:     make(map[string]*pb.PlayerIndex),
+		indexed:      make(map[string][]string),
+		parties:      make(map[string]string),
+		leases:       make(map[string]map[string][]string),
+		owners:       make(map[string]map[string]string),
+		submitted:    make(map[string]submission),
+		waiters:      make(map[string]map[chan struct{}]struct{}),
+	}
+}
+
+// Close is a no-op; there are no connections to release.
+func (ms *StateStorage) Close() error {
+	return nil
+}
+
+// notify wakes up the watchers of the keys.  Must be called with the write
+// lock held.
+func (ms *StateStorage) notify(keys ...string) {
+	for _, key := range keys {
+		for ch := range ms.waiters[key] {
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// subscribe returns a channel that receives a value when key is written,
+// and a function to call once the caller has stopped watching.  Writes made
+// while a previous one hasn't been received yet are merged.
+func (ms *StateStorage) subscribe(key string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if _, ok := ms.waiters[key]; !ok {
+		ms.waiters[key] = make(map[chan struct{}]struct{})
+	}
+	ms.waiters[key][ch] = struct{}{}
+
+	return ch, func() {
+		ms.mu.Lock()
+		defer ms.mu.Unlock()
+		delete(ms.waiters[key], ch)
+		if len(ms.waiters[key]) == 0 {
+			delete(ms.waiters, key)
+		}
+	}
+}
+
+// zadd adds members to a sorted set.  Must be called with the write lock held.
+func (ms *StateStorage) zadd(key string, member string, score float64) {
+	set, ok := ms.sortedSets[key]
+	if !ok {
+		set = make(map[string]float64)
+		ms.sortedSets[key] = set
+	}
+	set[member] = score
+}
+
+// zrem removes a member from a sorted set.  Must be called with the write lock held.
+func (ms *StateStorage) zrem(key string, member string) {
+	if set, ok := ms.sortedSets[key]; ok {
+		delete(set, member)
+		if len(set) == 0 {
+			delete(ms.sortedSets, key)
+		}
+	}
+}
+
+// zrange returns the members of a sorted set with scores between min and max
+// (inclusive), ordered by score and then member like Redis ZRANGEBYSCORE.
+// Must be called with the read lock held.
+func (ms *StateStorage) zrange(key string, min float64, max float64) []string {
+	members := make([]string, 0)
+	set := ms.sortedSets[key]
+	for member, score := range set {
+		if min <= score && score <= max {
+			members = append(members, member)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if set[members[i]] != set[members[j]] {
+			return set[members[i]] < set[members[j]]
+		}
+		return members[i] < members[j]
+	})
+	return members
+}
+
+// CreatePlayer stores a copy of the player and indexes it.
+func (ms *StateStorage) CreatePlayer(ctx context.Context, player *pb.Player) error {
+	indices, err := statestorage.IndicesWithMeta(ms.cfg)
+	if err != nil {
+		return err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.players[player.Id] = proto.Clone(player).(*pb.Player)
+	values := statestorage.IndexValues(player, indices, time.Now())
+	for attribute, value := range values {
+		ms.zadd(attribute, player.Id, value)
+	}
+	ms.indexed[player.Id] = statestorage.UserIndexKeys(values)
+	ms.notify(player.Id)
+	return nil
+}
+
+// CreatePlayers creates each of the players.
+func (ms *StateStorage) CreatePlayers(ctx context.Context, players []*pb.Player) []error {
+	errs := make([]error, len(players))
+	for i, player := range players {
+		errs[i] = ms.CreatePlayer(ctx, player)
+	}
+	return errs
+}
+
+// UpdatePlayer replaces the player's properties and updates the changed
+// indices, following the same rules as the Redis implementation.
+func (ms *StateStorage) UpdatePlayer(ctx context.Context, player *pb.Player) error {
+	indices, err := statestorage.Indices(ms.cfg)
+	if err != nil {
+		return err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	p, ok := ms.players[player.Id]
+	if !ok {
+		return statestorage.ErrNotFound
+	}
+	set, removed := statestorage.IndexChanges(p.Properties, player.Properties, indices)
+
+	// Only indexed players have their indices updated.
+	if keys, indexed := ms.indexed[player.Id]; indexed {
+		for attribute, value := range set {
+			ms.zadd(attribute, player.Id, value)
+			keys = append(without(keys, attribute), attribute)
+		}
+		for _, attribute := range removed {
+			ms.zrem(attribute, player.Id)
+			keys = without(keys, attribute)
+		}
+		ms.indexed[player.Id] = keys
+	}
+	if _, ok := ms.sortedSets["OM_METADATA.accessed"][player.Id]; ok {
+		ms.zadd("OM_METADATA.accessed", player.Id, float64(time.Now().Unix()))
+	}
+	p.Properties = player.Properties
+	ms.notify(player.Id)
+	return nil
+}
+
+// CreateParty checks the members can join the party, then stores and
+// indexes the party and deindexes the members.
+func (ms *StateStorage) CreateParty(ctx context.Context, party *pb.Player) error {
+	indices, err := statestorage.IndicesWithMeta(ms.cfg)
+	if err != nil {
+		return err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	partyErr := &statestorage.PartyError{}
+	for _, id := range party.Members {
+		if _, ok := ms.players[id]; !ok {
+			partyErr.Missing = append(partyErr.Missing, id)
+		} else if _, ok := ms.players[ms.parties[id]]; ok {
+			partyErr.InParty = append(partyErr.InParty, id)
+		}
+	}
+	if len(partyErr.Missing) > 0 || len(partyErr.InParty) > 0 {
+		return partyErr
+	}
+
+	for _, id := range party.Members {
+		ms.parties[id] = party.Id
+		for _, key := range ms.indexed[id] {
+			ms.zrem(key, id)
+		}
+		delete(ms.indexed, id)
+	}
+	ms.players[party.Id] = proto.Clone(party).(*pb.Player)
+	values := statestorage.IndexValues(party, indices, time.Now())
+	for attribute, value := range values {
+		ms.zadd(attribute, party.Id, value)
+	}
+	ms.indexed[party.Id] = statestorage.UserIndexKeys(values)
+	ms.notify(party.Id)
+	return nil
+}
+
+// RetrievePlayer fills in the player from the stored copy.
+func (ms *StateStorage) RetrievePlayer(ctx context.Context, player *pb.Player) error {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	p, ok := ms.players[player.Id]
+	if !ok {
+		return statestorage.ErrNotFound
+	}
+	proto.Merge(player, p)
+	return nil
+}
+
+// DeindexPlayer removes the player from the sorted sets they are indexed in.
+func (ms *StateStorage) DeindexPlayer(ctx context.Context, playerID string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	for _, key := range ms.indexed[playerID] {
+		ms.zrem(key, playerID)
+	}
+	delete(ms.indexed, playerID)
+	return nil
+}
+
+// without returns the keys other than key.
+func without(keys []string, key string) []string {
+	kept := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if k != key {
+			kept = append(kept, k)
+		}
+	}
+	return kept
+}
+
+// DeindexPlayers deindexes each of the players.
+func (ms *StateStorage) DeindexPlayers(ctx context.Context, playerIDs []string) []error {
+	errs := make([]error, len(playerIDs))
+	for i, id := range playerIDs {
+		errs[i] = ms.DeindexPlayer(ctx, id)
+	}
+	return errs
+}
+
+// DeletePlayer removes the player record, ignorelist entries and metadata indices.
+func (ms *StateStorage) DeletePlayer(ctx context.Context, playerID string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	delete(ms.players, playerID)
+	delete(ms.indexed, playerID)
+	delete(ms.parties, playerID)
+	for il := range ms.cfg.GetStringMap("ignoreLists") {
+		ms.zrem(il, playerID)
+	}
+	for _, attribute := range statestorage.MetaIndices {
+		ms.zrem(attribute, playerID)
+	}
+	ms.notify(playerID)
+	return nil
+}
+
+// TouchPlayers updates the 'accessed' metadata index of the players in it.
+func (ms *StateStorage) TouchPlayers(ctx context.Context, playerIDs []string) []error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	errs := make([]error, len(playerIDs))
+	now := float64(time.Now().Unix())
+	for i, id := range playerIDs {
+		if _, ok := ms.sortedSets["OM_METADATA.accessed"][id]; !ok {
+			errs[i] = statestorage.ErrNotFound
+			continue
+		}
+		ms.zadd("OM_METADATA.accessed", id, now)
+	}
+	return errs
+}
+
+// UpdatePlayersField sets a field on multiple players.  As with a Redis HSET,
+// players that don't exist yet are created.
+func (ms *StateStorage) UpdatePlayersField(ctx context.Context, field string, values map[string]string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	for id, value := range values {
+		p, ok := ms.players[id]
+		if !ok {
+			p = &pb.Player{Id: id}
+			ms.players[id] = p
+		}
+		if err := setPlayerField(p, field, value); err != nil {
+			return err
+		}
+		ms.notify(id)
+	}
+	return nil
+}
+
+// TransitionPlayers sets the status of the existing players whose current
+// status is one of 'from'.
+func (ms *StateStorage) TransitionPlayers(ctx context.Context, to string, from []string, playerIDs []string) ([]string, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	var changed []string
+	for _, id := range playerIDs {
+		p, ok := ms.players[id]
+		if !ok {
+			continue
+		}
+		for _, status := range from {
+			if p.Status == status {
+				p.Status = to
+				changed = append(changed, id)
+				break
+			}
+		}
+	}
+	ms.notify(changed...)
+	return changed, nil
+}
+
+// RetrievePlayersField reads a field of multiple players.
+func (ms *StateStorage) RetrievePlayersField(ctx context.Context, field string, playerIDs []string) (map[string]string, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	values := make(map[string]string, len(playerIDs))
+	for _, id := range playerIDs {
+		p, ok := ms.players[id]
+		if !ok {
+			continue
+		}
+		value, err := getPlayerField(p, field)
+		if err != nil {
+			return nil, err
+		}
+		if value != "" {
+			values[id] = value
+		}
+	}
+	return values, nil
+}
+
+// DeletePlayersField clears a field on multiple players.
+func (ms *StateStorage) DeletePlayersField(ctx context.Context, field string, playerIDs []string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	for _, id := range playerIDs {
+		if p, ok := ms.players[id]; ok {
+			if err := setPlayerField(p, field, ""); err != nil {
+				return err
+			}
+		}
+	}
+	ms.notify(playerIDs...)
+	return nil
+}
+
+// setPlayerField sets one of the string fields of a player by its state
+// storage field name.
+func setPlayerField(p *pb.Player, field string, value string) error {
+	switch field {
+	case "properties":
+		p.Properties = value
+	case "pool":
+		p.Pool = value
+	case "assignment":
+		p.Assignment = value
+	case "status":
+		p.Status = value
+	case "error":
+		p.Error = value
+	default:
+		return fmt.Errorf("player field %v cannot be set", field)
+	}
+	return nil
+}
+
+// getPlayerField returns one of the string fields of a player by its state
+// storage field name.
+func getPlayerField(p *pb.Player, field string) (string, error) {
+	switch field {
+	case "properties":
+		return p.Properties, nil
+	case "pool":
+		return p.Pool, nil
+	case "assignment":
+		return p.Assignment, nil
+	case "status":
+		return p.Status, nil
+	case "error":
+		return p.Error, nil
+	case "members":
+		// Stored as a JSON list, as in Redis.
+		if len(p.Members) == 0 {
+			return "", nil
+		}
+		members, err := json.Marshal(p.Members)
+		return string(members), err
+	}
+	return "", fmt.Errorf("player field %v cannot be read", field)
+}
+
+// WatchPlayer streams changes to the player's assignment, status and error.
+// It follows the same backoff semantics as the Redis player watcher, but
+// wakes up as soon as the player is written instead of
+// sleeping for the full backoff interval.
+func (ms *StateStorage) WatchPlayer(bo backoff.BackOffContext, player pb.Player) <-chan pb.Player {
+	watchChan := make(chan pb.Player, 1)
+
+	go func() {
+		defer close(watchChan)
+		changed, unsubscribe := ms.subscribe(player.Id)
+		defer unsubscribe()
+		var prevResults = ""
+
+		for {
+
+			results := pb.Player{Id: player.Id}
+			ms.RetrievePlayer(bo.Context(), &results)
+
+			curResults := fmt.Sprintf("%v%v%v", results.Assignment, results.Status, results.Error)
+			if prevResults != curResults {
+				select {
+				case watchChan <- pb.Player{
+					Id:         results.Id,
+					Assignment: results.Assignment,
+					Status:     results.Status,
+					Error:      results.Error,
+				}:
+				case <-bo.Context().Done():
+					return
+				}
+				prevResults = curResults
+				bo.Reset()
+			}
+
+			d := bo.NextBackOff()
+			if d == backoff.Stop {
+				return
+			}
+			select {
+			case <-changed:
+			case <-time.After(d):
+			case <-bo.Context().Done():
+				return
+			}
+		}
+	}()
+
+	return watchChan
+}
+
+// CreateMatchObject stores a copy of the match object.
+func (ms *StateStorage) CreateMatchObject(ctx context.Context, mo *pb.MatchObject) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.matchObjects[mo.Id] = proto.Clone(mo).(*pb.MatchObject)
+	ms.notify(mo.Id)
+	return nil
+}
+
+// SubmitProposal stores the match object, leases its players, queues it and
+// decrements the counter under a single lock, after forgetting the results
+// submitted too long ago.
+func (ms *StateStorage) SubmitProposal(ctx context.Context, mo *pb.MatchObject, il string, queue string, counter string, playerIDs []string, strict bool, since int64) (bool, error) {
+	digest, err := statestorage.ResultsDigest(mo, playerIDs)
+	if err != nil {
+		return false, err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	now := time.Now()
+	if ttl := ms.cfg.GetInt64("redis.expirations.matchobject"); ttl > 0 {
+		for id, sub := range ms.submitted {
+			if now.Sub(sub.at) >= time.Duration(ttl)*time.Second {
+				delete(ms.submitted, id)
+			}
+		}
+	}
+	if sub, ok := ms.submitted[mo.Id]; ok {
+		if sub.digest != digest {
+			return false, statestorage.ErrResultsConflict
+		}
+		return false, nil
+	}
+	if strict && mo.Error == "" {
+		claimed := make([]string, 0)
+		for _, id := range playerIDs {
+			owner := ms.owners[il][id]
+			score, ignored := ms.sortedSets[il][id]
+			if ignored && score >= float64(since) && owner != "" && owner != mo.Id {
+				claimed = append(claimed, id)
+			}
+		}
+		if len(claimed) > 0 {
+			return false, &statestorage.ClaimError{PlayerIDs: claimed}
+		}
+	}
+	ms.submitted[mo.Id] = submission{at: now, digest: digest}
+	ms.matchObjects[mo.Id] = proto.Clone(mo).(*pb.MatchObject)
+	if mo.Error == "" {
+		if len(playerIDs) > 0 {
+			ms.lease(il, mo.Id, playerIDs)
+		}
+		ms.push(queue, mo.Id)
+	}
+	ms.counters[counter]--
+	ms.notify(mo.Id)
+	return true, nil
+}
+
+// RetrieveMatchObject fills in the match object from the stored copy.
+func (ms *StateStorage) RetrieveMatchObject(ctx context.Context, mo *pb.MatchObject) error {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	stored, ok := ms.matchObjects[mo.Id]
+	if !ok {
+		return statestorage.ErrNotFound
+	}
+	proto.Merge(mo, stored)
+	return nil
+}
+
+// DeleteMatchObject removes the match object.
+func (ms *StateStorage) DeleteMatchObject(ctx context.Context, id string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	delete(ms.matchObjects, id)
+	delete(ms.submitted, id)
+	ms.notify(id)
+	return nil
+}
+
+// WatchMatchObject waits for the match object to be written.
+func (ms *StateStorage) WatchMatchObject(bo backoff.BackOffContext, mo pb.MatchObject) <-chan pb.MatchObject {
+	watchChan := make(chan pb.MatchObject)
+
+	go func() {
+		defer close(watchChan)
+		changed, unsubscribe := ms.subscribe(mo.Id)
+		defer unsubscribe()
+
+		for {
+			results := pb.MatchObject{Id: mo.Id}
+			if err := ms.RetrieveMatchObject(bo.Context(), &results); err == nil {
+				mLog.WithFields(log.Fields{"id": mo.Id}).Debug("state storage watched record update detected")
+				select {
+				case watchChan <- results:
+				case <-bo.Context().Done():
+				}
+				return
+			}
+
+			d := bo.NextBackOff()
+			if d == backoff.Stop {
+				return
+			}
+			select {
+			case <-changed:
+			case <-time.After(d):
+			case <-bo.Context().Done():
+				return
+			}
+		}
+	}()
+
+	return watchChan
+}
+
+// CountIndexRange counts the players in the filter's index within its range,
+// or in any of its values for string and tags indices.
+func (ms *StateStorage) CountIndexRange(ctx context.Context, filter *pb.Filter) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	index := statestorage.FindIndex(ms.cfg, filter.Attribute)
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	members, _ := ms.filter(index, filter)
+	return int64(len(members)), nil
+}
+
+// RetrieveIndexRange returns a page of the players in the filter's index
+// within its range, or in any of its values for string and tags indices.
+func (ms *StateStorage) RetrieveIndexRange(ctx context.Context, filter *pb.Filter, offset int, count int) (map[string]float64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	index := statestorage.FindIndex(ms.cfg, filter.Attribute)
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	members, scores := ms.filter(index, filter)
+	results := make(map[string]float64)
+	for i := offset; i < len(members) && i < offset+count; i++ {
+		results[members[i]] = scores[members[i]]
+	}
+	return results, nil
+}
+
+// filter returns the players matching the filter in the same order as
+// Redis, with their scores.  Players matching more than one value of a
+// string or tags filter get their lowest score, like the union the Redis
+// implementation pages through.  Must be called with the read lock held.
+func (ms *StateStorage) filter(index statestorage.Index, filter *pb.Filter) ([]string, map[string]float64) {
+	if !index.ByValue() {
+		return ms.zrange(index.Attribute, statestorage.FilterMin(index, filter), statestorage.FilterMax(index, filter)), ms.sortedSets[index.Attribute]
+	}
+
+	scores := make(map[string]float64)
+	for _, key := range statestorage.FilterKeys(index, filter) {
+		for member, score := range ms.sortedSets[key] {
+			if old, ok := scores[member]; !ok || score < old {
+				scores[member] = score
+			}
+		}
+	}
+	members := make([]string, 0, len(scores))
+	for member := range scores {
+		members = append(members, member)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if scores[members[i]] != scores[members[j]] {
+			return scores[members[i]] < scores[members[j]]
+		}
+		return members[i] < members[j]
+	})
+	return members, scores
+}
+
+// RetrieveIndexRegistry returns copies of the registry entries, ordered by
+// attribute.
+func (ms *StateStorage) RetrieveIndexRegistry(ctx context.Context) ([]*pb.PlayerIndex, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	entries := make([]*pb.PlayerIndex, 0, len(ms.registry))
+	for _, entry := range ms.registry {
+		entries = append(entries, proto.Clone(entry).(*pb.PlayerIndex))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Attribute < entries[j].Attribute })
+	return entries, nil
+}
+
+// UpdateIndexRegistry stores a copy of the entry returned by update.
+func (ms *StateStorage) UpdateIndexRegistry(ctx context.Context, attribute string, update func(entry *pb.PlayerIndex) (*pb.PlayerIndex, error)) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	var current *pb.PlayerIndex
+	if entry, ok := ms.registry[attribute]; ok {
+		current = proto.Clone(entry).(*pb.PlayerIndex)
+	}
+	entry, err := update(current)
+	if err != nil || entry == nil {
+		return false, err
+	}
+	ms.registry[attribute] = proto.Clone(entry).(*pb.PlayerIndex)
+	return true, nil
+}
+
+// IndexPlayer adds the player to the given indices, if the player is still
+// indexed.
+func (ms *StateStorage) IndexPlayer(ctx context.Context, player *pb.Player, indices []statestorage.Index) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	keys, ok := ms.indexed[player.Id]
+	if !ok {
+		return nil
+	}
+	for key, value := range statestorage.IndexValues(player, indices, time.Now()) {
+		ms.zadd(key, player.Id, value)
+		keys = append(without(keys, key), key)
+	}
+	ms.indexed[player.Id] = keys
+	return nil
+}
+
+// DeleteIndex deletes the index's sorted sets.
+func (ms *StateStorage) DeleteIndex(ctx context.Context, index statestorage.Index) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	for key := range ms.sortedSets {
+		if key == index.Attribute || (index.ByValue() && strings.HasPrefix(key, index.Key(""))) {
+			delete(ms.sortedSets, key)
+		}
+	}
+	return nil
+}
+
+// AddToIgnoreList adds the players to the ignorelist with the current time.
+func (ms *StateStorage) AddToIgnoreList(ctx context.Context, il string, playerIDs []string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	now := float64(time.Now().Unix())
+	for _, id := range playerIDs {
+		ms.zadd(il, id, now)
+	}
+	return nil
+}
+
+// RemoveFromIgnoreList removes the players from the ignorelist.
+func (ms *StateStorage) RemoveFromIgnoreList(ctx context.Context, il string, playerIDs []string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	for _, id := range playerIDs {
+		ms.zrem(il, id)
+	}
+	return nil
+}
+
+// MoveIgnoredPlayers moves the players from one ignorelist to another.
+func (ms *StateStorage) MoveIgnoredPlayers(ctx context.Context, playerIDs []string, src string, dest string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	now := float64(time.Now().Unix())
+	for _, id := range playerIDs {
+		ms.zadd(dest, id, now)
+		ms.zrem(src, id)
+	}
+	return nil
+}
+
+// RetrieveIgnoreList returns the players added to the ignorelist in the window.
+func (ms *StateStorage) RetrieveIgnoreList(ctx context.Context, il string, from int64, until int64) ([]string, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.zrange(il, float64(from), float64(until)), nil
+}
+
+// LeasePlayers adds the players to the ignorelist with the current time,
+// owned by the lease.
+func (ms *StateStorage) LeasePlayers(ctx context.Context, il string, leaseID string, playerIDs []string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.lease(il, leaseID, playerIDs)
+	return nil
+}
+
+// lease adds the players to the ignorelist owned by the lease.  ms.mu must
+// be held.
+func (ms *StateStorage) lease(il string, leaseID string, playerIDs []string) {
+	now := float64(time.Now().Unix())
+	if ms.leases[il] == nil {
+		ms.leases[il] = make(map[string][]string)
+		ms.owners[il] = make(map[string]string)
+	}
+	ms.zadd("OM_LEASES."+il, leaseID, now)
+	for _, id := range playerIDs {
+		ms.zadd(il, id, now)
+		ms.owners[il][id] = leaseID
+	}
+	ms.leases[il][leaseID] = append(ms.leases[il][leaseID], playerIDs...)
+}
+
+// ReleaseLease removes the players the lease still owns from the ignorelist
+// and deletes the lease.
+func (ms *StateStorage) ReleaseLease(ctx context.Context, il string, leaseID string) ([]string, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	released := make([]string, 0)
+	for _, id := range ms.leases[il][leaseID] {
+		if ms.owners[il][id] == leaseID {
+			ms.zrem(il, id)
+			delete(ms.owners[il], id)
+			released = append(released, id)
+		}
+	}
+	delete(ms.leases[il], leaseID)
+	ms.zrem("OM_LEASES."+il, leaseID)
+	return released, nil
+}
+
+// RetrieveLeases returns the leases on the ignorelist granted until the
+// timestamp.
+func (ms *StateStorage) RetrieveLeases(ctx context.Context, il string, until int64) ([]string, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.zrange("OM_LEASES."+il, 0, float64(until)), nil
+}
+
+// PushQueue adds the value to the queue.
+func (ms *StateStorage) PushQueue(ctx context.Context, queue string, value string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.push(queue, value)
+	return nil
+}
+
+// push adds the value to the queue.  ms.mu must be held.
+func (ms *StateStorage) push(queue string, value string) {
+	q, ok := ms.queues[queue]
+	if !ok {
+		q = make(map[string]struct{})
+		ms.queues[queue] = q
+	}
+	q[value] = struct{}{}
+}
+
+// PopQueue removes and returns up to count values from the queue.
+func (ms *StateStorage) PopQueue(ctx context.Context, queue string, count int) ([]string, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	results := make([]string, 0)
+	for value := range ms.queues[queue] {
+		if len(results) >= count {
+			break
+		}
+		results = append(results, value)
+		delete(ms.queues[queue], value)
+	}
+	return results, nil
+}
+
+// CountQueue returns the number of values in the queue.
+func (ms *StateStorage) CountQueue(ctx context.Context, queue string) (int, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return len(ms.queues[queue]), nil
+}
+
+// IncrementCounter increments the counter and returns the new value.
+func (ms *StateStorage) IncrementCounter(ctx context.Context, key string) (int64, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.counters[key]++
+	return ms.counters[key], nil
+}
+
+// DecrementCounter decrements the counter and returns the new value.
+func (ms *StateStorage) DecrementCounter(ctx context.Context, key string) (int64, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.counters[key]--
+	return ms.counters[key], nil
+}
+
+// RetrieveCounter returns the counter, or ErrNotFound if it isn't set.
+func (ms *StateStorage) RetrieveCounter(ctx context.Context, key string) (int64, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	v, ok := ms.counters[key]
+	if !ok {
+		return 0, statestorage.ErrNotFound
+	}
+	return v, nil
+}
+
+// DeleteCounter removes the counter.
+func (ms *StateStorage) DeleteCounter(ctx context.Context, key string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	delete(ms.counters, key)
+	return nil
+}
@@ -0,0 +1,429 @@
+package memory
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage"
+	"github.com/cenkalti/backoff"
+	"github.com/spf13/viper"
+)
+
+func newTestStorage() *StateStorage {
+	cfg := viper.New()
+	cfg.Set("playerIndices", []string{"mmr.rating", "region.europe-west1"})
+	cfg.Set("ignoreLists.proposed.name", "proposed")
+	cfg.Set("ignoreLists.deindexed.name", "deindexed")
+	return New(cfg)
+}
+
+func TestIndexRange(t *testing.T) {
+	ctx := context.Background()
+	ms := newTestStorage()
+
+	for id, props := range map[string]string{
+		"a": `{"mmr": {"rating": 100}, "region": {"europe-west1": 10}}`,
+		"b": `{"mmr": {"rating": 200}}`,
+		"c": `{"mmr": {"rating": 300}}`,
+		"d": `{"region": {"europe-west1": 50}}`,
+	} {
+		if err := ms.CreatePlayer(ctx, &pb.Player{Id: id, Properties: props}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	filter := &pb.Filter{Attribute: "mmr.rating", Minv: 150}
+	count, err := ms.CountIndexRange(ctx, filter)
+	if err != nil || count != 2 {
+		t.Errorf("CountIndexRange: got (%v, %v), want (2, nil)", count, err)
+	}
+
+	page, _ := ms.RetrieveIndexRange(ctx, filter, 0, 1)
+	if len(page) != 1 || page["b"] != 200 {
+		t.Errorf("RetrieveIndexRange first page: got %v, want map[b:200]", page)
+	}
+	page, _ = ms.RetrieveIndexRange(ctx, filter, 1, 1)
+	if len(page) != 1 || page["c"] != 300 {
+		t.Errorf("RetrieveIndexRange second page: got %v, want map[c:300]", page)
+	}
+
+	ms.DeindexPlayer(ctx, "c")
+	count, _ = ms.CountIndexRange(ctx, filter)
+	if count != 1 {
+		t.Errorf("CountIndexRange after deindex: got %v, want 1", count)
+	}
+}
+
+func TestTypedIndices(t *testing.T) {
+	ctx := context.Background()
+	ms := newTestStorage()
+	ms.cfg.Set("playerIndices", []string{"ping.us-east:float", "mode:string", "maps:tags"})
+
+	for id, props := range map[string]string{
+		"a": `{"ping": {"us-east": 20.5}, "mode": "ctf", "maps": ["oasis", "aleroth"]}`,
+		"b": `{"ping": {"us-east": 45.25}, "mode": "demo", "maps": ["oasis"]}`,
+		"c": `{"ping": {"us-east": 90}, "mode": "battleroyale"}`,
+	} {
+		ms.CreatePlayer(ctx, &pb.Player{Id: id, Properties: props})
+	}
+
+	cases := []struct {
+		filter *pb.Filter
+		want   []string
+	}{
+		{&pb.Filter{Attribute: "ping.us-east", Fminv: 20.5, Fmaxv: 45.5}, []string{"a", "b"}},
+		{&pb.Filter{Attribute: "ping.us-east", Fminv: 45.3}, []string{"c"}},
+		{&pb.Filter{Attribute: "mode", Values: []string{"ctf", "demo"}}, []string{"a", "b"}},
+		{&pb.Filter{Attribute: "maps", Values: []string{"oasis", "aleroth"}}, []string{"a", "b"}},
+		{&pb.Filter{Attribute: "maps", Values: []string{"aleroth"}}, []string{"a"}},
+		{&pb.Filter{Attribute: "mode"}, []string{}},
+	}
+	for _, c := range cases {
+		count, _ := ms.CountIndexRange(ctx, c.filter)
+		page, _ := ms.RetrieveIndexRange(ctx, c.filter, 0, 10)
+		got := make([]string, 0, len(page))
+		for id := range page {
+			got = append(got, id)
+		}
+		sort.Strings(got)
+		if int(count) != len(c.want) || !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%v: got (%v, %v), want %v", c.filter, count, got, c.want)
+		}
+	}
+
+	ms.DeindexPlayer(ctx, "a")
+	if count, _ := ms.CountIndexRange(ctx, &pb.Filter{Attribute: "maps", Values: []string{"aleroth"}}); count != 0 {
+		t.Errorf("maps=aleroth after deindex: got %v, want 0", count)
+	}
+}
+
+func TestUpdatePlayer(t *testing.T) {
+	ctx := context.Background()
+	ms := newTestStorage()
+
+	if err := ms.UpdatePlayer(ctx, &pb.Player{Id: "missing"}); err != statestorage.ErrNotFound {
+		t.Errorf("UpdatePlayer missing: got %v, want ErrNotFound", err)
+	}
+
+	ms.CreatePlayer(ctx, &pb.Player{Id: "a", Properties: `{"mmr": {"rating": 100}, "region": {"europe-west1": 10}}`})
+	ms.CreatePlayer(ctx, &pb.Player{Id: "b", Properties: `{"mmr": {"rating": 100}}`})
+	created := ms.sortedSets["OM_METADATA.created"]["a"]
+	ms.sortedSets["OM_METADATA.created"]["a"] = created - 100
+
+	// Change one attribute and drop the other.
+	if err := ms.UpdatePlayer(ctx, &pb.Player{Id: "a", Properties: `{"mmr": {"rating": 150}}`}); err != nil {
+		t.Fatal(err)
+	}
+	if v := ms.sortedSets["mmr.rating"]["a"]; v != 150 {
+		t.Errorf("mmr.rating: got %v, want 150", v)
+	}
+	if _, ok := ms.sortedSets["region.europe-west1"]["a"]; ok {
+		t.Error("still indexed under region.europe-west1")
+	}
+	if v := ms.sortedSets["OM_METADATA.created"]["a"]; v != created-100 {
+		t.Errorf("created: got %v, want %v", v, created-100)
+	}
+
+	// Deindexed players aren't added back.
+	ms.DeindexPlayer(ctx, "b")
+	ms.UpdatePlayer(ctx, &pb.Player{Id: "b", Properties: `{"mmr": {"rating": 200}, "region": {"europe-west1": 10}}`})
+	if count, _ := ms.CountIndexRange(ctx, &pb.Filter{Attribute: "mmr.rating", Minv: 0}); count != 1 {
+		t.Errorf("mmr.rating count: got %v, want 1", count)
+	}
+	if _, ok := ms.sortedSets["region.europe-west1"]["b"]; ok {
+		t.Error("deindexed player added to region.europe-west1")
+	}
+
+	// Indexed players without any indexed attributes are added, and
+	// deindexed ones aren't.
+	ms.CreatePlayer(ctx, &pb.Player{Id: "c", Properties: `{}`})
+	ms.CreatePlayer(ctx, &pb.Player{Id: "d", Properties: `{}`})
+	ms.DeindexPlayer(ctx, "d")
+	ms.UpdatePlayer(ctx, &pb.Player{Id: "c", Properties: `{"mmr": {"rating": 300}}`})
+	ms.UpdatePlayer(ctx, &pb.Player{Id: "d", Properties: `{"mmr": {"rating": 300}}`})
+	if got := ms.zrange("mmr.rating", 300, 300); len(got) != 1 || got[0] != "c" {
+		t.Errorf("mmr.rating 300: got %v, want [c]", got)
+	}
+}
+
+func TestIgnoreLists(t *testing.T) {
+	ctx := context.Background()
+	ms := newTestStorage()
+
+	ms.AddToIgnoreList(ctx, "proposed", []string{"a", "b", "c"})
+	ms.MoveIgnoredPlayers(ctx, []string{"b"}, "proposed", "deindexed")
+
+	got, _ := statestorage.RetrieveIgnoreList(ctx, ms, ms.cfg, "proposed")
+	sort.Strings(got)
+	if len(got) != 2 || got[0] != "a" || got[1] != "c" {
+		t.Errorf("proposed: got %v, want [a c]", got)
+	}
+
+	// A window that ended before the players were added is empty.
+	now := time.Now().Unix()
+	got, _ = ms.RetrieveIgnoreList(ctx, "deindexed", 0, now-100)
+	if len(got) != 0 {
+		t.Errorf("deindexed, old window: got %v, want []", got)
+	}
+
+	ms.DeletePlayer(ctx, "b")
+	got, _ = ms.RetrieveIgnoreList(ctx, "deindexed", 0, now+100)
+	if len(got) != 0 {
+		t.Errorf("deindexed after delete: got %v, want []", got)
+	}
+}
+
+func TestLeases(t *testing.T) {
+	ctx := context.Background()
+	ms := newTestStorage()
+
+	ms.LeasePlayers(ctx, "proposed", "a", []string{"p1", "p2"})
+	ms.LeasePlayers(ctx, "proposed", "b", []string{"p2", "p3"})
+
+	// p2 was taken over by lease b, so releasing a leaves it ignored.
+	released, _ := ms.ReleaseLease(ctx, "proposed", "a")
+	if !reflect.DeepEqual(released, []string{"p1"}) {
+		t.Errorf("release a: got %v, want [p1]", released)
+	}
+	got, _ := ms.RetrieveIgnoreList(ctx, "proposed", 0, time.Now().Unix()+100)
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, []string{"p2", "p3"}) {
+		t.Errorf("ignored after release: got %v, want [p2 p3]", got)
+	}
+
+	leases, _ := ms.RetrieveLeases(ctx, "proposed", time.Now().Unix())
+	if !reflect.DeepEqual(leases, []string{"b"}) {
+		t.Errorf("leases: got %v, want [b]", leases)
+	}
+	if leases, _ = ms.RetrieveLeases(ctx, "proposed", time.Now().Unix()-100); len(leases) != 0 {
+		t.Errorf("old leases: got %v, want []", leases)
+	}
+}
+
+func TestSubmitProposal(t *testing.T) {
+	ctx := context.Background()
+	ms := newTestStorage()
+	ms.IncrementCounter(ctx, "mmfs")
+	ms.IncrementCounter(ctx, "mmfs")
+
+	mo := &pb.MatchObject{Id: "proposal.1.a"}
+	failed := &pb.MatchObject{Id: "proposal.1.b", Error: "no players"}
+	for i := 0; i < 2; i++ {
+		created, _ := ms.SubmitProposal(ctx, mo, "proposed", "proposalq", "mmfs", []string{"p1"}, true, 0)
+		if created != (i == 0) {
+			t.Errorf("submission %v: got created %v", i, created)
+		}
+		ms.SubmitProposal(ctx, failed, "proposed", "proposalq", "mmfs", nil, true, 0)
+	}
+	other := &pb.MatchObject{Id: mo.Id, Properties: "{}"}
+	if _, err := ms.SubmitProposal(ctx, other, "proposed", "proposalq", "mmfs", []string{"p1"}, true, 0); err != statestorage.ErrResultsConflict {
+		t.Errorf("other results: got %v, want ErrResultsConflict", err)
+	}
+
+	// In strict mode, a proposal sharing p1 is refused; otherwise the
+	// evaluator is left to choose.
+	rival := &pb.MatchObject{Id: "proposal.1.c"}
+	_, err := ms.SubmitProposal(ctx, rival, "proposed", "proposalq", "mmfs", []string{"p1", "p2"}, true, 0)
+	if claimErr, ok := err.(*statestorage.ClaimError); !ok || !reflect.DeepEqual(claimErr.PlayerIDs, []string{"p1"}) {
+		t.Errorf("strict rival: got %v, want p1 claimed", err)
+	}
+	if created, err := ms.SubmitProposal(ctx, rival, "proposed", "proposalq", "mmfs", []string{"p1", "p2"}, false, 0); !created || err != nil {
+		t.Errorf("rival: got (%v, %v), want it created", created, err)
+	}
+	ms.IncrementCounter(ctx, "mmfs")
+
+	queued, _ := ms.PopQueue(ctx, "proposalq", 10)
+	sort.Strings(queued)
+	if !reflect.DeepEqual(queued, []string{"proposal.1.a", "proposal.1.c"}) {
+		t.Errorf("queue: got %v, want [proposal.1.a proposal.1.c]", queued)
+	}
+	if n, _ := ms.RetrieveCounter(ctx, "mmfs"); n != 0 {
+		t.Errorf("counter: got %v, want 0", n)
+	}
+	if err := ms.RetrieveMatchObject(ctx, failed); err != nil {
+		t.Errorf("error results: got %v, want them stored", err)
+	}
+	released, _ := ms.ReleaseLease(ctx, "proposed", "proposal.1.c")
+	if !reflect.DeepEqual(released, []string{"p1", "p2"}) {
+		t.Errorf("lease: got %v, want [p1 p2]", released)
+	}
+
+	// Deleting the results, or their record expiring, lets results with
+	// the same ID be submitted again.
+	ms.DeleteMatchObject(ctx, mo.Id)
+	if created, _ := ms.SubmitProposal(ctx, mo, "proposed", "proposalq", "mmfs", nil, false, 0); !created {
+		t.Error("after delete: got not created, want created")
+	}
+	ms.cfg.Set("redis.expirations.matchobject", 60)
+	ms.submitted[failed.Id] = submission{at: time.Now().Add(-time.Minute)}
+	if created, _ := ms.SubmitProposal(ctx, failed, "proposed", "proposalq", "mmfs", nil, false, 0); !created {
+		t.Error("after expiry: got not created, want created")
+	}
+
+	// In strict mode, players added to the ignorelist before the start of
+	// its window aren't claimed any more.
+	ms.LeasePlayers(ctx, "proposed", "mo.profile", []string{"p1"})
+	stale := &pb.MatchObject{Id: "proposal.1.d"}
+	if _, err := ms.SubmitProposal(ctx, stale, "proposed", "proposalq", "mmfs", []string{"p1"}, true, 0); err == nil {
+		t.Error("strict: got nil, want p1 claimed")
+	}
+	if created, err := ms.SubmitProposal(ctx, stale, "proposed", "proposalq", "mmfs", []string{"p1"}, true, time.Now().Unix()+1); !created || err != nil {
+		t.Errorf("strict after window: got (%v, %v), want it created", created, err)
+	}
+}
+
+func TestQueuesAndCounters(t *testing.T) {
+	ctx := context.Background()
+	ms := newTestStorage()
+
+	ms.PushQueue(ctx, "profileq", "p1")
+	ms.PushQueue(ctx, "profileq", "p2")
+	ms.PushQueue(ctx, "profileq", "p3")
+	if n, _ := ms.CountQueue(ctx, "profileq"); n != 3 {
+		t.Errorf("CountQueue: got %v, want 3", n)
+	}
+	popped, _ := ms.PopQueue(ctx, "profileq", 2)
+	if len(popped) != 2 {
+		t.Errorf("PopQueue: got %v, want 2 values", popped)
+	}
+	if n, _ := ms.CountQueue(ctx, "profileq"); n != 1 {
+		t.Errorf("CountQueue after pop: got %v, want 1", n)
+	}
+
+	if _, err := ms.RetrieveCounter(ctx, "concurrentMMFs"); err != statestorage.ErrNotFound {
+		t.Errorf("RetrieveCounter unset: got %v, want ErrNotFound", err)
+	}
+	ms.IncrementCounter(ctx, "concurrentMMFs")
+	ms.IncrementCounter(ctx, "concurrentMMFs")
+	if v, _ := ms.DecrementCounter(ctx, "concurrentMMFs"); v != 1 {
+		t.Errorf("DecrementCounter: got %v, want 1", v)
+	}
+}
+
+func TestWatchMatchObject(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ms := newTestStorage()
+
+	// A long backoff interval means this only passes if the watcher is
+	// woken up by the write.
+	bo := backoff.NewConstantBackOff(time.Minute)
+	watchChan := ms.WatchMatchObject(backoff.WithContext(bo, ctx), pb.MatchObject{Id: "mo1"})
+
+	ms.CreateMatchObject(ctx, &pb.MatchObject{Id: "mo1", Properties: `{"ok": true}`})
+	select {
+	case mo, ok := <-watchChan:
+		if !ok || mo.Properties != `{"ok": true}` {
+			t.Errorf("got (%v, %v), want the created match object", mo, ok)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for match object")
+	}
+
+	// The watcher stops waiting on its key once it is done.
+	<-watchChan
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	if len(ms.waiters) != 0 {
+		t.Errorf("got waiters %v, want none", ms.waiters)
+	}
+}
+
+func TestIndexRegistry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ms := newTestStorage()
+	ms.cfg.Set("indexRegistry.interval", 1)
+	ms.cfg.Set("indexRegistry.gcDelay", 1)
+	ms.CreatePlayer(ctx, &pb.Player{Id: "a", Properties: `{"mmr": {"rating": 100}, "mode": "ctf"}`, Status: pb.PlayerStatus_QUEUED.String()})
+	ms.CreatePlayer(ctx, &pb.Player{Id: "b", Properties: `{"mode": "ctf"}`, Status: pb.PlayerStatus_MATCHED.String()})
+	ms.CreatePlayer(ctx, &pb.Player{Id: "c", Properties: `{"mode": "ctf"}`, Status: pb.PlayerStatus_PROPOSED.String()})
+
+	// eventually polls until the condition holds.
+	eventually := func(desc string, cond func() bool) {
+		for deadline := time.Now().Add(5 * time.Second); !cond(); time.Sleep(50 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %v", desc)
+			}
+		}
+	}
+
+	// The configured indices are registered when the watcher starts.
+	go statestorage.WatchIndices(ctx, ms.cfg, ms)
+	eventually("config indices", func() bool {
+		entries, _ := ms.RetrieveIndexRegistry(ctx)
+		return len(entries) == 2
+	})
+
+	// New indices are backfilled.
+	if err := statestorage.AddIndex(ctx, ms.cfg, ms, statestorage.Index{Attribute: "mode", Type: statestorage.StringIndex}); err != nil {
+		t.Fatal(err)
+	}
+	eventually("backfill", func() bool {
+		count, _ := ms.CountIndexRange(ctx, &pb.Filter{Attribute: "mode", Values: []string{"ctf"}})
+		return count == 2
+	})
+	if _, ok := ms.sortedSets["mode=ctf"]["b"]; ok {
+		t.Error("backfilled a player who isn't queued or proposed")
+	}
+
+	// An active index can't change type.
+	if err := statestorage.AddIndex(ctx, ms.cfg, ms, statestorage.Index{Attribute: "mode", Type: statestorage.TagsIndex}); err != statestorage.ErrIndexTypeConflict {
+		t.Errorf("AddIndex with another type: got %v, want ErrIndexTypeConflict", err)
+	}
+
+	// Retired indices are no longer used, then deleted.
+	if err := statestorage.RetireIndex(ctx, ms.cfg, ms, "mmr.rating"); err != nil {
+		t.Fatal(err)
+	}
+	if indices, _ := statestorage.Indices(ms.cfg); len(indices) != 2 {
+		t.Errorf("Indices after retiring: got %v, want 2 indices", indices)
+	}
+	if err := statestorage.RetireIndex(ctx, ms.cfg, ms, "mmr.rating"); err != statestorage.ErrNotFound {
+		t.Errorf("RetireIndex twice: got %v, want ErrNotFound", err)
+	}
+	eventually("garbage collection", func() bool {
+		ms.mu.RLock()
+		defer ms.mu.RUnlock()
+		_, ok := ms.sortedSets["mmr.rating"]
+		return !ok && ms.registry["mmr.rating"].Collected != 0
+	})
+}
+
+func TestSetPlayerStatus(t *testing.T) {
+	ctx := context.Background()
+	ms := newTestStorage()
+	for id, status := range map[string]pb.PlayerStatus{"proposed": pb.PlayerStatus_PROPOSED, "assigned": pb.PlayerStatus_ASSIGNED, "expired": pb.PlayerStatus_EXPIRED} {
+		ms.CreatePlayer(ctx, &pb.Player{Id: id, Status: status.String()})
+	}
+
+	// Finished players keep their status, and missing players aren't created.
+	ids := []string{"proposed", "assigned", "expired", "missing"}
+	if err := statestorage.SetPlayerStatus(ctx, ms, pb.PlayerStatus_QUEUED, ids); err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[string]pb.PlayerStatus{"proposed": pb.PlayerStatus_QUEUED, "assigned": pb.PlayerStatus_ASSIGNED, "expired": pb.PlayerStatus_EXPIRED} {
+		player := &pb.Player{Id: id}
+		ms.RetrievePlayer(ctx, player)
+		if player.Status != want.String() {
+			t.Errorf("%v: got status %v, want %v", id, player.Status, want)
+		}
+	}
+	if err := ms.RetrievePlayer(ctx, &pb.Player{Id: "missing"}); err != statestorage.ErrNotFound {
+		t.Errorf("missing player: got %v, want ErrNotFound", err)
+	}
+
+	// Party members follow their party.
+	ms.CreatePlayer(ctx, &pb.Player{Id: "member", Status: pb.PlayerStatus_QUEUED.String()})
+	ms.CreateParty(ctx, &pb.Player{Id: "party", Members: []string{"member"}, Status: pb.PlayerStatus_QUEUED.String()})
+	statestorage.SetPlayerStatus(ctx, ms, pb.PlayerStatus_PROPOSED, []string{"party"})
+	member := &pb.Player{Id: "member"}
+	ms.RetrievePlayer(ctx, member)
+	if member.Status != pb.PlayerStatus_PROPOSED.String() {
+		t.Errorf("member: got status %v, want PROPOSED", member.Status)
+	}
+}
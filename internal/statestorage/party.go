@@ -0,0 +1,153 @@
+/*
+Copyright 2019 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statestorage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	"github.com/spf13/viper"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// PartyPlayer returns the player record a party is stored and indexed as.
+// Its properties are the party's properties with these added, for every
+// configured int or float index at least one member has a value for:
+//   - <index>: the members' values combined using the method set in
+//     parties.aggregate (avg, max, min or sum; avg is the default).
+//   - party.avg.<index> and party.max.<index>: the average and maximum of the
+//     members' values.
+//
+// party.size is always set to the number of members.  String and tags
+// indices aren't aggregated; set them in the party's properties.
+func PartyPlayer(cfg *viper.Viper, party *pb.Party, members []*pb.Player) (*pb.Player, error) {
+	if party.Id == "" {
+		return nil, errors.New("party has no id")
+	}
+	if len(party.Members) == 0 {
+		return nil, errors.New("party has no members")
+	}
+	seen := make(map[string]bool, len(party.Members))
+	for _, id := range party.Members {
+		if id == party.Id {
+			return nil, errors.New("party can't be its own member")
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("party member '%v' listed twice", id)
+		}
+		seen[id] = true
+	}
+	method := cfg.GetString("parties.aggregate")
+	if method == "" {
+		method = "avg"
+	}
+	indices, err := Indices(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	props := party.Properties
+	if props == "" {
+		props = "{}"
+	}
+	if props, err = sjson.Set(props, "party.size", len(party.Members)); err != nil {
+		return nil, err
+	}
+	for _, index := range indices {
+		attribute := index.Attribute
+		if strings.HasPrefix(attribute, "OM_METADATA") || index.ByValue() {
+			continue
+		}
+		values := make([]float64, 0, len(members))
+		for _, m := range members {
+			if v := gjson.Get(m.Properties, attribute); v.Exists() {
+				values = append(values, v.Float())
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+
+		value, err := aggregate(method, values)
+		if err != nil {
+			return nil, err
+		}
+		avg, _ := aggregate("avg", values)
+		max, _ := aggregate("max", values)
+		for path, v := range map[string]float64{
+			attribute:                value,
+			"party.avg." + attribute: avg,
+			"party.max." + attribute: max,
+		} {
+			if props, err = sjson.Set(props, path, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &pb.Player{
+		Id:         party.Id,
+		Properties: props,
+		Members:    party.Members,
+	}, nil
+}
+
+// aggregate combines the members' values for one attribute.
+func aggregate(method string, values []float64) (float64, error) {
+	result := values[0]
+	for _, v := range values[1:] {
+		switch method {
+		case "avg", "sum":
+			result += v
+		case "max":
+			result = math.Max(result, v)
+		case "min":
+			result = math.Min(result, v)
+		}
+	}
+	switch method {
+	case "avg":
+		return result / float64(len(values)), nil
+	case "max", "min", "sum":
+		return result, nil
+	}
+	return 0, fmt.Errorf("unknown party aggregate '%v'", method)
+}
+
+// PartyMembers returns the members of each of the given IDs that is a
+// party, reading the 'members' field of all of them at once.  IDs whose
+// record can't be read are treated as single players.
+func PartyMembers(ctx context.Context, store Service, ids []string) map[string][]string {
+	parties := make(map[string][]string)
+	values, err := store.RetrievePlayersField(ctx, "members", ids)
+	if err != nil {
+		return parties
+	}
+	for id, value := range values {
+		var members []string
+		if err := json.Unmarshal([]byte(value), &members); err == nil && len(members) > 0 {
+			parties[id] = members
+		}
+	}
+	return parties
+}
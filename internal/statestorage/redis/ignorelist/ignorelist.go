@@ -216,3 +216,105 @@ func buildElementValueList(ignorelistID string, playerIDs []string) []interface{
 	}
 	return cmdArgs
 }
+
+// Leases record which players a proposal added to an ignorelist, so they can
+// be removed again as soon as the proposal is rejected or withdrawn.  Each
+// lease is a set of player IDs; a hash records the lease that owns each
+// player's ignorelist entry, which is the lease that added them most
+// recently, and a sorted set holds every lease by the time it was granted.
+
+// LeasesKey is the sorted set of the leases on an ignorelist, scored by the
+// epoch timestamp they were granted at.
+func LeasesKey(ignorelistID string) string {
+	return "OM_LEASES." + ignorelistID
+}
+
+// LeaseKey is the set of the players in a lease on an ignorelist.
+func LeaseKey(ignorelistID string, leaseID string) string {
+	return "OM_LEASE." + ignorelistID + "." + leaseID
+}
+
+// OwnersKey is the hash of the lease owning each player's entry in an
+// ignorelist.
+func OwnersKey(ignorelistID string) string {
+	return "OM_LEASE_OWNERS." + ignorelistID
+}
+
+// leaseScript adds players to an ignorelist under a lease.  KEYS are the
+// ignorelist, its owners hash, the lease set and the leases sorted set.
+// ARGV[1] is the current epoch timestamp, ARGV[2] the lease ID and the rest
+// of ARGV the player IDs.
+var leaseScript = redis.NewScript(4, `
+redis.call('ZADD', KEYS[4], ARGV[1], ARGV[2])
+for i = 3, #ARGV do
+	redis.call('ZADD', KEYS[1], ARGV[1], ARGV[i])
+	redis.call('HSET', KEYS[2], ARGV[i], ARGV[2])
+	redis.call('SADD', KEYS[3], ARGV[i])
+end
+return 0
+`)
+
+// releaseScript removes the players a lease still owns from an ignorelist
+// and deletes the lease.  KEYS are as for leaseScript, ARGV[1] is the lease
+// ID.  It returns the players removed.
+var releaseScript = redis.NewScript(4, `
+local released = {}
+for _, id in ipairs(redis.call('SMEMBERS', KEYS[3])) do
+	if redis.call('HGET', KEYS[2], id) == ARGV[1] then
+		redis.call('ZREM', KEYS[1], id)
+		redis.call('HDEL', KEYS[2], id)
+		released[#released + 1] = id
+	end
+end
+redis.call('DEL', KEYS[3])
+redis.call('ZREM', KEYS[4], ARGV[1])
+return released
+`)
+
+// Lease adds the players to the ignorelist with the current time, owned by
+// the lease.
+func Lease(ctx context.Context, pool *redis.Pool, ignorelistID string, leaseID string, playerIDs []string) error {
+	redisConn, err := pool.GetContext(ctx)
+	defer redisConn.Close()
+	if err != nil {
+		return err
+	}
+
+	ilLog.WithFields(log.Fields{
+		"ignorelist": ignorelistID,
+		"lease":      leaseID,
+		"numPlayers": len(playerIDs),
+	}).Debug("leasing players")
+
+	args := redis.Args{ignorelistID, OwnersKey(ignorelistID), LeaseKey(ignorelistID, leaseID), LeasesKey(ignorelistID), time.Now().Unix(), leaseID}.AddFlat(playerIDs)
+	_, err = leaseScript.Do(redisConn, args...)
+	return err
+}
+
+// Release removes the players the lease still owns from the ignorelist and
+// deletes the lease.  It returns the players removed.
+func Release(ctx context.Context, pool *redis.Pool, ignorelistID string, leaseID string) ([]string, error) {
+	redisConn, err := pool.GetContext(ctx)
+	defer redisConn.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	ilLog.WithFields(log.Fields{
+		"ignorelist": ignorelistID,
+		"lease":      leaseID,
+	}).Debug("releasing lease")
+
+	return redis.Strings(releaseScript.Do(redisConn, ignorelistID, OwnersKey(ignorelistID), LeaseKey(ignorelistID, leaseID), LeasesKey(ignorelistID), leaseID))
+}
+
+// RetrieveLeases returns the IDs of the leases on the ignorelist granted at
+// or before the until epoch timestamp.
+func RetrieveLeases(ctx context.Context, pool *redis.Pool, ignorelistID string, until int64) ([]string, error) {
+	redisConn, err := pool.GetContext(ctx)
+	defer redisConn.Close()
+	if err != nil {
+		return nil, err
+	}
+	return retrieve(redisConn, LeasesKey(ignorelistID), 0, until)
+}
@@ -0,0 +1,252 @@
+/*
+Copyright 2019 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package keyspace delivers Redis keyspace notifications to the state
+// storage watchers, so they find out about changes to the keys they are
+// watching without waiting for their next poll.
+//
+// Notifications are best-effort: Redis doesn't queue them for disconnected
+// clients, and they must be enabled on the Redis server.  Watchers should
+// keep polling (less often) in case one is missed.
+//
+// Reference: https://redis.io/topics/notifications
+package keyspace
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// Logrus structured logging setup
+var (
+	ksLogFields = log.Fields{
+		"app":       "openmatch",
+		"component": "statestorage",
+	}
+	ksLog = log.WithFields(ksLogFields)
+)
+
+// channelPrefix is the prefix of the keyspace notification channel for a
+// key in database 0, the database Open Match uses.
+const channelPrefix = "__keyspace@0__:"
+
+// requiredEvents are the notify-keyspace-events flags the watchers need:
+// keyspace events ('K') for hash ('h'), string ('$') and generic ('g')
+// commands.
+const requiredEvents = "Khg$"
+
+// reconnectDelay is how long to wait before reconnecting after the
+// subscription connection fails.
+const reconnectDelay = time.Second
+
+// Notifier subscribes to keyspace notifications for the keys being watched
+// over a single Redis connection, and passes them on to the watchers.  A nil
+// *Notifier is valid, and never sends notifications.
+type Notifier struct {
+	pool *redis.Pool
+
+	mu   sync.Mutex
+	psc  *redis.PubSubConn
+	subs map[string]map[chan struct{}]struct{}
+	done chan struct{}
+}
+
+// New returns a Notifier using a connection from pool, or nil if
+// redis.notifications.enabled is false.  If redis.notifications.configure is
+// true, it also turns on the keyspace notifications it needs on the Redis
+// server.
+func New(cfg *viper.Viper, pool *redis.Pool) *Notifier {
+	if !cfg.GetBool("redis.notifications.enabled") {
+		return nil
+	}
+	if cfg.GetBool("redis.notifications.configure") {
+		if err := configure(pool); err != nil {
+			ksLog.WithFields(log.Fields{"error": err.Error()}).Warn("Unable to enable Redis keyspace notifications, watchers will rely on polling")
+		}
+	}
+
+	n := &Notifier{
+		pool: pool,
+		subs: make(map[string]map[chan struct{}]struct{}),
+		done: make(chan struct{}),
+	}
+	go n.run()
+	return n
+}
+
+// configure adds the required flags to the server's notify-keyspace-events
+// setting, keeping any flags that are already set.
+func configure(pool *redis.Pool) error {
+	redisConn := pool.Get()
+	defer redisConn.Close()
+
+	current, err := redis.Strings(redisConn.Do("CONFIG", "GET", "notify-keyspace-events"))
+	if err != nil {
+		return err
+	}
+	events := ""
+	if len(current) == 2 {
+		events = current[1]
+	}
+	missing := ""
+	for _, flag := range requiredEvents {
+		if !strings.ContainsRune(events, flag) && !(flag != 'K' && strings.ContainsRune(events, 'A')) {
+			missing += string(flag)
+		}
+	}
+	if missing == "" {
+		return nil
+	}
+	_, err = redisConn.Do("CONFIG", "SET", "notify-keyspace-events", events+missing)
+	return err
+}
+
+// Subscribe returns a channel that receives a value when key changes, and a
+// function to call once the caller has stopped watching.  Notifications
+// that arrive while a previous one hasn't been received yet are merged.
+func (n *Notifier) Subscribe(key string) (<-chan struct{}, func()) {
+	if n == nil {
+		return nil, func() {}
+	}
+
+	ch := make(chan struct{}, 1)
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, ok := n.subs[key]; !ok {
+		n.subs[key] = make(map[chan struct{}]struct{})
+		n.send("SUBSCRIBE", key)
+	}
+	n.subs[key][ch] = struct{}{}
+
+	return ch, func() {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		delete(n.subs[key], ch)
+		if len(n.subs[key]) == 0 {
+			delete(n.subs, key)
+			n.send("UNSUBSCRIBE", key)
+		}
+	}
+}
+
+// send subscribes or unsubscribes from a key's channel, if connected.  If
+// it fails, the connection is broken and run reconnects.  n.mu must be held.
+func (n *Notifier) send(cmd string, key string) {
+	if n.psc == nil {
+		return
+	}
+	var err error
+	if cmd == "SUBSCRIBE" {
+		err = n.psc.Subscribe(channelPrefix + key)
+	} else {
+		err = n.psc.Unsubscribe(channelPrefix + key)
+	}
+	if err != nil {
+		ksLog.WithFields(log.Fields{"error": err.Error(), "key": key}).Debug("Keyspace subscription update failed")
+	}
+}
+
+// notify passes a notification for key on to its watchers.
+func (n *Notifier) notify(key string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for ch := range n.subs[key] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// run receives notifications until the Notifier is closed, reconnecting if
+// the connection fails.  Watchers are notified after a reconnect, since
+// changes may have been missed.
+func (n *Notifier) run() {
+	for {
+		n.receive()
+		select {
+		case <-n.done:
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+// receive subscribes to every watched key over a new connection and
+// delivers notifications until the connection fails or is closed.
+func (n *Notifier) receive() {
+	psc := &redis.PubSubConn{Conn: n.pool.Get()}
+	defer psc.Close()
+
+	n.mu.Lock()
+	select {
+	case <-n.done:
+		n.mu.Unlock()
+		return
+	default:
+	}
+	n.psc = psc
+	// Subscribe to a channel nothing publishes to, so the connection is in
+	// subscriber mode even when no keys are being watched.
+	psc.Subscribe(channelPrefix)
+	keys := make([]string, 0, len(n.subs))
+	for key := range n.subs {
+		psc.Subscribe(channelPrefix + key)
+		keys = append(keys, key)
+	}
+	n.mu.Unlock()
+	for _, key := range keys {
+		n.notify(key)
+	}
+
+	defer func() {
+		n.mu.Lock()
+		n.psc = nil
+		n.mu.Unlock()
+	}()
+	for {
+		switch v := psc.Receive().(type) {
+		case redis.Message:
+			n.notify(strings.TrimPrefix(v.Channel, channelPrefix))
+		case error:
+			select {
+			case <-n.done:
+			default:
+				ksLog.WithFields(log.Fields{"error": v.Error()}).Warn("Keyspace notification connection failed, reconnecting")
+			}
+			return
+		}
+	}
+}
+
+// Close stops receiving notifications.
+func (n *Notifier) Close() error {
+	if n == nil {
+		return nil
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	close(n.done)
+	if n.psc != nil {
+		return n.psc.Close()
+	}
+	return nil
+}
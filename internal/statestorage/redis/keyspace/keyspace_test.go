@@ -0,0 +1,76 @@
+package keyspace
+
+import (
+	"testing"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/rafaeljusto/redigomock"
+)
+
+func TestConfigure(t *testing.T) {
+	tests := []struct {
+		current string
+		set     string
+	}{
+		{"", "Khg$"},
+		{"Ex", "ExKhg$"},
+		{"KA", ""},
+		{"Kgh$z", ""},
+	}
+	for _, tt := range tests {
+		redisConn := redigomock.NewConn()
+		redisConn.Command("CONFIG", "GET", "notify-keyspace-events").ExpectSlice([]byte("notify-keyspace-events"), []byte(tt.current))
+		set := redisConn.Command("CONFIG", "SET", "notify-keyspace-events", tt.set).Expect("OK")
+		pool := &redis.Pool{Dial: func() (redis.Conn, error) { return redisConn, nil }}
+
+		if err := configure(pool); err != nil {
+			t.Fatal(err)
+		}
+		if called := redisConn.Stats(set) > 0; called != (tt.set != "") {
+			t.Errorf("configure with '%v': CONFIG SET called = %v, want %v", tt.current, called, tt.set != "")
+		}
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	n := &Notifier{subs: make(map[string]map[chan struct{}]struct{})}
+	a, stopA := n.Subscribe("key")
+	b, stopB := n.Subscribe("key")
+
+	// Notifications are merged until received.
+	n.notify("key")
+	n.notify("key")
+	n.notify("other")
+	for _, ch := range []<-chan struct{}{a, b} {
+		select {
+		case <-ch:
+		default:
+			t.Fatal("expected a notification")
+		}
+		select {
+		case <-ch:
+			t.Fatal("expected one notification")
+		default:
+		}
+	}
+
+	stopA()
+	n.notify("key")
+	select {
+	case <-a:
+		t.Error("notified after unsubscribing")
+	default:
+	}
+	stopB()
+	if len(n.subs) != 0 {
+		t.Errorf("subscriptions remain after unsubscribing: %v", n.subs)
+	}
+
+	// A nil Notifier never notifies.
+	var nilNotifier *Notifier
+	ch, stop := nilNotifier.Subscribe("key")
+	stop()
+	if ch != nil {
+		t.Error("nil Notifier returned a channel")
+	}
+}
@@ -20,16 +20,19 @@ package playerindices
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
 	om_messages "github.com/GoogleCloudPlatform/open-match/internal/pb"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage"
+	"github.com/gogo/protobuf/jsonpb"
 	"github.com/gomodule/redigo/redis"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
-	"github.com/tidwall/gjson"
 )
 
 var (
@@ -41,14 +44,13 @@ var (
 	piLog = log.WithFields(piLogFields)
 
 	// OM Internal metadata indices
-	MetaIndices = []string{
-		"OM_METADATA.created",
-		"OM_METADATA.accessed",
-	}
+	MetaIndices = statestorage.MetaIndices
 )
 
-// Indexing  is all done using Sorted Sets in Redis, which require integer
-// 'scores' for each attribute in order to index players.
+// Indexing  is all done using Sorted Sets in Redis, which require numeric
+// 'scores' for each attribute in order to index players.  Categorical data
+// can use string and tags indices instead, which keep a sorted set per value
+// (see 'Typed indices' below).
 //
 // Here are the guidelines if you want to index a player attribute in your
 // Properties JSON blob when the  player's request comes in the Frontend  API
@@ -93,9 +95,12 @@ var (
 //     "mmr.rating",
 //   ]
 //
-// For now, OM reads your 'config/matchmaker_config.(json|yaml)' file for a
-// list of indices, which it monitors using the golang module Viper
-// (https://github.com/spf13/viper).
+// OM reads your 'config/matchmaker_config.(json|yaml)' file for a list of
+// indices, which it monitors using the golang module Viper
+// (https://github.com/spf13/viper).  Each API adds the indices in its config
+// to the index registry in state storage when it starts, and from then on
+// uses the registry, which can be changed through the Admin API (see
+// api/protobuf-spec/admin.proto).
 // In a full deployment, it is expected that you don't manage the config file
 // directly, but instead put the contents of that file into a Kubernetes
 // ConfigMap.  Kubernetes will write those contents to a file inside your
@@ -110,6 +115,67 @@ var (
 // an index. Changes will affect all indexed players that come in the Frontend
 // API from that point on.
 // NOTE: there are potential edge cases here; see Retrieve() for details.
+//
+// Typed indices
+//
+// An index can be given a type by adding it after a colon:
+//   "indices": [
+//     "mmr.rating",
+//     "pings.us-east:float",
+//     "mode:string",
+//     "maps:tags",
+//   ]
+// int (the default) and float indices are sorted sets named after the
+// attribute, scored by the value.  string and tags indices are a sorted set
+// per value named '<attribute>=<value>' (e.g. 'mode=ctf'), scored by the
+// epoch timestamp the player was indexed at; a tags index reads a JSON array
+// of strings, like "maps": ["sunsetvalley", "bigskymountain"], and indexes
+// the player under each of them.
+//
+// The sorted sets of the user-defined indices a player was added to are
+// recorded in the player's hash (see IndexedField), so the player can be
+// removed from exactly those sets without scanning for values.
+
+// IndexedField is the field of a player's hash holding the JSON array of
+// the user-defined index sorted sets the player is in.  It is deleted when
+// the player is deindexed, so it also marks whether the player is indexed.
+const IndexedField = "OM_INDEXED"
+
+// maxAttempts is how many times a script or transaction is retried when the
+// keys it read are modified before it runs.
+const maxAttempts = 5
+
+// deindexScript removes a player from the sorted sets they are indexed in
+// and deletes their IndexedField.  KEYS[1] is the player's hash and the rest
+// of KEYS are the sorted sets; ARGV[1] is the player ID, and ARGV[2] and
+// ARGV[3] are the IndexedField and properties the sorted sets were read
+// from (empty if unset).  If either has changed since, nothing is done and 0 is
+// returned; otherwise it returns 1.
+var deindexScript = redis.NewScript(-1, `
+if (redis.call('HGET', KEYS[1], 'OM_INDEXED') or '') ~= ARGV[2] or
+   (redis.call('HGET', KEYS[1], 'properties') or '') ~= ARGV[3] then
+	return 0
+end
+for i = 2, #KEYS do
+	redis.call('ZREM', KEYS[i], ARGV[1])
+end
+redis.call('HDEL', KEYS[1], 'OM_INDEXED')
+return 1
+`)
+
+// EncodeIndexed returns the IndexedField value for the sorted sets.
+func EncodeIndexed(keys []string) (string, error) {
+	sort.Strings(keys)
+	indexed, err := json.Marshal(keys)
+	return string(indexed), err
+}
+
+// DecodeIndexed parses an IndexedField value.
+func DecodeIndexed(indexed string) ([]string, error) {
+	keys := []string{}
+	err := json.Unmarshal([]byte(indexed), &keys)
+	return keys, err
+}
 
 // Create indices for given player attributes in Redis.
 // TODO: make this quit and not index the player if the context is cancelled.
@@ -119,87 +185,181 @@ func Create(ctx context.Context, rPool *redis.Pool, cfg *viper.Viper, player om_
 	redisConn := rPool.Get()
 	defer redisConn.Close()
 
+	// Start putting this player into the indices in Redis.
+	redisConn.Send("MULTI")
+	if err := SendCreate(redisConn, cfg, player); err != nil {
+		redisConn.Send("DISCARD")
+		return err
+	}
+
+	// Run pipelined Redis commands.
+	_, err := redisConn.Do("EXEC")
+
+	return err
+}
+
+// SendCreate queues the commands to index the player on the connection
+// without executing them, so they can be pipelined with other commands.
+func SendCreate(redisConn redis.Conn, cfg *viper.Viper, player om_messages.Player) error {
 	iLog := piLog.WithFields(log.Fields{"playerId": player.Id})
 
-	// Get the indices from viper
-	indices, err := Retrieve(cfg)
-	// Get metadata indicies
-	indices = append(indices, MetaIndices...)
+	// Get the indices from viper, including the metadata indices
+	indices, err := statestorage.IndicesWithMeta(cfg)
 	if err != nil {
 		iLog.Error(err.Error())
 		return err
 	}
 
-	// Start putting this player into the indices in Redis.
-	redisConn.Send("MULTI")
-	// Loop through all attributes we want to index.
-	for _, attribute := range indices {
-		// Default value for all attributes if missing or malformed is the current epoch timestamp.
-		value := time.Now().Unix()
-
-		// If this is a user-defined index, look for it in the input player properties JSON
-		if !strings.HasPrefix(attribute, "OM_METADATA") {
-			// NOTE: This gjson call has issues with JSON keys containing meta characters (dot, slash, etc).
-			//   The regexp.QuoteMeta below gets around those issues, but won't pick up dot-notation keys!
-			//   End result is that you shouldn't use meta characters in your JSON property keys!
-			//v := gjson.Get(player.Properties, regexp.QuoteMeta(attribute))
-			v := gjson.Get(player.Properties, attribute)
-
-			// If this attribute wasn't provided in the JSON, continue to the
-			// next attribute to index.
-			if !v.Exists() {
-				iLog.WithFields(log.Fields{"attribute": attribute, "value": v.Raw}).Debug("Couldn't find index in JSON: ", player.Properties)
-				continue
-			} else if -9223372036854775808 <= v.Int() && v.Int() <= 9223372036854775807 {
-				// value contains a valid 64-bit integer
-				value = v.Int()
-			} else {
-				iLog.WithFields(log.Fields{"attribute": attribute}).Debug("No valid value for attribute, not indexing")
-			}
-
-		}
+	// Loop through all attributes we found values for.
+	values := statestorage.IndexValues(&player, indices, time.Now())
+	for key, value := range values {
 		// Index the attribute by value.
-		iLog.Debug(fmt.Sprintf("%v %v %v %v", "ZADD", attribute, player.Id, value))
-		redisConn.Send("ZADD", attribute, value, player.Id)
+		iLog.Debug(fmt.Sprintf("%v %v %v %v", "ZADD", key, player.Id, value))
+		redisConn.Send("ZADD", key, value, player.Id)
 	}
 
-	// Run pipelined Redis commands.
-	_, err = redisConn.Do("EXEC")
-
-	return err
+	// Record the sorted sets so the player can be deindexed from them.
+	indexed, err := EncodeIndexed(statestorage.UserIndexKeys(values))
+	if err != nil {
+		return err
+	}
+	return redisConn.Send("HSET", player.Id, IndexedField, indexed)
 }
 
 // Delete a player's indices without deleting their JSON object representation from
-// state storage.
+// state storage.  The player is removed from the sorted sets recorded in
+// their IndexedField; the removal is retried if the player is modified
+// after the field is read.
 // Note: In Open Match, it is best practice to 'lazily' remove indices
 // by running this as a goroutine.
-// TODO: make this quit cleanly if the context is cancelled.
 func Delete(ctx context.Context, rPool *redis.Pool, cfg *viper.Viper, playerID string) error {
+	redisConn, err := rPool.GetContext(ctx)
+	defer redisConn.Close()
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		args, err := deindexArgs(redisConn, cfg, playerID)
+		if err != nil {
+			return err
+		}
+		deleted, err := redis.Int(deindexScript.Do(redisConn, args...))
+		if err != nil || deleted == 1 {
+			return err
+		}
+		piLog.WithFields(log.Fields{"playerID": playerID}).Debug("Player modified during deindexing, retrying")
+	}
+	return errors.New("player was modified concurrently too many times during deindexing")
+}
 
-	diLog := piLog.WithFields(log.Fields{"playerID": playerID})
+// DeleteMany is Delete for many players, pipelining the reads and then the
+// removals over a single connection.  Players modified in between are
+// retried with Delete.  It returns an error for each player, in order.
+func DeleteMany(ctx context.Context, rPool *redis.Pool, cfg *viper.Viper, playerIDs []string) []error {
+	errs := make([]error, len(playerIDs))
+	redisConn, err := rPool.GetContext(ctx)
+	if err != nil {
+		redisConn.Close()
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
 
-	// Connect to redis
-	redisConn := rPool.Get()
-	defer redisConn.Close()
+	for _, id := range playerIDs {
+		redisConn.Send("HMGET", id, IndexedField, "properties")
+	}
+	redisConn.Flush()
+	args := make([]redis.Args, len(playerIDs))
+	for i, id := range playerIDs {
+		fields, err := redis.Strings(redisConn.Receive())
+		if err == nil {
+			args[i], err = deindexKeys(cfg, id, fields[0], fields[1])
+		}
+		errs[i] = err
+	}
 
-	// Get the list of indices to delete
-	indices, err := Retrieve(cfg)
-	// Look for previously configured indices
-	indices = append(indices, RetrievePrevious(cfg)...)
+	for i := range playerIDs {
+		if errs[i] == nil {
+			deindexScript.Send(redisConn, args[i]...)
+		}
+	}
+	redisConn.Flush()
+	var retry []int
+	for i := range playerIDs {
+		if errs[i] != nil {
+			continue
+		}
+		deleted, err := redis.Int(redisConn.Receive())
+		if err == nil && deleted == 0 {
+			retry = append(retry, i)
+		}
+		errs[i] = err
+	}
+	redisConn.Close()
+
+	for _, i := range retry {
+		errs[i] = Delete(ctx, rPool, cfg, playerIDs[i])
+	}
+	return errs
+}
+
+// deindexArgs reads the player's IndexedField and properties and returns
+// the arguments for deindexScript.
+func deindexArgs(redisConn redis.Conn, cfg *viper.Viper, playerID string) (redis.Args, error) {
+	fields, err := redis.Strings(redisConn.Do("HMGET", playerID, IndexedField, "properties"))
 	if err != nil {
-		diLog.Error(err.Error())
-		return err
+		return nil, err
 	}
+	return deindexKeys(cfg, playerID, fields[0], fields[1])
+}
 
-	// Remove playerID from indices
-	redisConn.Send("MULTI")
-	for _, attribute := range indices {
-		diLog.WithFields(log.Fields{"attribute": attribute}).Debug("De-indexing")
-		redisConn.Send("ZREM", attribute, playerID)
+// deindexKeys returns the arguments for deindexScript given the player's
+// IndexedField and properties.
+func deindexKeys(cfg *viper.Viper, playerID string, indexed string, properties string) (redis.Args, error) {
+	keys, err := IndexedKeys(cfg, indexed, properties)
+	if err != nil {
+		return nil, err
 	}
-	_, err = redisConn.Do("EXEC")
-	return err
+	return redis.Args{len(keys) + 1, playerID}.AddFlat(keys).Add(playerID, indexed, properties), nil
+}
 
+// IndexedKeys returns the sorted sets a player is indexed in given their
+// IndexedField and properties.  Players indexed before the field was
+// recorded are removed from every numeric index, and from the string and
+// tags index sorted sets for the values in their properties, of the current
+// and previously configured indices.
+func IndexedKeys(cfg *viper.Viper, indexed string, properties string) ([]string, error) {
+	if indexed != "" {
+		return DecodeIndexed(indexed)
+	}
+	indices, err := Retrieve(cfg)
+	if err != nil {
+		return nil, err
+	}
+	indices = append(indices, RetrievePrevious(cfg)...)
+	var keys []string
+	for _, index := range indices {
+		if index.ByValue() {
+			for _, value := range index.Values(properties) {
+				keys = append(keys, index.Key(value))
+			}
+		} else {
+			keys = append(keys, index.Attribute)
+		}
+	}
+	return keys, nil
+}
+
+// SendDeleteKeys queues the commands to remove the player from the sorted
+// sets returned by IndexedKeys and delete their IndexedField, so they can be
+// run in a transaction that WATCHes the player.
+func SendDeleteKeys(redisConn redis.Conn, playerID string, keys []string) {
+	for _, key := range keys {
+		redisConn.Send("ZREM", key, playerID)
+	}
+	redisConn.Send("HDEL", playerID, IndexedField)
 }
 
 // DeleteMeta removes a player's internal Open Match metadata indices, and should only be used
@@ -227,47 +387,258 @@ func DeleteMeta(ctx context.Context, rPool *redis.Pool, playerID string) {
 	}
 }
 
-// Touch is analogous to the Unix touch command.  It updates the accessed time of the player
-// in the OM_METADATA.accessed index to the current epoch timestamp.
-func Touch(ctx context.Context, rPool *redis.Pool, playerID string) error {
-	// Connect to redis
-	redisConn := rPool.Get()
+// touchScript updates the accessed time of the players already in
+// OM_METADATA.accessed, leaving out players who were never created or have
+// been deleted.  KEYS[1] is the index, ARGV[1] the current epoch timestamp
+// and the rest of ARGV the player IDs.  It returns 1 for each player touched
+// and 0 for each player left out, in the same order.
+var touchScript = redis.NewScript(1, `
+local touched = {}
+for i = 2, #ARGV do
+	if redis.call('ZSCORE', KEYS[1], ARGV[i]) then
+		redis.call('ZADD', KEYS[1], ARGV[1], ARGV[i])
+		touched[i - 1] = 1
+	else
+		touched[i - 1] = 0
+	end
+end
+return touched
+`)
+
+// TouchExisting updates the accessed time of many players in the
+// OM_METADATA.accessed index to the current epoch timestamp, in a single
+// round trip, leaving out players who aren't in the index.  It reports
+// whether each player was touched.
+func TouchExisting(ctx context.Context, rPool *redis.Pool, playerIDs []string) ([]bool, error) {
+	redisConn, err := rPool.GetContext(ctx)
 	defer redisConn.Close()
+	if err != nil {
+		return nil, err
+	}
 
-	_, err := redisConn.Do("ZADD", "OM_METADATA.accessed", time.Now().Unix(), playerID)
-	return err
+	args := redis.Args{"OM_METADATA.accessed", time.Now().Unix()}.AddFlat(playerIDs)
+	replies, err := redis.Ints(touchScript.Do(redisConn, args...))
+	if err != nil {
+		return nil, err
+	}
+	touched := make([]bool, len(playerIDs))
+	for i := range touched {
+		touched[i] = i < len(replies) && replies[i] == 1
+	}
+	return touched, nil
 }
 
 // Retrieve pulls the player indices from the Viper config
-func Retrieve(cfg *viper.Viper) (indices []string, err error) {
-
-	// In addition to the user-defined indices from the config file, Open Match
-	// forces the following indicies to exist for all players.  'created' is
-	// used to calculate how long a player has been waiting for a match,
-	// 'accessed' is used to determine when a player needs to be expired out of
-	// state storage.
-	indices = append(indices, []string{}...)
-
-	if cfg.IsSet("playerIndices") {
-		indices = append(indices, cfg.GetStringSlice("playerIndices")...)
-	} else {
-		err = errors.New("Failure to get list of indices")
+func Retrieve(cfg *viper.Viper) (indices []statestorage.Index, err error) {
+	return statestorage.Indices(cfg)
+}
+
+// RetrievePrevious attempts to handle an edge case when the user has removed an
+// index from the list of player indices but players still exist who are
+// indexed using the (now no longer used) index.  Indices retired through the
+// Admin API are tracked by the index registry and returned here until their
+// sorted sets are deleted; indices removed from the config of an API that
+// isn't using the registry should be put into the previousPlayerIndices
+// config parameter so that deleting players with previous indexes doesn't
+// result in a Redis memory leak.
+func RetrievePrevious(cfg *viper.Viper) []statestorage.Index {
+	return statestorage.PreviousIndices(cfg)
+}
+
+// RegistryKey is the Redis hash holding the player index registry; each
+// field is an attribute, and its value is the JSON-encoded PlayerIndex.
+const RegistryKey = "OM_INDICES"
+
+// RetrieveRegistry reads every entry in the player index registry.
+func RetrieveRegistry(ctx context.Context, rPool *redis.Pool) ([]*om_messages.PlayerIndex, error) {
+	redisConn, err := rPool.GetContext(ctx)
+	defer redisConn.Close()
+	if err != nil {
 		return nil, err
 	}
 
-	return
+	fields, err := redis.StringMap(redisConn.Do("HGETALL", RegistryKey))
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*om_messages.PlayerIndex, 0, len(fields))
+	for attribute, entryJSON := range fields {
+		entry := &om_messages.PlayerIndex{}
+		if err := jsonpb.UnmarshalString(entryJSON, entry); err != nil {
+			piLog.WithFields(log.Fields{"error": err.Error(), "attribute": attribute}).Error("Malformed index registry entry")
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
 }
 
-// RetrievePrevious attempts to handle an edge case when the user has removed an
-// index from the list of player indices but players still exist who are
-// indexed using the (now no longer used) index. The user should put the
-// index they are no longer using into this config parameter so that
-// deleting players with previous indexes doesn't result in a Redis memory
-// leak.  In a future version, Open Match should track previous indices
-// itself and handle this for the user.
-func RetrievePrevious(cfg *viper.Viper) []string {
-	if cfg.IsSet("previousPlayerIndices") {
-		return cfg.GetStringSlice("previousPlayerIndices")
-	}
-	return nil
+// UpdateRegistry reads the attribute's registry entry and writes the entry
+// returned by update in a transaction that WATCHes the registry, retrying
+// if the registry changes in between.  See
+// statestorage.Service.UpdateIndexRegistry.
+func UpdateRegistry(ctx context.Context, rPool *redis.Pool, attribute string, update func(entry *om_messages.PlayerIndex) (*om_messages.PlayerIndex, error)) (bool, error) {
+	redisConn, err := rPool.GetContext(ctx)
+	defer redisConn.Close()
+	if err != nil {
+		return false, err
+	}
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if _, err = redisConn.Do("WATCH", RegistryKey); err != nil {
+			return false, err
+		}
+		var current *om_messages.PlayerIndex
+		entryJSON, err := redis.String(redisConn.Do("HGET", RegistryKey, attribute))
+		switch {
+		case err == nil:
+			current = &om_messages.PlayerIndex{}
+			if err = jsonpb.UnmarshalString(entryJSON, current); err != nil {
+				redisConn.Do("UNWATCH")
+				return false, err
+			}
+		case err != redis.ErrNil:
+			redisConn.Do("UNWATCH")
+			return false, err
+		}
+
+		entry, err := update(current)
+		if err != nil || entry == nil {
+			redisConn.Do("UNWATCH")
+			return false, err
+		}
+		if entryJSON, err = (&jsonpb.Marshaler{}).MarshalToString(entry); err != nil {
+			redisConn.Do("UNWATCH")
+			return false, err
+		}
+
+		redisConn.Send("MULTI")
+		redisConn.Send("HSET", RegistryKey, attribute, entryJSON)
+		reply, err := redisConn.Do("EXEC")
+		if err != nil {
+			return false, err
+		}
+		if reply != nil {
+			return true, nil
+		}
+		// A nil reply means the registry changed after it was WATCHed.
+		piLog.WithFields(log.Fields{"attribute": attribute}).Debug("Index registry modified during update, retrying")
+	}
+	return false, errors.New("index registry was modified concurrently too many times during update")
+}
+
+// addScript adds an indexed player to more sorted sets.  KEYS[1] is the
+// player's hash and the rest of KEYS the sorted sets; ARGV[1] is the player
+// ID, ARGV[2] the player's IndexedField the new value was derived from,
+// ARGV[3] the new IndexedField, and the rest of ARGV the player's scores, in
+// the same order as the sorted sets.  Players who aren't indexed are left
+// out, and 0 is returned; if the field has changed since it was read,
+// nothing is done and -1 is returned.  Otherwise it returns 1.
+var addScript = redis.NewScript(-1, `
+local indexed = redis.call('HGET', KEYS[1], 'OM_INDEXED')
+if not indexed then
+	return 0
+end
+if indexed ~= ARGV[2] then
+	return -1
+end
+for i = 2, #KEYS do
+	redis.call('ZADD', KEYS[i], ARGV[i + 2], ARGV[1])
+end
+redis.call('HSET', KEYS[1], 'OM_INDEXED', ARGV[3])
+return 1
+`)
+
+// Add indexes the player under the given indices only, if the player is
+// still indexed; players who have been deindexed are left out.
+func Add(ctx context.Context, rPool *redis.Pool, player om_messages.Player, indices []statestorage.Index) error {
+	redisConn, err := rPool.GetContext(ctx)
+	defer redisConn.Close()
+	if err != nil {
+		return err
+	}
+
+	values := statestorage.IndexValues(&player, indices, time.Now())
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		indexed, err := redis.String(redisConn.Do("HGET", player.Id, IndexedField))
+		if err == redis.ErrNil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		keys, err := DecodeIndexed(indexed)
+		if err != nil {
+			return err
+		}
+
+		added := redis.Args{}
+		scores := redis.Args{}
+		for key, value := range values {
+			added = added.Add(key)
+			scores = scores.Add(value)
+			keys = append(keys, key)
+		}
+		updated, err := EncodeIndexed(dedupe(keys))
+		if err != nil {
+			return err
+		}
+		args := redis.Args{len(added) + 1, player.Id}.AddFlat(added).Add(player.Id, indexed, updated).AddFlat(scores)
+		result, err := redis.Int(addScript.Do(redisConn, args...))
+		if err != nil || result >= 0 {
+			return err
+		}
+	}
+	return errors.New("player was modified concurrently too many times during indexing")
 }
+
+// dedupe removes repeated keys.
+func dedupe(keys []string) []string {
+	seen := make(map[string]bool, len(keys))
+	unique := keys[:0]
+	for _, key := range keys {
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, key)
+		}
+	}
+	return unique
+}
+
+// Drop deletes all of the index's sorted sets.  The sorted sets of string
+// and tags indices are found with SCAN.
+func Drop(ctx context.Context, rPool *redis.Pool, index statestorage.Index) error {
+	redisConn, err := rPool.GetContext(ctx)
+	defer redisConn.Close()
+	if err != nil {
+		return err
+	}
+
+	if !index.ByValue() {
+		_, err = redisConn.Do("DEL", index.Attribute)
+		return err
+	}
+	pattern := globEscaper.Replace(index.Key("")) + "*"
+	cursor := 0
+	for {
+		reply, err := redis.Values(redisConn.Do("SCAN", cursor, "MATCH", pattern, "COUNT", 1000))
+		if err != nil {
+			return err
+		}
+		var keys []string
+		if _, err = redis.Scan(reply, &cursor, &keys); err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if _, err = redisConn.Do("DEL", redis.Args{}.AddFlat(keys)...); err != nil {
+				return err
+			}
+		}
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// globEscaper escapes the characters special to Redis glob-style patterns.
+var globEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
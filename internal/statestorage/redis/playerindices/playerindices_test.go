@@ -0,0 +1,29 @@
+package playerindices
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/rafaeljusto/redigomock"
+	"github.com/spf13/viper"
+)
+
+func TestDelete(t *testing.T) {
+	redisConn := redigomock.NewConn()
+	hmget := redisConn.Command("HMGET", "p1", IndexedField, "properties").
+		Expect([]interface{}{[]byte(`["mmr.rating","mode=ctf"]`), []byte(`{"mmr": {"rating": 1}}`)})
+	// The player is modified between the first read and the script, so
+	// the script does nothing the first time.
+	evalsha := redisConn.Command("EVALSHA", deindexScript.Hash(), 3, "p1", "mmr.rating", "mode=ctf", "p1", `["mmr.rating","mode=ctf"]`, `{"mmr": {"rating": 1}}`).
+		Expect(int64(0)).
+		Expect(int64(1))
+	pool := &redis.Pool{Dial: func() (redis.Conn, error) { return redisConn, nil }}
+
+	if err := Delete(context.Background(), pool, viper.New(), "p1"); err != nil {
+		t.Fatal(err)
+	}
+	if redisConn.Stats(hmget) != 2 || redisConn.Stats(evalsha) != 2 {
+		t.Errorf("HMGET called %v times and EVALSHA %v times, want 2 each", redisConn.Stats(hmget), redisConn.Stats(evalsha))
+	}
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2019 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redishelpers
+
+import (
+	"context"
+	"time"
+
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage/redis/ignorelist"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage/redis/redispb"
+	"github.com/gomodule/redigo/redis"
+	log "github.com/sirupsen/logrus"
+)
+
+// SubmittedKey is the key marking that the MMF results with the ID were
+// submitted, which makes SubmitProposal idempotent.  It holds the results'
+// digest, and expires with the results' match object, and is deleted with
+// it.
+func SubmittedKey(id string) string {
+	return "OM_SUBMITTED." + id
+}
+
+// submitScript records an MMF's results.  KEYS are the match object, its
+// submitted marker, the proposal queue, the counter, and the ignorelist, its
+// owners hash, the lease set and the leases sorted set as for the ignorelist
+// lease script.  ARGV[1] is the current epoch timestamp, ARGV[2] the results
+// ID, ARGV[3] the match object TTL, ARGV[4] '1' if the results are a proposal
+// rather than an error, ARGV[5] '1' to claim the players strictly, ARGV[6]
+// the lowest ignorelist score that still counts as a claim, ARGV[7] the
+// results' digest, and ARGV[8] the number of match object field and value
+// arguments that follow; the player IDs come last.  It returns 0 if the same
+// results were already submitted, -1 if different ones were, the players
+// claimed by other leases if there are any in strict mode, and 1 otherwise.
+var submitScript = redis.NewScript(8, `
+local submitted = redis.call('GET', KEYS[2])
+if submitted then
+	if submitted == ARGV[7] then
+		return 0
+	end
+	return -1
+end
+
+local last = 8 + tonumber(ARGV[8])
+if ARGV[4] == '1' and ARGV[5] == '1' then
+	local claimed = {}
+	local since = tonumber(ARGV[6])
+	for i = last + 1, #ARGV do
+		local owner = redis.call('HGET', KEYS[6], ARGV[i])
+		if owner and owner ~= ARGV[2] then
+			local score = redis.call('ZSCORE', KEYS[5], ARGV[i])
+			if score and tonumber(score) >= since then
+				claimed[#claimed + 1] = ARGV[i]
+			end
+		end
+	end
+	if #claimed > 0 then
+		return claimed
+	end
+end
+
+local ttl = tonumber(ARGV[3])
+if ttl > 0 then
+	redis.call('SET', KEYS[2], ARGV[7], 'EX', ttl)
+else
+	redis.call('SET', KEYS[2], ARGV[7])
+end
+
+for i = 9, last, 2 do
+	redis.call('HSET', KEYS[1], ARGV[i], ARGV[i + 1])
+end
+if ttl > 0 then
+	redis.call('EXPIRE', KEYS[1], ttl)
+end
+
+if ARGV[4] == '1' then
+	if #ARGV > last then
+		redis.call('ZADD', KEYS[8], ARGV[1], ARGV[2])
+	end
+	for i = last + 1, #ARGV do
+		redis.call('ZADD', KEYS[5], ARGV[1], ARGV[i])
+		redis.call('HSET', KEYS[6], ARGV[i], ARGV[2])
+		redis.call('SADD', KEYS[7], ARGV[i])
+	end
+	redis.call('SADD', KEYS[3], ARGV[2])
+end
+redis.call('DECR', KEYS[4])
+return 1
+`)
+
+// SubmitProposal writes the match object, leases the players to it on the
+// ignorelist, adds its ID to the queue and decrements the counter with a
+// single script.  Match objects with an error are only written and counted.
+// It returns false, and changes nothing, if the same results were already
+// submitted, statestorage.ErrResultsConflict if different results were
+// submitted with the same ID, or, in strict mode, a
+// *statestorage.ClaimError if any of the players was leased to another
+// proposal or match at or after the epoch timestamp 'since'.
+func SubmitProposal(ctx context.Context, pool *redis.Pool, mo *pb.MatchObject, ttl int, il string, queue string, counter string, playerIDs []string, strict bool, since int64) (bool, error) {
+	key, fields, err := redispb.HashFields(mo)
+	if err != nil {
+		return false, err
+	}
+	digest, err := statestorage.ResultsDigest(mo, playerIDs)
+	if err != nil {
+		return false, err
+	}
+
+	redisConn, err := pool.GetContext(ctx)
+	defer redisConn.Close()
+	if err != nil {
+		return false, err
+	}
+
+	proposal := "1"
+	if mo.Error != "" {
+		proposal = "0"
+	}
+	claim := "0"
+	if strict {
+		claim = "1"
+	}
+	rhLog.WithFields(log.Fields{
+		"key":        key,
+		"ignorelist": il,
+		"queue":      queue,
+		"numPlayers": len(playerIDs),
+		"strict":     strict,
+	}).Debug("submitting proposal")
+
+	args := redis.Args{
+		key, SubmittedKey(key), queue, counter,
+		il, ignorelist.OwnersKey(il), ignorelist.LeaseKey(il, key), ignorelist.LeasesKey(il),
+		time.Now().Unix(), key, ttl, proposal, claim, since, digest, len(fields),
+	}
+	args = append(args, fields...).AddFlat(playerIDs)
+	reply, err := submitScript.Do(redisConn, args...)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := reply.([]interface{}); ok {
+		claimed, err := redis.Strings(reply, nil)
+		if err != nil {
+			return false, err
+		}
+		return false, &statestorage.ClaimError{PlayerIDs: claimed}
+	}
+	created, err := redis.Int(reply, nil)
+	if created == -1 {
+		return false, statestorage.ErrResultsConflict
+	}
+	return created == 1, err
+}
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage/redis/keyspace"
 	"github.com/gomodule/redigo/redis"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -88,7 +89,11 @@ func ConnectionPool(cfg *viper.Viper) (*redis.Pool, error) {
 // The pattern for this function is from 'Go Concurrency Patterns', it is a function
 // that wraps a closure goroutine, and returns a channel.
 // reference: https://talks.golang.org/2012/concurrency.slide#25
-func Watcher(ctx context.Context, pool *redis.Pool, key string) <-chan string {
+//
+// Between queries it waits five seconds, or until the notifier reports the
+// key has been written, whichever is sooner.  n may be nil, in which case it
+// only polls.
+func Watcher(ctx context.Context, pool *redis.Pool, n *keyspace.Notifier, key string) <-chan string {
 	// Add the key as a field to all logs for the execution of this function.
 	rhLog = rhLog.WithFields(log.Fields{"key": key})
 	rhLog.Debug("Watching key in statestorage for changes")
@@ -96,6 +101,9 @@ func Watcher(ctx context.Context, pool *redis.Pool, key string) <-chan string {
 	watchChan := make(chan string)
 
 	go func() {
+		updates, unsubscribe := n.Subscribe(key)
+		defer unsubscribe()
+
 		// var declaration
 		var results string
 		var err = errors.New("haven't queried Redis yet")
@@ -110,7 +118,11 @@ func Watcher(ctx context.Context, pool *redis.Pool, key string) <-chan string {
 			default:
 				results, err = Retrieve(ctx, pool, key)
 				if err != nil {
-					time.Sleep(5 * time.Second) // TODO: exp bo + jitter
+					select {
+					case <-updates:
+					case <-time.After(5 * time.Second): // TODO: exp bo + jitter
+					case <-ctx.Done():
+					}
 				}
 			}
 		}
@@ -218,6 +230,48 @@ func RetrieveField(ctx context.Context, pool *redis.Pool, key string, field stri
 	return redis.String(redisConn.Do(cmd, key, field))
 }
 
+// RetrieveMultiFields is a concurrent-safe, context-aware redis HGET of the
+// input field of every input key, pipelined over one connection.  Keys that
+// don't have the field are left out of the results.
+func RetrieveMultiFields(ctx context.Context, pool *redis.Pool, keys []string, field string) (map[string]string, error) {
+	results := make(map[string]string, len(keys))
+	if len(keys) == 0 {
+		return results, nil
+	}
+
+	// Add the cmd & field to all logs for the execution of this function.
+	cmd := "HGET"
+	rfLog := rhLog.WithFields(log.Fields{"field": field, "query": cmd, "count": len(keys)})
+
+	// Get a connection to redis
+	redisConn, err := pool.GetContext(ctx)
+	defer redisConn.Close()
+
+	// Encountered an issue getting a connection from the pool.
+	if err != nil {
+		rfLog.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Error("state storage connection error")
+		return nil, err
+	}
+
+	// Run pipelined redis queries and collect the replies
+	rfLog.Debug("state storage operation")
+	for _, key := range keys {
+		redisConn.Send(cmd, key, field)
+	}
+	replies, err := redis.Values(redisConn.Do(""))
+	if err != nil {
+		return nil, err
+	}
+	for i, reply := range replies {
+		if value, err := redis.String(reply, nil); err == nil {
+			results[keys[i]] = value
+		}
+	}
+	return results, nil
+}
+
 // RetrieveAll is a concurrent-safe, context-aware redis HGETALL on the input key
 func RetrieveAll(ctx context.Context, pool *redis.Pool, key string) (map[string]string, error) {
 
@@ -310,12 +364,12 @@ func UpdateMultiFields(ctx context.Context, pool *redis.Pool, kv map[string]stri
 	return err
 }
 
-// Delete is a concurrent-safe, context-aware redis DEL on the input key
-func Delete(ctx context.Context, pool *redis.Pool, key string) error {
+// Delete is a concurrent-safe, context-aware redis DEL on the input keys
+func Delete(ctx context.Context, pool *redis.Pool, keys ...string) error {
 
 	// Add the key as a field to all logs for the execution of this function.
 	cmd := "DEL"
-	dLog := rhLog.WithFields(log.Fields{"key": key, "query": cmd})
+	dLog := rhLog.WithFields(log.Fields{"key": keys, "query": cmd})
 	dLog.Debug("state storage operation")
 
 	// Get a connection to redis
@@ -331,7 +385,7 @@ func Delete(ctx context.Context, pool *redis.Pool, key string) error {
 	}
 
 	// Run redis query and return
-	_, err = redisConn.Do(cmd, key)
+	_, err = redisConn.Do(cmd, redis.Args{}.AddFlat(keys)...)
 	return err
 }
 
@@ -365,6 +419,75 @@ func DeleteMultiFields(ctx context.Context, pool *redis.Pool, keys []string, fie
 	return err
 }
 
+// Transactions pipelines 'n' MULTI/EXEC transactions over one connection, so
+// they all complete in a single round trip.  'queue' sends the commands for
+// the i'th transaction.  The returned slice holds the error queueing or
+// executing each transaction, or nil if it succeeded.
+func Transactions(ctx context.Context, pool *redis.Pool, n int, queue func(redisConn redis.Conn, i int) error) []error {
+	errs := make([]error, n)
+	if n == 0 {
+		return errs
+	}
+	tLog := rhLog.WithFields(log.Fields{"transactions": n})
+
+	// Get a connection to redis
+	redisConn, err := pool.GetContext(ctx)
+	defer redisConn.Close()
+
+	// Encountered an issue getting a connection from the pool.
+	if err != nil {
+		tLog.WithFields(log.Fields{
+			"error": err.Error(),
+		}).Error("state storage connection error")
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+
+	// Queue every transaction, remembering where its EXEC reply will be.
+	p := &pipeline{Conn: redisConn}
+	execs := make([]int, n)
+	for i := range execs {
+		execs[i] = -1
+		p.Send("MULTI")
+		if errs[i] = queue(p, i); errs[i] != nil {
+			p.Send("DISCARD")
+			continue
+		}
+		p.Send("EXEC")
+		execs[i] = p.sent - 1
+	}
+
+	// Flush and read all the replies.
+	tLog.Debug("state storage operation")
+	replies, err := redis.Values(p.Do(""))
+	for i, exec := range execs {
+		switch {
+		case exec < 0:
+		case err != nil:
+			errs[i] = err
+		default:
+			if rErr, ok := replies[exec].(redis.Error); ok {
+				errs[i] = rErr
+			}
+		}
+	}
+	return errs
+}
+
+// pipeline counts the commands sent on a connection, so the reply to each
+// command can be found when they're all received at once.
+type pipeline struct {
+	redis.Conn
+	sent int
+}
+
+func (p *pipeline) Send(cmd string, args ...interface{}) error {
+	p.sent++
+	return p.Conn.Send(cmd, args...)
+}
+
 // Count is a concurrent-safe, context-aware redis SCARD on the input key
 func Count(ctx context.Context, pool *redis.Pool, key string) (int, error) {
 	// Add the key as a field to all logs for the execution of this function.
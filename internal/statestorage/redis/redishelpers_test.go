@@ -0,0 +1,319 @@
+package redishelpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage/redis/ignorelist"
+	"github.com/alicebob/miniredis"
+	"github.com/gomodule/redigo/redis"
+	"github.com/rafaeljusto/redigomock"
+	"github.com/spf13/viper"
+)
+
+func TestTransactions(t *testing.T) {
+	redisConn := redigomock.NewConn()
+	redisConn.Command("MULTI").Expect("OK")
+	redisConn.Command("DISCARD").Expect("OK")
+	redisConn.GenericCommand("HSET").Expect("QUEUED")
+	exec := redisConn.Command("EXEC").
+		Expect([]interface{}{int64(1)}).
+		Expect(redis.Error("EXECABORT Transaction discarded"))
+	pool := &redis.Pool{Dial: func() (redis.Conn, error) { return redisConn, nil }}
+
+	keys := []string{"a", "", "c"}
+	errs := Transactions(context.Background(), pool, len(keys), func(redisConn redis.Conn, i int) error {
+		if keys[i] == "" {
+			return errors.New("no key")
+		}
+		return redisConn.Send("HSET", keys[i], "field", "value")
+	})
+
+	if len(errs) != 3 || errs[0] != nil || errs[1] == nil || errs[2] == nil {
+		t.Errorf("got %v, want [nil, error, error]", errs)
+	}
+	if redisConn.Stats(exec) != 2 {
+		t.Errorf("EXEC called %v times, want 2", redisConn.Stats(exec))
+	}
+}
+
+func TestRetrieveMultiFields(t *testing.T) {
+	redisConn := redigomock.NewConn()
+	redisConn.Command("HGET", "a", "properties").Expect([]byte(`{"x": 1}`))
+	redisConn.Command("HGET", "b", "properties").Expect(nil)
+	pool := &redis.Pool{Dial: func() (redis.Conn, error) { return redisConn, nil }}
+
+	got, err := RetrieveMultiFields(context.Background(), pool, []string{"a", "b"}, "properties")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got["a"] != `{"x": 1}` {
+		t.Errorf("got %v, want map[a:{\"x\": 1}]", got)
+	}
+}
+
+func TestSubmitProposal(t *testing.T) {
+	ctx := context.Background()
+	mr, pool := newMiniredis(t, nil)
+	defer mr.Close()
+	mr.Set("concurrentMMFs", "2")
+
+	mo := &pb.MatchObject{Id: "proposal.1.a", Properties: `{"mode": "ctf"}`}
+	for i, want := range []bool{true, false} {
+		created, err := SubmitProposal(ctx, pool, mo, 0, "proposed", "proposalq", "concurrentMMFs", []string{"p1", "p2"}, true, 0)
+		if err != nil || created != want {
+			t.Errorf("submission %v: got (%v, %v), want %v", i, created, err, want)
+		}
+	}
+	if got := mr.HGet(mo.Id, "properties"); got != mo.Properties {
+		t.Errorf("properties: got %v, want %v", got, mo.Properties)
+	}
+	if !mr.Exists(SubmittedKey(mo.Id)) {
+		t.Error("got no submitted marker")
+	}
+	if got, _ := mr.ZMembers("proposed"); !reflect.DeepEqual(got, []string{"p1", "p2"}) {
+		t.Errorf("ignorelist: got %v, want [p1 p2]", got)
+	}
+	for _, id := range []string{"p1", "p2"} {
+		if got := mr.HGet(ignorelist.OwnersKey("proposed"), id); got != mo.Id {
+			t.Errorf("%v: got owner %v, want %v", id, got, mo.Id)
+		}
+	}
+	if got, _ := mr.Members(ignorelist.LeaseKey("proposed", mo.Id)); !reflect.DeepEqual(got, []string{"p1", "p2"}) {
+		t.Errorf("lease: got %v, want [p1 p2]", got)
+	}
+	if got, _ := mr.ZMembers(ignorelist.LeasesKey("proposed")); !reflect.DeepEqual(got, []string{mo.Id}) {
+		t.Errorf("leases: got %v, want [%v]", got, mo.Id)
+	}
+	if got, _ := mr.Members("proposalq"); !reflect.DeepEqual(got, []string{mo.Id}) {
+		t.Errorf("queue: got %v, want [%v]", got, mo.Id)
+	}
+	// Only the first submission is counted.
+	if got, _ := mr.Get("concurrentMMFs"); got != "1" {
+		t.Errorf("counter: got %v, want 1", got)
+	}
+
+	// Different results with the same ID are refused.
+	other := &pb.MatchObject{Id: mo.Id, Properties: `{"mode": "koth"}`}
+	if _, err := SubmitProposal(ctx, pool, other, 0, "proposed", "proposalq", "concurrentMMFs", []string{"p1", "p2"}, true, 0); err != statestorage.ErrResultsConflict {
+		t.Errorf("other results: got %v, want ErrResultsConflict", err)
+	}
+	if got := mr.HGet(mo.Id, "properties"); got != mo.Properties {
+		t.Errorf("properties: got %v, want %v kept", got, mo.Properties)
+	}
+
+	// In strict mode, a proposal with players leased to others is refused
+	// and changes nothing.
+	rival := &pb.MatchObject{Id: "proposal.1.b"}
+	_, err := SubmitProposal(ctx, pool, rival, 0, "proposed", "proposalq", "concurrentMMFs", []string{"p1", "p3"}, true, 0)
+	if claimErr, ok := err.(*statestorage.ClaimError); !ok || !reflect.DeepEqual(claimErr.PlayerIDs, []string{"p1"}) {
+		t.Errorf("rival: got %v, want p1 claimed", err)
+	}
+	if mr.Exists(rival.Id) || mr.Exists(SubmittedKey(rival.Id)) {
+		t.Error("got rival written, want nothing changed")
+	}
+	if got, _ := mr.ZMembers("proposed"); !reflect.DeepEqual(got, []string{"p1", "p2"}) {
+		t.Errorf("ignorelist: got %v, want [p1 p2]", got)
+	}
+	if got, _ := mr.Get("concurrentMMFs"); got != "1" {
+		t.Errorf("counter: got %v, want 1", got)
+	}
+}
+
+func TestSubmitProposalSince(t *testing.T) {
+	ctx := context.Background()
+	mr, pool := newMiniredis(t, nil)
+	defer mr.Close()
+	mr.Set("concurrentMMFs", "2")
+	// p1 was leased to another match at 100.
+	mr.ZAdd("proposed", 100, "p1")
+	mr.HSet(ignorelist.OwnersKey("proposed"), "p1", "other")
+
+	// A claim made at or after 'since' still counts.
+	inWindow := &pb.MatchObject{Id: "proposal.1.a"}
+	_, err := SubmitProposal(ctx, pool, inWindow, 0, "proposed", "proposalq", "concurrentMMFs", []string{"p1"}, true, 100)
+	if claimErr, ok := err.(*statestorage.ClaimError); !ok || !reflect.DeepEqual(claimErr.PlayerIDs, []string{"p1"}) {
+		t.Errorf("since 100: got %v, want p1 claimed", err)
+	}
+	if mr.Exists(inWindow.Id) {
+		t.Error("got refused proposal written")
+	}
+
+	// An older one has lapsed, and the player can be proposed again.
+	expired := &pb.MatchObject{Id: "proposal.1.b"}
+	if created, err := SubmitProposal(ctx, pool, expired, 0, "proposed", "proposalq", "concurrentMMFs", []string{"p1"}, true, 101); !created || err != nil {
+		t.Errorf("since 101: got (%v, %v), want it created", created, err)
+	}
+	if got := mr.HGet(ignorelist.OwnersKey("proposed"), "p1"); got != expired.Id {
+		t.Errorf("got owner %v, want %v", got, expired.Id)
+	}
+	if got, _ := mr.Members("proposalq"); !reflect.DeepEqual(got, []string{expired.Id}) {
+		t.Errorf("queue: got %v, want [%v]", got, expired.Id)
+	}
+}
+
+// scriptConn runs before, if set, ahead of every script it sends to Redis,
+// so tests can make changes that race with the script.
+type scriptConn struct {
+	redis.Conn
+	before func()
+}
+
+func (c *scriptConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	if cmd == "EVALSHA" && c.before != nil {
+		c.before()
+	}
+	return c.Conn.Do(cmd, args...)
+}
+
+// newMiniredis starts an in-process Redis server, with Lua scripting, and
+// returns it with a pool of connections to it that run before ahead of
+// every script.
+func newMiniredis(t *testing.T, before func()) (*miniredis.Miniredis, *redis.Pool) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := &redis.Pool{Dial: func() (redis.Conn, error) {
+		c, err := redis.Dial("tcp", mr.Addr())
+		return &scriptConn{Conn: c, before: before}, err
+	}}
+	return mr, pool
+}
+
+func TestUpdatePlayer(t *testing.T) {
+	ctx := context.Background()
+	// conflicts is how many of the update scripts have the player's
+	// properties changed under them.
+	conflicts := 0
+	var mr *miniredis.Miniredis
+	mr, pool := newMiniredis(t, func() {
+		if conflicts > 0 {
+			conflicts--
+			mr.HSet("p1", "properties", fmt.Sprintf(`{"mmr": {"rating": %v}, "mode": {"ctf": 1}}`, 10+conflicts))
+		}
+	})
+	defer mr.Close()
+	cfg := viper.New()
+	cfg.Set("playerIndices", []string{"mmr.rating", "mode.ctf"})
+	rs := NewWithPool(cfg, pool)
+	rs.CreatePlayer(ctx, &pb.Player{Id: "p1", Properties: `{"mmr": {"rating": 1}, "mode": {"ctf": 1}}`})
+	rs.CreatePlayer(ctx, &pb.Player{Id: "p2", Properties: `{"mmr": {"rating": 1}}`})
+
+	// A change made between reading the player and the script makes the
+	// script retry, with the changes computed from the new properties.
+	conflicts = 1
+	if err := rs.UpdatePlayer(ctx, &pb.Player{Id: "p1", Properties: `{"mmr": {"rating": 2}}`}); err != nil {
+		t.Fatal(err)
+	}
+	if got := mr.HGet("p1", "properties"); got != `{"mmr": {"rating": 2}}` {
+		t.Errorf("properties: got %v, want the update", got)
+	}
+	if score, err := mr.ZScore("mmr.rating", "p1"); err != nil || score != 2 {
+		t.Errorf("mmr.rating: got (%v, %v), want 2", score, err)
+	}
+	if members, _ := mr.ZMembers("mode.ctf"); len(members) != 0 {
+		t.Errorf("mode.ctf: got %v, want p1 removed", members)
+	}
+	if got := mr.HGet("p1", "OM_INDEXED"); got != `["mmr.rating"]` {
+		t.Errorf("OM_INDEXED: got %v, want [\"mmr.rating\"]", got)
+	}
+
+	// Conflicting on every attempt fails without making the update.
+	conflicts = maxUpdateAttempts
+	if err := rs.UpdatePlayer(ctx, &pb.Player{Id: "p1", Properties: `{"mmr": {"rating": 3}}`}); err == nil {
+		t.Error("got nil, want an error after too many conflicts")
+	}
+	if score, _ := mr.ZScore("mmr.rating", "p1"); score != 2 {
+		t.Errorf("mmr.rating after conflicts: got %v, want 2", score)
+	}
+
+	// Deindexed players only have their properties replaced.
+	if err := rs.DeindexPlayer(ctx, "p2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.UpdatePlayer(ctx, &pb.Player{Id: "p2", Properties: `{"mmr": {"rating": 4}}`}); err != nil {
+		t.Fatal(err)
+	}
+	if members, _ := mr.ZMembers("mmr.rating"); len(members) != 1 {
+		t.Errorf("mmr.rating: got %v, want p2 left out", members)
+	}
+
+	if err := rs.UpdatePlayer(ctx, &pb.Player{Id: "missing"}); err != statestorage.ErrNotFound {
+		t.Errorf("missing player: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestDeindexPlayer(t *testing.T) {
+	ctx := context.Background()
+	// conflict changes p1 under the next script.
+	conflict := false
+	var mr *miniredis.Miniredis
+	mr, pool := newMiniredis(t, func() {
+		if conflict {
+			conflict = false
+			mr.HSet("p1", "properties", `{"mmr": {"rating": 1}, "mode": "ctf", "tags": ["a", "b"], "x": 1}`)
+		}
+	})
+	defer mr.Close()
+	cfg := viper.New()
+	cfg.Set("playerIndices", []string{"mmr.rating", "mode:string", "tags:tags"})
+	rs := NewWithPool(cfg, pool)
+	rs.CreatePlayer(ctx, &pb.Player{Id: "p1", Properties: `{"mmr": {"rating": 1}, "mode": "ctf", "tags": ["a", "b"]}`})
+	rs.CreatePlayer(ctx, &pb.Player{Id: "p2", Properties: `{"mode": "ctf"}`})
+
+	// The sorted sets recorded in OM_INDEXED are the ones the player is
+	// removed from, even once the indices are no longer configured.
+	cfg.Set("playerIndices", []string{"mmr.rating"})
+	conflict = true
+	if err := rs.DeindexPlayer(ctx, "p1"); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"mmr.rating", "mode=ctf", "tags=a", "tags=b"} {
+		if members, _ := mr.ZMembers(key); len(members) > 1 || (len(members) == 1 && members[0] == "p1") {
+			t.Errorf("%v: got %v, want p1 removed", key, members)
+		}
+	}
+	if mr.HGet("p1", "OM_INDEXED") != "" {
+		t.Errorf("OM_INDEXED: got %v, want it deleted", mr.HGet("p1", "OM_INDEXED"))
+	}
+	if members, _ := mr.ZMembers("mode=ctf"); len(members) != 1 || members[0] != "p2" {
+		t.Errorf("mode=ctf: got %v, want [p2]", members)
+	}
+
+	errs := rs.DeindexPlayers(ctx, []string{"p2", "missing"})
+	if errs[0] != nil || errs[1] != nil {
+		t.Errorf("DeindexPlayers: got %v, want no errors", errs)
+	}
+	if mr.Exists("mode=ctf") {
+		t.Error("mode=ctf: got p2 kept, want it removed")
+	}
+}
+
+func TestTransitionPlayers(t *testing.T) {
+	ctx := context.Background()
+	mr, pool := newMiniredis(t, nil)
+	defer mr.Close()
+	rs := NewWithPool(viper.New(), pool)
+	mr.HSet("queued", "status", "QUEUED")
+	mr.HSet("assigned", "status", "ASSIGNED")
+	mr.HSet("unset", "properties", "{}")
+
+	changed, err := rs.TransitionPlayers(ctx, "PROPOSED", []string{"", "QUEUED"}, []string{"queued", "assigned", "unset", "missing"})
+	if err != nil || !reflect.DeepEqual(changed, []string{"queued", "unset"}) {
+		t.Errorf("got (%v, %v), want [queued unset]", changed, err)
+	}
+	for id, want := range map[string]string{"queued": "PROPOSED", "assigned": "ASSIGNED", "unset": "PROPOSED"} {
+		if got := mr.HGet(id, "status"); got != want {
+			t.Errorf("%v: got status %v, want %v", id, got, want)
+		}
+	}
+	if mr.Exists("missing") {
+		t.Error("got missing player created, want it left out")
+	}
+}
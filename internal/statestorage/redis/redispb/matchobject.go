@@ -29,6 +29,8 @@ import (
 	"time"
 
 	om_messages "github.com/GoogleCloudPlatform/open-match/internal/pb"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage/redis/keyspace"
 	"github.com/cenkalti/backoff"
 	"github.com/gogo/protobuf/jsonpb"
 	"github.com/gomodule/redigo/redis"
@@ -69,8 +71,11 @@ func UnmarshalFromRedis(ctx context.Context, pool *redis.Pool, pb *om_messages.M
 		"key":       key,
 	})
 	pbMap, err := redis.StringMap(redisConn.Do(cmd, key))
+	if err != nil {
+		return err
+	}
 	if len(pbMap) == 0 {
-		return errors.New("matchobject key does not exist")
+		return statestorage.ErrNotFound
 	}
 
 	// Put values from redis into the MatchObject message
@@ -110,8 +115,12 @@ func UnmarshalFromRedis(ctx context.Context, pool *redis.Pool, pb *om_messages.M
 // that wraps a closure goroutine, and returns a channel.
 // reference: https://talks.golang.org/2012/concurrency.slide#25
 //
+// Between queries it waits for the backoff interval, or until the notifier
+// reports the key has been written, whichever is sooner.  n may be nil, in
+// which case it only polls.
+//
 // NOTE: runs until cancelled, timed out or result is found in Redis.
-func Watcher(bo backoff.BackOffContext, pool *redis.Pool, pb om_messages.MatchObject) <-chan om_messages.MatchObject {
+func Watcher(bo backoff.BackOffContext, pool *redis.Pool, n *keyspace.Notifier, pb om_messages.MatchObject) <-chan om_messages.MatchObject {
 
 	watchChan := make(chan om_messages.MatchObject)
 	results := om_messages.MatchObject{Id: pb.Id}
@@ -119,6 +128,9 @@ func Watcher(bo backoff.BackOffContext, pool *redis.Pool, pb om_messages.MatchOb
 	go func() {
 		defer close(watchChan)
 
+		updates, unsubscribe := n.Subscribe(pb.Id)
+		defer unsubscribe()
+
 		// var declaration
 		var err = errors.New("haven't queried Redis yet")
 
@@ -135,7 +147,12 @@ func Watcher(bo backoff.BackOffContext, pool *redis.Pool, pb om_messages.MatchOb
 
 			if d := bo.NextBackOff(); d != backoff.Stop {
 				moLog.Debug("No new results, backing off")
-				time.Sleep(d)
+				select {
+				case <-updates:
+				case <-time.After(d):
+				case <-bo.Context().Done():
+					return
+				}
 			} else {
 				moLog.Debug("No new results after all backoff attempts")
 				return
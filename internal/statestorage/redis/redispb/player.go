@@ -24,11 +24,12 @@ package redispb
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	om_messages "github.com/GoogleCloudPlatform/open-match/internal/pb"
-	"github.com/GoogleCloudPlatform/open-match/internal/statestorage/redis/playerindices"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage/redis/keyspace"
 	"github.com/cenkalti/backoff"
 	"github.com/gogo/protobuf/jsonpb"
 	"github.com/gomodule/redigo/redis"
@@ -44,7 +45,9 @@ var (
 	pLog = log.WithFields(pLogFields)
 )
 
-// UnmarshalPlayerFromRedis unmarshals a Player from a redis hash.
+// UnmarshalPlayerFromRedis unmarshals a Player from a redis hash.  It
+// returns redis.ErrNil, leaving the player unchanged, if the hash doesn't
+// exist.
 // This can probably be deprecated if we work on getting the above generic enough.
 // The problem is that protobuf message reflection is pretty messy.
 func UnmarshalPlayerFromRedis(ctx context.Context, pool *redis.Pool, player *om_messages.Player) error {
@@ -71,6 +74,9 @@ func UnmarshalPlayerFromRedis(ctx context.Context, pool *redis.Pool, player *om_
 
 	// Run redis command
 	playerMap, err := redis.StringMap(redisConn.Do(cmd, key))
+	if err == nil && len(playerMap) == 0 {
+		return redis.ErrNil
+	}
 
 	// Put values from redis into the Player message
 	player.Properties = playerMap["properties"]
@@ -79,6 +85,14 @@ func UnmarshalPlayerFromRedis(ctx context.Context, pool *redis.Pool, player *om_
 	player.Status = playerMap["status"]
 	player.Error = playerMap["error"]
 
+	// Parties store their member IDs as a JSON array.
+	if m := playerMap["members"]; m != "" {
+		if err := json.Unmarshal([]byte(m), &player.Members); err != nil {
+			resultLog.Error("failure on members")
+			resultLog.Error(m)
+		}
+	}
+
 	// TODO: Room for improvement here.
 	if a := playerMap["attributes"]; a != "" {
 		attrsJSON := fmt.Sprintf("{\"attributes\": %v}", a)
@@ -102,10 +116,14 @@ func UnmarshalPlayerFromRedis(ctx context.Context, pool *redis.Pool, player *om_
 // that wraps a closure goroutine, and returns a channel.
 // reference: https://talks.golang.org/2012/concurrency.slide#25
 //
+// Between queries it waits for the backoff interval, or until the notifier
+// reports the player's hash has changed, whichever is sooner.  n may be nil,
+// in which case it only polls.
+//
 // NOTE: this function will never stop querying Redis during normal operation! You need to
 //  disconnect the client from the frontend API (which closes the context) once
 //  you've received the results you were waiting for to stop doing work!
-func PlayerWatcher(bo backoff.BackOffContext, pool *redis.Pool, pb om_messages.Player) <-chan om_messages.Player {
+func PlayerWatcher(bo backoff.BackOffContext, pool *redis.Pool, n *keyspace.Notifier, pb om_messages.Player) <-chan om_messages.Player {
 
 	pwLog := pLog.WithFields(log.Fields{"playerId": pb.Id})
 
@@ -115,23 +133,19 @@ func PlayerWatcher(bo backoff.BackOffContext, pool *redis.Pool, pb om_messages.P
 	go func() {
 		defer close(watchChan)
 
+		updates, unsubscribe := n.Subscribe(pb.Id)
+		defer unsubscribe()
+
 		// var declaration
 		var prevResults = ""
 
 		// Loop, querying redis until this key has a value or the Redis query fails
 		for {
-			// Update the player's 'accessed' timestamp to denote they haven't disappeared
-			err := playerindices.Touch(bo.Context(), pool, pb.Id)
-			if err != nil {
-				// Not fatal, but this error should be addressed.  This could
-				// cause the player to expire while still actively connected!
-				pwLog.WithFields(log.Fields{"error": err.Error()}).Error("Unable to update accessed metadata timestamp")
-			}
-
 			// Get player from redis.
 			results := om_messages.Player{Id: pb.Id}
-			err = UnmarshalPlayerFromRedis(bo.Context(), pool, &results)
-			if err != nil {
+			err := UnmarshalPlayerFromRedis(bo.Context(), pool, &results)
+			// A player who doesn't exist (yet) has no results to send.
+			if err != nil && err != redis.ErrNil {
 				// Return error and quit.
 				pwLog.Debug("State storage error:", err.Error())
 				results.Error = err.Error()
@@ -168,7 +182,13 @@ func PlayerWatcher(bo backoff.BackOffContext, pool *redis.Pool, pb om_messages.P
 			}
 
 			if d := bo.NextBackOff(); d != backoff.Stop {
-				time.Sleep(d)
+				select {
+				case <-updates:
+					pwLog.Debug("state storage watched player record notification")
+				case <-time.After(d):
+				case <-bo.Context().Done():
+					return
+				}
 			} else {
 				return
 			}
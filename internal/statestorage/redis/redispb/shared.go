@@ -48,6 +48,70 @@ var (
 // The protobuf message in question must have an 'id' field.
 // If a positive integer TTL is provided, it will also be set.
 func MarshalToRedis(ctx context.Context, pool *redis.Pool, pb proto.Message, ttl int) error {
+	// Get the Redis connection.
+	redisConn, err := pool.GetContext(context.Background())
+	defer redisConn.Close()
+	if err != nil {
+		sLog.WithFields(log.Fields{
+			"error":     err.Error(),
+			"component": "statestorage",
+		}).Error("failed to connect to redis")
+		return err
+	}
+	redisConn.Send("MULTI")
+	if err = SendMarshalToRedis(redisConn, pb, ttl); err != nil {
+		redisConn.Send("DISCARD")
+		return err
+	}
+	_, err = redisConn.Do("EXEC")
+	return err
+}
+
+// SendMarshalToRedis queues the commands to write a protobuf message to a
+// redis hash (and set its TTL) on the connection without executing them, so
+// they can be pipelined with other commands.  The caller should wrap them
+// in a MULTI/EXEC transaction.
+func SendMarshalToRedis(redisConn redis.Conn, pb proto.Message, ttl int) error {
+	key, fields, err := HashFields(pb)
+	if err != nil {
+		return err
+	}
+
+	// Prepare redis command.
+	cmd := "HSET"
+	resultLog := sLog.WithFields(log.Fields{
+		"key": key,
+		"cmd": cmd,
+	})
+
+	for i := 0; i < len(fields); i += 2 {
+		redisConn.Send(cmd, key, fields[i], fields[i+1])
+		resultLog.WithFields(log.Fields{
+			"component": "statestorage",
+			"field":     fields[i],
+			"value":     fields[i+1],
+		}).Info("State storage operation")
+	}
+	if ttl > 0 {
+		redisConn.Send("EXPIRE", key, ttl)
+		resultLog.WithFields(log.Fields{
+			"component": "statestorage",
+			"ttl":       ttl,
+		}).Info("State storage expiration set")
+	} else {
+		resultLog.WithFields(log.Fields{
+			"component": "statestorage",
+			"ttl":       ttl,
+		}).Debug("State storage expiration not set")
+	}
+
+	return nil
+}
+
+// HashFields returns the redis key of a protobuf message and the field and
+// value pairs of the hash it is written to.  The protobuf message in question
+// must have an 'id' field, which is the key and isn't written to the hash.
+func HashFields(pb proto.Message) (string, redis.Args, error) {
 
 	// We want to serialize to redis as JSON, not the typical protobuf string
 	// serializer, so start by marshalling to json.
@@ -59,7 +123,7 @@ func MarshalToRedis(ctx context.Context, pool *redis.Pool, pb proto.Message, ttl
 			"component": "statestorage",
 			"protobuf":  pb,
 		}).Error("failure marshaling protobuf message to JSON")
-		return err
+		return "", nil, err
 	}
 
 	// Get redis key
@@ -72,75 +136,19 @@ func MarshalToRedis(ctx context.Context, pool *redis.Pool, pb proto.Message, ttl
 			"error":     err.Error(),
 			"component": "statestorage",
 		}).Error("failed to retrieve from redis")
-		return err
-	}
-	key := keyResult.String()
-
-	// Prepare redis command.
-	cmd := "HSET"
-	resultLog := sLog.WithFields(log.Fields{
-		"key": key,
-		"cmd": cmd,
-	})
-
-	// Get the Redis connection.
-	redisConn, err := pool.GetContext(context.Background())
-	defer redisConn.Close()
-	if err != nil {
-		sLog.WithFields(log.Fields{
-			"error":     err.Error(),
-			"component": "statestorage",
-		}).Error("failed to connect to redis")
-		return err
+		return "", nil, err
 	}
-	redisConn.Send("MULTI")
 
-	// Write all non-id fields from the protobuf message to state storage.
 	// Use reflection to get the field names from the protobuf message.
+	fields := redis.Args{}
 	pbInfo := reflect.ValueOf(pb).Elem()
 	for i := 0; i < pbInfo.NumField(); i++ {
 		// TODO: change this to use the json name field from the struct tags
 		//  something like parseTag() in src/encoding/json/tags.go
-		//field := strings.ToLower(pbInfo.Type().Field(i).Tag.Get("json"))
 		field := strings.ToLower(pbInfo.Type().Field(i).Name)
-		value := ""
-		//value, err = strconv.Unquote(gjson.Get(jsonMsg, field).String())
-		value = gjson.Get(jsonMsg, field).String()
-		if err != nil {
-			resultLog.Error("Issue with Unquoting string", err)
-		}
 		if field != "id" {
-			// This isn't the ID field, so write it to the redis hash.
-			redisConn.Send(cmd, key, field, value)
-			if err != nil {
-				resultLog.WithFields(log.Fields{
-					"error":     err.Error(),
-					"component": "statestorage",
-					"field":     field,
-				}).Error("State storage error")
-				return err
-			}
-			resultLog.WithFields(log.Fields{
-				"component": "statestorage",
-				"field":     field,
-				"value":     value,
-			}).Info("State storage operation")
-
+			fields = append(fields, field, gjson.Get(jsonMsg, field).String())
 		}
 	}
-	if ttl > 0 {
-		redisConn.Send("EXPIRE", key, ttl)
-		resultLog.WithFields(log.Fields{
-			"component": "statestorage",
-			"ttl":       ttl,
-		}).Info("State storage expiration set")
-	} else {
-		resultLog.WithFields(log.Fields{
-			"component": "statestorage",
-			"ttl":       ttl,
-		}).Debug("State storage expiration not set")
-	}
-
-	_, err = redisConn.Do("EXEC")
-	return err
+	return keyResult.String(), fields, nil
 }
@@ -0,0 +1,693 @@
+/*
+Copyright 2019 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redishelpers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage/redis/ignorelist"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage/redis/keyspace"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage/redis/playerindices"
+	"github.com/GoogleCloudPlatform/open-match/internal/statestorage/redis/redispb"
+	"github.com/cenkalti/backoff"
+	"github.com/gomodule/redigo/redis"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// RedisStateStorage is the Redis implementation of statestorage.Service.  It
+// is a thin wrapper around the helpers in this package and the redispb,
+// playerindices and ignorelist packages.
+type RedisStateStorage struct {
+	cfg      *viper.Viper
+	pool     *redis.Pool
+	notifier *keyspace.Notifier
+}
+
+// Compile-time check that RedisStateStorage satisfies the interface.
+var _ statestorage.Service = (*RedisStateStorage)(nil)
+
+// New connects to the Redis instance in the config and returns a
+// statestorage.Service backed by it.
+func New(cfg *viper.Viper) (*RedisStateStorage, error) {
+	pool, err := ConnectionPool(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithPool(cfg, pool), nil
+}
+
+// NewWithPool returns a statestorage.Service using an existing Redis
+// connection pool.  If redis.notifications.enabled is set, watchers are woken
+// by Redis keyspace notifications as well as polling.
+func NewWithPool(cfg *viper.Viper, pool *redis.Pool) *RedisStateStorage {
+	return &RedisStateStorage{cfg: cfg, pool: pool, notifier: keyspace.New(cfg, pool)}
+}
+
+// Pool returns the underlying Redis connection pool.
+func (rs *RedisStateStorage) Pool() *redis.Pool {
+	return rs.pool
+}
+
+// Close stops receiving keyspace notifications and closes the Redis
+// connection pool.
+func (rs *RedisStateStorage) Close() error {
+	rs.notifier.Close()
+	return rs.pool.Close()
+}
+
+// CreatePlayer writes the player to a Redis hash and indexes it.
+func (rs *RedisStateStorage) CreatePlayer(ctx context.Context, player *pb.Player) error {
+	err := redispb.MarshalToRedis(ctx, rs.pool, player, rs.cfg.GetInt("redis.expirations.player"))
+	if err != nil {
+		return err
+	}
+	return playerindices.Create(ctx, rs.pool, rs.cfg, *player)
+}
+
+// CreatePlayers writes and indexes the players, pipelining one transaction
+// per player over a single connection.
+func (rs *RedisStateStorage) CreatePlayers(ctx context.Context, players []*pb.Player) []error {
+	ttl := rs.cfg.GetInt("redis.expirations.player")
+	return Transactions(ctx, rs.pool, len(players), func(redisConn redis.Conn, i int) error {
+		if err := redispb.SendMarshalToRedis(redisConn, players[i], ttl); err != nil {
+			return err
+		}
+		return playerindices.SendCreate(redisConn, rs.cfg, *players[i])
+	})
+}
+
+// maxUpdateAttempts is how many times UpdatePlayer retries its script
+// when the player is modified concurrently.
+const maxUpdateAttempts = 5
+
+// updateScript replaces a player's properties and updates their indices if
+// the player is indexed.  KEYS[1] is the player's hash, KEYS[2] the accessed
+// metadata index, followed by the sorted sets to remove the player from and
+// then the sorted sets to add them to.  ARGV[1] is the player ID, ARGV[2]
+// and ARGV[3] the properties and IndexedField the changes were computed from
+// (empty if unset), ARGV[4] and ARGV[5] their new values, ARGV[6] the number
+// of sorted sets to remove the player from, ARGV[7] the current epoch
+// timestamp, and the rest of ARGV the scores for the sorted sets to add the
+// player to, in the same order.  It returns -1 if the player doesn't exist,
+// 0, changing nothing, if the properties or IndexedField have changed
+// since they were read, and 1 otherwise.
+var updateScript = redis.NewScript(-1, `
+local properties = redis.call('HGET', KEYS[1], 'properties')
+if not properties then
+	return -1
+end
+if properties ~= ARGV[2] or (redis.call('HGET', KEYS[1], 'OM_INDEXED') or '') ~= ARGV[3] then
+	return 0
+end
+
+redis.call('HSET', KEYS[1], 'properties', ARGV[4])
+if ARGV[3] ~= '' then
+	local removed = tonumber(ARGV[6])
+	for i = 3, removed + 2 do
+		redis.call('ZREM', KEYS[i], ARGV[1])
+	end
+	for i = removed + 3, #KEYS do
+		redis.call('ZADD', KEYS[i], ARGV[i + 5 - removed], ARGV[1])
+	end
+	redis.call('HSET', KEYS[1], 'OM_INDEXED', ARGV[5])
+end
+redis.call('ZADD', KEYS[2], 'XX', ARGV[7], ARGV[1])
+return 1
+`)
+
+// UpdatePlayer replaces the player's properties and, if the player is
+// indexed, updates the changed indices with a single script.  The script
+// only makes the changes if the player's properties and indices are still
+// the ones they were computed from, and is retried otherwise, so concurrent
+// deindexing and updates are never undone.  Players who have been deindexed
+// (or were indexed by a version that didn't record their indices) only have
+// their properties replaced.
+func (rs *RedisStateStorage) UpdatePlayer(ctx context.Context, player *pb.Player) error {
+	indices, err := playerindices.Retrieve(rs.cfg)
+	if err != nil {
+		return err
+	}
+	upLog := rhLog.WithFields(log.Fields{"playerID": player.Id})
+
+	redisConn, err := rs.pool.GetContext(ctx)
+	defer redisConn.Close()
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		fields, err := redis.Values(redisConn.Do("HMGET", player.Id, "properties", playerindices.IndexedField))
+		if err != nil {
+			return err
+		}
+		if fields[0] == nil {
+			return statestorage.ErrNotFound
+		}
+		old, _ := redis.String(fields[0], nil)
+		recorded, _ := redis.String(fields[1], nil)
+
+		set, removed := statestorage.IndexChanges(old, player.Properties, indices)
+		indexed := ""
+		if recorded != "" {
+			if indexed, err = indexedAfter(recorded, set, removed); err != nil {
+				return err
+			}
+		}
+
+		keys := redis.Args{player.Id, "OM_METADATA.accessed"}.AddFlat(removed)
+		scores := redis.Args{}
+		for attribute, value := range set {
+			keys = keys.Add(attribute)
+			scores = scores.Add(value)
+		}
+		args := redis.Args{len(keys)}.AddFlat(keys).
+			Add(player.Id, old, recorded, player.Properties, indexed, len(removed), time.Now().Unix()).
+			AddFlat(scores)
+		result, err := redis.Int(updateScript.Do(redisConn, args...))
+		if err != nil {
+			return err
+		}
+		switch result {
+		case -1:
+			return statestorage.ErrNotFound
+		case 1:
+			upLog.WithFields(log.Fields{"changed": len(set), "removed": len(removed), "indexed": recorded != ""}).Debug("Player updated")
+			return nil
+		}
+		upLog.Debug("Player modified during update, retrying")
+	}
+	return errors.New("player was modified concurrently too many times during update")
+}
+
+// indexedAfter returns the player's IndexedField once the changes to their
+// indices are made.
+func indexedAfter(recorded string, set map[string]float64, removed []string) (string, error) {
+	keys, err := playerindices.DecodeIndexed(recorded)
+	if err != nil {
+		return "", err
+	}
+	in := make(map[string]bool, len(keys)+len(set))
+	for _, key := range keys {
+		in[key] = true
+	}
+	for key := range set {
+		in[key] = true
+	}
+	for _, key := range removed {
+		delete(in, key)
+	}
+	keys = keys[:0]
+	for key := range in {
+		keys = append(keys, key)
+	}
+	return playerindices.EncodeIndexed(keys)
+}
+
+// RetrievePlayer reads the player's Redis hash.
+func (rs *RedisStateStorage) RetrievePlayer(ctx context.Context, player *pb.Player) error {
+	err := redispb.UnmarshalPlayerFromRedis(ctx, rs.pool, player)
+	if err == redis.ErrNil {
+		return statestorage.ErrNotFound
+	}
+	return err
+}
+
+// PartyField is the field of a member's hash holding the ID of the party
+// they joined.
+const PartyField = "OM_PARTY"
+
+// CreateParty WATCHes the members' hashes and the parties they joined,
+// checks the members can join, then writes and indexes the party and
+// deindexes the members in a transaction, retrying if any of them changes
+// in between.
+func (rs *RedisStateStorage) CreateParty(ctx context.Context, party *pb.Player) error {
+	ttl := rs.cfg.GetInt("redis.expirations.player")
+	cpLog := rhLog.WithFields(log.Fields{"partyID": party.Id})
+
+	redisConn, err := rs.pool.GetContext(ctx)
+	defer redisConn.Close()
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		if _, err = redisConn.Do("WATCH", redis.Args{}.AddFlat(party.Members)...); err != nil {
+			return err
+		}
+		partyErr := &statestorage.PartyError{}
+		memberKeys := make([][]string, len(party.Members))
+		for i, id := range party.Members {
+			fields, err := redis.Values(redisConn.Do("HMGET", id, "properties", playerindices.IndexedField, PartyField))
+			if err != nil {
+				redisConn.Do("UNWATCH")
+				return err
+			}
+			if fields[0] == nil {
+				partyErr.Missing = append(partyErr.Missing, id)
+				continue
+			}
+			properties, _ := redis.String(fields[0], nil)
+			indexed, _ := redis.String(fields[1], nil)
+			if joined, _ := redis.String(fields[2], nil); joined != "" {
+				// Membership of a party that has since been deleted doesn't count.
+				if _, err = redisConn.Do("WATCH", joined); err != nil {
+					return err
+				}
+				exists, err := redis.Bool(redisConn.Do("EXISTS", joined))
+				if err != nil {
+					redisConn.Do("UNWATCH")
+					return err
+				}
+				if exists {
+					partyErr.InParty = append(partyErr.InParty, id)
+					continue
+				}
+			}
+			if memberKeys[i], err = playerindices.IndexedKeys(rs.cfg, indexed, properties); err != nil {
+				redisConn.Do("UNWATCH")
+				return err
+			}
+		}
+		if len(partyErr.Missing) > 0 || len(partyErr.InParty) > 0 {
+			redisConn.Do("UNWATCH")
+			return partyErr
+		}
+
+		redisConn.Send("MULTI")
+		for i, id := range party.Members {
+			redisConn.Send("HSET", id, PartyField, party.Id)
+			playerindices.SendDeleteKeys(redisConn, id, memberKeys[i])
+		}
+		if err := redispb.SendMarshalToRedis(redisConn, party, ttl); err != nil {
+			redisConn.Do("DISCARD")
+			return err
+		}
+		if err := playerindices.SendCreate(redisConn, rs.cfg, *party); err != nil {
+			redisConn.Do("DISCARD")
+			return err
+		}
+		reply, err := redisConn.Do("EXEC")
+		if err != nil {
+			return err
+		}
+		if reply != nil {
+			cpLog.WithFields(log.Fields{"members": len(party.Members)}).Debug("Party created")
+			return nil
+		}
+		// A nil reply means a member or their party changed after it was WATCHed.
+		cpLog.Debug("Party members modified during party creation, retrying")
+	}
+	return errors.New("party members were modified concurrently too many times during party creation")
+}
+
+// DeindexPlayer removes the player from the configured player indices.
+func (rs *RedisStateStorage) DeindexPlayer(ctx context.Context, playerID string) error {
+	return playerindices.Delete(ctx, rs.pool, rs.cfg, playerID)
+}
+
+// DeindexPlayers removes the players from the configured player indices,
+// pipelining the commands over a single connection.
+func (rs *RedisStateStorage) DeindexPlayers(ctx context.Context, playerIDs []string) []error {
+	return playerindices.DeleteMany(ctx, rs.pool, rs.cfg, playerIDs)
+}
+
+// DeletePlayer deletes the player's Redis hash, then removes the player from
+// all ignorelists and metadata indices.  Cleanup continues after a failure so
+// as little as possible is leaked; the first error encountered is returned.
+func (rs *RedisStateStorage) DeletePlayer(ctx context.Context, playerID string) error {
+	err := Delete(ctx, rs.pool, playerID)
+
+	// Delete player from all ignorelists
+	redisConn, connErr := rs.pool.GetContext(ctx)
+	defer redisConn.Close()
+	if connErr != nil {
+		return connErr
+	}
+	redisConn.Send("MULTI")
+	for il := range rs.cfg.GetStringMap("ignoreLists") {
+		ignorelist.SendRemove(redisConn, il, []string{playerID})
+	}
+	_, ilErr := redisConn.Do("EXEC")
+	if ilErr != nil {
+		rhLog.WithFields(log.Fields{
+			"error":    ilErr.Error(),
+			"playerID": playerID,
+		}).Error("Error de-indexing player from ignorelists")
+		if err == nil {
+			err = ilErr
+		}
+	}
+
+	playerindices.DeleteMeta(ctx, rs.pool, playerID)
+	return err
+}
+
+// UpdatePlayersField sets a field in multiple player hashes.
+func (rs *RedisStateStorage) UpdatePlayersField(ctx context.Context, field string, values map[string]string) error {
+	return UpdateMultiFields(ctx, rs.pool, values, field)
+}
+
+// TouchPlayers updates the 'accessed' metadata index of the players in it,
+// with a single script.
+func (rs *RedisStateStorage) TouchPlayers(ctx context.Context, playerIDs []string) []error {
+	errs := make([]error, len(playerIDs))
+	if len(playerIDs) == 0 {
+		return errs
+	}
+	touched, err := playerindices.TouchExisting(ctx, rs.pool, playerIDs)
+	for i := range errs {
+		switch {
+		case err != nil:
+			errs[i] = err
+		case !touched[i]:
+			errs[i] = statestorage.ErrNotFound
+		}
+	}
+	return errs
+}
+
+// transitionScript sets the status of the players whose current status is
+// one of those allowed.  KEYS are the players' hashes, ARGV[1] the new status
+// and the rest of ARGV the allowed current statuses.  Players whose hash
+// doesn't exist are left out.  It returns the players whose status was set.
+var transitionScript = redis.NewScript(-1, `
+local changed = {}
+for _, key in ipairs(KEYS) do
+	if redis.call('EXISTS', key) == 1 then
+		local current = redis.call('HGET', key, 'status') or ''
+		for i = 2, #ARGV do
+			if current == ARGV[i] then
+				redis.call('HSET', key, 'status', ARGV[1])
+				changed[#changed + 1] = key
+				break
+			end
+		end
+	end
+end
+return changed
+`)
+
+// TransitionPlayers sets the status of the players allowed to move to it
+// with a single script.
+func (rs *RedisStateStorage) TransitionPlayers(ctx context.Context, to string, from []string, playerIDs []string) ([]string, error) {
+	if len(playerIDs) == 0 {
+		return nil, nil
+	}
+	redisConn, err := rs.pool.GetContext(ctx)
+	defer redisConn.Close()
+	if err != nil {
+		return nil, err
+	}
+	args := redis.Args{len(playerIDs)}.AddFlat(playerIDs).Add(to).AddFlat(from)
+	return redis.Strings(transitionScript.Do(redisConn, args...))
+}
+
+// RetrievePlayersField reads a field of multiple player hashes.
+func (rs *RedisStateStorage) RetrievePlayersField(ctx context.Context, field string, playerIDs []string) (map[string]string, error) {
+	return RetrieveMultiFields(ctx, rs.pool, playerIDs, field)
+}
+
+// DeletePlayersField deletes a field from multiple player hashes.
+func (rs *RedisStateStorage) DeletePlayersField(ctx context.Context, field string, playerIDs []string) error {
+	return DeleteMultiFields(ctx, rs.pool, playerIDs, field)
+}
+
+// WatchPlayer watches the player's Redis hash for updates.
+func (rs *RedisStateStorage) WatchPlayer(bo backoff.BackOffContext, player pb.Player) <-chan pb.Player {
+	return redispb.PlayerWatcher(bo, rs.pool, rs.notifier, player)
+}
+
+// CreateMatchObject writes the match object to a Redis hash.
+func (rs *RedisStateStorage) CreateMatchObject(ctx context.Context, mo *pb.MatchObject) error {
+	return redispb.MarshalToRedis(ctx, rs.pool, mo, rs.cfg.GetInt("redis.expirations.matchobject"))
+}
+
+// SubmitProposal writes the match object and queues it with a single
+// script.
+func (rs *RedisStateStorage) SubmitProposal(ctx context.Context, mo *pb.MatchObject, il string, queue string, counter string, playerIDs []string, strict bool, since int64) (bool, error) {
+	return SubmitProposal(ctx, rs.pool, mo, rs.cfg.GetInt("redis.expirations.matchobject"), il, queue, counter, playerIDs, strict, since)
+}
+
+// RetrieveMatchObject reads the match object's Redis hash.
+func (rs *RedisStateStorage) RetrieveMatchObject(ctx context.Context, mo *pb.MatchObject) error {
+	return redispb.UnmarshalFromRedis(ctx, rs.pool, mo)
+}
+
+// DeleteMatchObject deletes the match object's Redis hash and its submitted
+// marker.
+func (rs *RedisStateStorage) DeleteMatchObject(ctx context.Context, id string) error {
+	return Delete(ctx, rs.pool, id, SubmittedKey(id))
+}
+
+// WatchMatchObject watches Redis until the match object exists.
+func (rs *RedisStateStorage) WatchMatchObject(bo backoff.BackOffContext, mo pb.MatchObject) <-chan pb.MatchObject {
+	return redispb.Watcher(bo, rs.pool, rs.notifier, mo)
+}
+
+// CountIndexRange runs a ZCOUNT on the filter's attribute index.  For string
+// and tags indices, it counts the union of the sorted sets for the filter's
+// values.
+func (rs *RedisStateStorage) CountIndexRange(ctx context.Context, filter *pb.Filter) (int64, error) {
+	// Redis commands can't be interrupted, so don't start one for a
+	// request that has already been cancelled.
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	redisConn, err := rs.pool.GetContext(ctx)
+	defer redisConn.Close()
+	if err != nil {
+		return 0, err
+	}
+	index := statestorage.FindIndex(rs.cfg, filter.Attribute)
+	if !index.ByValue() {
+		return redis.Int64(redisConn.Do("ZCOUNT", index.Attribute, score(statestorage.FilterMin(index, filter)), score(statestorage.FilterMax(index, filter))))
+	}
+
+	keys := statestorage.FilterKeys(index, filter)
+	switch len(keys) {
+	case 0:
+		return 0, nil
+	case 1:
+		return redis.Int64(redisConn.Do("ZCARD", keys[0]))
+	}
+	redisConn.Send("MULTI")
+	redisConn.Send("ZUNIONSTORE", redis.Args{unionKey, len(keys)}.AddFlat(keys)...)
+	redisConn.Send("DEL", unionKey)
+	replies, err := redis.Values(redisConn.Do("EXEC"))
+	if err != nil {
+		return 0, err
+	}
+	return redis.Int64(replies[0], nil)
+}
+
+// RetrieveIndexRange runs a ZRANGEBYSCORE on the filter's attribute index.
+// For string and tags indices, it pages through the union of the sorted sets
+// for the filter's values.
+func (rs *RedisStateStorage) RetrieveIndexRange(ctx context.Context, filter *pb.Filter, offset int, count int) (map[string]float64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	redisConn, err := rs.pool.GetContext(ctx)
+	defer redisConn.Close()
+	if err != nil {
+		return nil, err
+	}
+	index := statestorage.FindIndex(rs.cfg, filter.Attribute)
+	if !index.ByValue() {
+		return float64Map(redisConn.Do("ZRANGEBYSCORE", index.Attribute, score(statestorage.FilterMin(index, filter)), score(statestorage.FilterMax(index, filter)), "WITHSCORES", "LIMIT", offset, count))
+	}
+
+	keys := statestorage.FilterKeys(index, filter)
+	switch len(keys) {
+	case 0:
+		return map[string]float64{}, nil
+	case 1:
+		return float64Map(redisConn.Do("ZRANGEBYSCORE", keys[0], "-inf", "+inf", "WITHSCORES", "LIMIT", offset, count))
+	}
+	// The union is built, read and deleted in one transaction, so no other
+	// client ever sees it.
+	redisConn.Send("MULTI")
+	redisConn.Send("ZUNIONSTORE", redis.Args{unionKey, len(keys)}.AddFlat(keys).Add("AGGREGATE", "MIN")...)
+	redisConn.Send("ZRANGEBYSCORE", unionKey, "-inf", "+inf", "WITHSCORES", "LIMIT", offset, count)
+	redisConn.Send("DEL", unionKey)
+	replies, err := redis.Values(redisConn.Do("EXEC"))
+	if err != nil {
+		return nil, err
+	}
+	return float64Map(replies[1], nil)
+}
+
+// unionKey is the temporary sorted set string and tags filters with more
+// than one value are unioned into.
+const unionKey = "OM_FILTER.union"
+
+// score formats a filter bound for ZRANGEBYSCORE, where infinity is written
+// '+inf'.  https://redis.io/commands/zrangebyscore
+func score(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+inf"
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// float64Map converts a WITHSCORES reply to a map of member to score.
+func float64Map(reply interface{}, err error) (map[string]float64, error) {
+	values, err := redis.Strings(reply, err)
+	if err != nil {
+		return nil, err
+	}
+	if len(values)%2 != 0 {
+		return nil, errors.New("float64Map expects even number of values result")
+	}
+	m := make(map[string]float64, len(values)/2)
+	for i := 0; i < len(values); i += 2 {
+		if m[values[i]], err = strconv.ParseFloat(values[i+1], 64); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// RetrieveIndexRegistry reads the player index registry hash.
+func (rs *RedisStateStorage) RetrieveIndexRegistry(ctx context.Context) ([]*pb.PlayerIndex, error) {
+	return playerindices.RetrieveRegistry(ctx, rs.pool)
+}
+
+// UpdateIndexRegistry updates an entry of the player index registry hash in
+// a transaction.
+func (rs *RedisStateStorage) UpdateIndexRegistry(ctx context.Context, attribute string, update func(entry *pb.PlayerIndex) (*pb.PlayerIndex, error)) (bool, error) {
+	return playerindices.UpdateRegistry(ctx, rs.pool, attribute, update)
+}
+
+// IndexPlayer adds the player to the given indices.
+func (rs *RedisStateStorage) IndexPlayer(ctx context.Context, player *pb.Player, indices []statestorage.Index) error {
+	return playerindices.Add(ctx, rs.pool, *player, indices)
+}
+
+// DeleteIndex deletes the index's sorted sets.
+func (rs *RedisStateStorage) DeleteIndex(ctx context.Context, index statestorage.Index) error {
+	return playerindices.Drop(ctx, rs.pool, index)
+}
+
+// AddToIgnoreList adds the players to the ignorelist sorted set.
+func (rs *RedisStateStorage) AddToIgnoreList(ctx context.Context, il string, playerIDs []string) error {
+	redisConn, err := rs.pool.GetContext(ctx)
+	defer redisConn.Close()
+	if err != nil {
+		return err
+	}
+	return ignorelist.Add(redisConn, il, playerIDs)
+}
+
+// RemoveFromIgnoreList removes the players from the ignorelist sorted set.
+func (rs *RedisStateStorage) RemoveFromIgnoreList(ctx context.Context, il string, playerIDs []string) error {
+	redisConn, err := rs.pool.GetContext(ctx)
+	defer redisConn.Close()
+	if err != nil {
+		return err
+	}
+	return ignorelist.Remove(redisConn, il, playerIDs)
+}
+
+// MoveIgnoredPlayers moves players between ignorelist sorted sets.
+func (rs *RedisStateStorage) MoveIgnoredPlayers(ctx context.Context, playerIDs []string, src string, dest string) error {
+	return ignorelist.Move(ctx, rs.pool, playerIDs, src, dest)
+}
+
+// RetrieveIgnoreList runs a ZRANGEBYSCORE on the ignorelist sorted set.
+func (rs *RedisStateStorage) RetrieveIgnoreList(ctx context.Context, il string, from int64, until int64) ([]string, error) {
+	redisConn, err := rs.pool.GetContext(ctx)
+	defer redisConn.Close()
+	if err != nil {
+		return nil, err
+	}
+	return redis.Strings(redisConn.Do("ZRANGEBYSCORE", il, from, until))
+}
+
+// LeasePlayers adds the players to the ignorelist sorted set under the
+// lease, with a single script.
+func (rs *RedisStateStorage) LeasePlayers(ctx context.Context, il string, leaseID string, playerIDs []string) error {
+	return ignorelist.Lease(ctx, rs.pool, il, leaseID, playerIDs)
+}
+
+// ReleaseLease removes the players the lease owns from the ignorelist sorted
+// set, with a single script.
+func (rs *RedisStateStorage) ReleaseLease(ctx context.Context, il string, leaseID string) ([]string, error) {
+	return ignorelist.Release(ctx, rs.pool, il, leaseID)
+}
+
+// RetrieveLeases runs a ZRANGEBYSCORE on the ignorelist's leases sorted set.
+func (rs *RedisStateStorage) RetrieveLeases(ctx context.Context, il string, until int64) ([]string, error) {
+	return ignorelist.RetrieveLeases(ctx, rs.pool, il, until)
+}
+
+// PushQueue runs a SADD on the queue set.
+func (rs *RedisStateStorage) PushQueue(ctx context.Context, queue string, value string) error {
+	_, err := Update(ctx, rs.pool, queue, value)
+	return err
+}
+
+// PopQueue runs a SPOP on the queue set.
+func (rs *RedisStateStorage) PopQueue(ctx context.Context, queue string, count int) ([]string, error) {
+	redisConn, err := rs.pool.GetContext(ctx)
+	defer redisConn.Close()
+	if err != nil {
+		return nil, err
+	}
+	return redis.Strings(redisConn.Do("SPOP", queue, count))
+}
+
+// CountQueue runs a SCARD on the queue set.
+func (rs *RedisStateStorage) CountQueue(ctx context.Context, queue string) (int, error) {
+	return Count(ctx, rs.pool, queue)
+}
+
+// IncrementCounter runs an INCR on the counter key.
+func (rs *RedisStateStorage) IncrementCounter(ctx context.Context, key string) (int64, error) {
+	return redis.Int64(Increment(ctx, rs.pool, key))
+}
+
+// DecrementCounter runs a DECR on the counter key.
+func (rs *RedisStateStorage) DecrementCounter(ctx context.Context, key string) (int64, error) {
+	return redis.Int64(Decrement(ctx, rs.pool, key))
+}
+
+// RetrieveCounter runs a GET on the counter key.
+func (rs *RedisStateStorage) RetrieveCounter(ctx context.Context, key string) (int64, error) {
+	redisConn, err := rs.pool.GetContext(ctx)
+	defer redisConn.Close()
+	if err != nil {
+		return 0, err
+	}
+	v, err := redis.Int64(redisConn.Do("GET", key))
+	if err == redis.ErrNil {
+		return 0, statestorage.ErrNotFound
+	}
+	return v, err
+}
+
+// DeleteCounter deletes the counter key.
+func (rs *RedisStateStorage) DeleteCounter(ctx context.Context, key string) error {
+	return Delete(ctx, rs.pool, key)
+}
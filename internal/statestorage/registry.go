@@ -0,0 +1,332 @@
+/*
+Copyright 2019 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statestorage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// The player index registry is the list of player indices, stored in state
+// storage so it can be changed at run-time through the Admin API.  Every API
+// calls WatchIndices, which adds the indices in its playerIndices config
+// that have never been registered, then reloads the registry periodically.
+// Once it has been loaded, Indices and PreviousIndices return the registry's
+// indices instead of the config's.
+//
+// Retired indices stay in the registry.  Players are still removed from them
+// when deindexed until their sorted sets are deleted, indexRegistry.gcDelay
+// seconds after they were retired.
+
+var (
+	// Logrus structured logging setup
+	irLogFields = log.Fields{
+		"app":       "openmatch",
+		"component": "statestorage",
+	}
+	irLog = log.WithFields(irLogFields)
+
+	// registries holds the most recently loaded registry for each config;
+	// there is normally one config per process.
+	registries sync.Map // *viper.Viper -> *registry
+	// watching holds the configs WatchIndices has been called with, so the
+	// components sharing a config in a single process share one watcher.
+	watching sync.Map // *viper.Viper -> struct{}
+)
+
+// Defaults for the indexRegistry config, in seconds.
+const (
+	defaultRegistryInterval = 10
+	defaultRegistryGCDelay  = 60
+)
+
+// registry is a snapshot of the index registry.
+type registry struct {
+	current []Index
+	retired []Index
+}
+
+// registryDuration reads an indexRegistry config value in seconds.
+func registryDuration(cfg *viper.Viper, key string, def int) time.Duration {
+	if v := cfg.GetInt("indexRegistry." + key); v > 0 {
+		return time.Duration(v) * time.Second
+	}
+	return time.Duration(def) * time.Second
+}
+
+// IndexFromPB converts a registry entry to an Index.  An empty type is an
+// int index.
+func IndexFromPB(entry *pb.PlayerIndex) (Index, error) {
+	if entry.Type == "" {
+		return ParseIndex(entry.Attribute)
+	}
+	return ParseIndex(entry.Attribute + ":" + entry.Type)
+}
+
+// LoadIndices reads the registry from state storage and makes it the set of
+// indices returned for the config.
+func LoadIndices(ctx context.Context, cfg *viper.Viper, store Service) ([]*pb.PlayerIndex, error) {
+	entries, err := store.RetrieveIndexRegistry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r := &registry{}
+	for _, entry := range entries {
+		index, err := IndexFromPB(entry)
+		if err != nil {
+			irLog.WithFields(log.Fields{"error": err.Error()}).Error("Skipping malformed index registry entry")
+			continue
+		}
+		switch {
+		case entry.Retired == 0:
+			r.current = append(r.current, index)
+		case entry.Collected == 0:
+			r.retired = append(r.retired, index)
+		}
+	}
+	registries.Store(cfg, r)
+	return entries, nil
+}
+
+// WatchIndices registers the indices in the config that have never been
+// registered, then reloads the registry and deletes the sorted sets of
+// retired indices every indexRegistry.interval seconds until the context is
+// cancelled.  Only the first call for a config does anything.
+func WatchIndices(ctx context.Context, cfg *viper.Viper, store Service) {
+	if _, loaded := watching.LoadOrStore(cfg, struct{}{}); loaded {
+		return
+	}
+
+	indices, err := Indices(cfg)
+	if err != nil {
+		irLog.WithFields(log.Fields{"error": err.Error()}).Error("No player indices configured")
+	}
+	for _, index := range indices {
+		entry := &pb.PlayerIndex{Attribute: index.Attribute, Type: string(index.Type), Added: time.Now().Unix()}
+		added, err := store.UpdateIndexRegistry(ctx, index.Attribute, func(current *pb.PlayerIndex) (*pb.PlayerIndex, error) {
+			if current != nil {
+				return nil, nil
+			}
+			return entry, nil
+		})
+		if err != nil {
+			irLog.WithFields(log.Fields{"error": err.Error(), "attribute": index.Attribute}).Error("Failed to register index")
+			continue
+		}
+		if added {
+			irLog.WithFields(log.Fields{"attribute": index.Attribute, "type": index.Type}).Info("Registered index from config")
+			go Backfill(ctx, cfg, store, index)
+		}
+	}
+
+	interval := registryDuration(cfg, "interval", defaultRegistryInterval)
+	for {
+		if entries, err := LoadIndices(ctx, cfg, store); err != nil {
+			irLog.WithFields(log.Fields{"error": err.Error()}).Error("Failed to load index registry")
+		} else {
+			collectIndices(ctx, cfg, store, entries)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// collectIndices deletes the sorted sets of indices that were retired more
+// than indexRegistry.gcDelay seconds ago.  Each index is marked as collected
+// before its sorted sets are deleted, so it can't be added again in between.
+func collectIndices(ctx context.Context, cfg *viper.Viper, store Service, entries []*pb.PlayerIndex) {
+	cutoff := time.Now().Add(-registryDuration(cfg, "gcDelay", defaultRegistryGCDelay)).Unix()
+	for _, entry := range entries {
+		if entry.Retired == 0 || entry.Collected != 0 || entry.Retired > cutoff {
+			continue
+		}
+		index, err := IndexFromPB(entry)
+		if err != nil {
+			continue
+		}
+		ciLog := irLog.WithFields(log.Fields{"attribute": index.Attribute})
+
+		// Only collect the entry that was read, not one added since.
+		retired := entry.Retired
+		setCollected := func(collected int64) (bool, error) {
+			return store.UpdateIndexRegistry(ctx, entry.Attribute, func(current *pb.PlayerIndex) (*pb.PlayerIndex, error) {
+				if current == nil || current.Retired != retired || (current.Collected == 0) == (collected == 0) {
+					return nil, nil
+				}
+				current.Collected = collected
+				return current, nil
+			})
+		}
+		marked, err := setCollected(time.Now().Unix())
+		if err != nil || !marked {
+			if err != nil {
+				ciLog.WithFields(log.Fields{"error": err.Error()}).Error("Failed to record retired index deletion")
+			}
+			continue
+		}
+		if err := store.DeleteIndex(ctx, index); err != nil {
+			ciLog.WithFields(log.Fields{"error": err.Error()}).Error("Failed to delete retired index")
+			// Try again on the next pass.
+			if _, err := setCollected(0); err != nil {
+				ciLog.WithFields(log.Fields{"error": err.Error()}).Error("Failed to reset retired index deletion")
+			}
+			continue
+		}
+		ciLog.Info("Deleted retired index")
+	}
+}
+
+// ErrIndexTypeConflict is returned by AddIndex when the attribute is already
+// registered with another type, and its sorted sets haven't been deleted.
+var ErrIndexTypeConflict = errors.New("index is registered with a different type; retire it and wait for its sorted sets to be deleted first")
+
+// AddIndex adds the index to the registry and backfills it once every API
+// has reloaded the registry.  An index that was retired and deleted is
+// replaced, and one that was retired but not yet deleted is reactivated if
+// the type is the same.  Adding an index that is already active with the
+// same type does nothing.
+func AddIndex(ctx context.Context, cfg *viper.Viper, store Service, index Index) error {
+	if strings.HasPrefix(index.Attribute, "OM_METADATA") {
+		return errors.New("metadata indices can't be registered")
+	}
+	added, err := store.UpdateIndexRegistry(ctx, index.Attribute, func(current *pb.PlayerIndex) (*pb.PlayerIndex, error) {
+		if current != nil && current.Collected == 0 {
+			registered, err := IndexFromPB(current)
+			if err == nil && registered.Type != index.Type {
+				return nil, ErrIndexTypeConflict
+			}
+			if current.Retired == 0 {
+				return nil, nil
+			}
+		}
+		return &pb.PlayerIndex{Attribute: index.Attribute, Type: string(index.Type), Added: time.Now().Unix()}, nil
+	})
+	if err != nil || !added {
+		return err
+	}
+	if _, err := LoadIndices(ctx, cfg, store); err != nil {
+		return err
+	}
+	go Backfill(context.Background(), cfg, store, index)
+	return nil
+}
+
+// RetireIndex marks the index as retired in the registry.  It returns
+// ErrNotFound if the index isn't registered or is already retired.
+func RetireIndex(ctx context.Context, cfg *viper.Viper, store Service, attribute string) error {
+	_, err := store.UpdateIndexRegistry(ctx, attribute, func(current *pb.PlayerIndex) (*pb.PlayerIndex, error) {
+		if current == nil || current.Retired != 0 {
+			return nil, ErrNotFound
+		}
+		current.Retired = time.Now().Unix()
+		return current, nil
+	})
+	if err != nil {
+		return err
+	}
+	_, err = LoadIndices(ctx, cfg, store)
+	return err
+}
+
+// Backfill waits until every API has reloaded the registry and so indexes
+// new players under the index, then adds the queued and proposed players
+// already in state storage to it; proposed players are back in the pool if
+// their proposal is rejected.  Players are paged through by creation time
+// rather than by offset, so players deleted meanwhile don't cause others to
+// be skipped.
+func Backfill(ctx context.Context, cfg *viper.Viper, store Service, index Index) {
+	bfLog := irLog.WithFields(log.Fields{"attribute": index.Attribute})
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(registryDuration(cfg, "interval", defaultRegistryInterval)):
+	}
+
+	pageSize := cfg.GetInt("redis.queryArgs.count")
+	if pageSize <= 0 {
+		pageSize = 10000
+	}
+	// Each page starts at the creation time of the last player of the
+	// previous one; the players already seen at that time are skipped.
+	backfilled := map[string]bool{
+		pb.PlayerStatus_QUEUED.String():   true,
+		pb.PlayerStatus_PROPOSED.String(): true,
+	}
+	created := &pb.Filter{Attribute: "OM_METADATA.created"}
+	seen := make(map[string]bool)
+	count := 0
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		page, err := store.RetrieveIndexRange(ctx, created, 0, pageSize+len(seen))
+		if err != nil {
+			bfLog.WithFields(log.Fields{"error": err.Error()}).Error("Failed to backfill index")
+			return
+		}
+
+		last := created.Minv
+		for _, score := range page {
+			if int64(score) > last {
+				last = int64(score)
+			}
+		}
+		atLast := make(map[string]bool)
+		unseen := 0
+		for id, score := range page {
+			if int64(score) == last {
+				atLast[id] = true
+			}
+			if seen[id] {
+				continue
+			}
+			unseen++
+			player := &pb.Player{Id: id}
+			if err := store.RetrievePlayer(ctx, player); err != nil || !backfilled[player.Status] {
+				continue
+			}
+			if err := store.IndexPlayer(ctx, player, []Index{index}); err != nil {
+				bfLog.WithFields(log.Fields{"error": err.Error(), "playerID": id}).Error("Failed to backfill player")
+				continue
+			}
+			count++
+		}
+		if unseen < pageSize {
+			break
+		}
+		if last != created.Minv {
+			seen = atLast
+		} else {
+			for id := range atLast {
+				seen[id] = true
+			}
+		}
+		created.Minv = last
+	}
+	bfLog.WithFields(log.Fields{"count": count}).Info("Index backfilled")
+}
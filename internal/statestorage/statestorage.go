@@ -0,0 +1,295 @@
+/*
+Package statestorage defines the interface Open Match components use to
+read and write players, match objects, player indices, ignorelists, queues and
+counters, independent of the backing database.
+
+Copyright 2019 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+package statestorage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	"github.com/cenkalti/backoff"
+	"github.com/golang/protobuf/proto"
+	"github.com/spf13/viper"
+)
+
+// ErrNotFound is returned when the requested key does not exist in state storage.
+var ErrNotFound = errors.New("key not found in state storage")
+
+// ClaimError is returned by SubmitProposal in strict mode when some of the
+// proposal's players are already claimed by another proposal or match.
+type ClaimError struct {
+	PlayerIDs []string
+}
+
+func (e *ClaimError) Error() string {
+	return fmt.Sprintf("players already claimed: %v", strings.Join(e.PlayerIDs, ","))
+}
+
+// ErrResultsConflict is returned by SubmitProposal when different results
+// were already submitted under the same ID.
+var ErrResultsConflict = errors.New("different results already submitted with this id")
+
+// ResultsDigest returns a digest of MMF results and the players they claim.
+// SubmitProposal records it to tell a retry of a submission from different
+// results reusing its ID.
+func ResultsDigest(mo *pb.MatchObject, playerIDs []string) (string, error) {
+	data, err := proto.Marshal(mo)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write(data)
+	for _, id := range playerIDs {
+		h.Write([]byte{0})
+		h.Write([]byte(id))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// PartyError is returned by CreateParty when some of the members can't join
+// the party, because they don't exist or are already in another party.
+type PartyError struct {
+	Missing []string
+	InParty []string
+}
+
+func (e *PartyError) Error() string {
+	var reasons []string
+	if len(e.Missing) > 0 {
+		reasons = append(reasons, fmt.Sprintf("party members not found: %v", strings.Join(e.Missing, ",")))
+	}
+	if len(e.InParty) > 0 {
+		reasons = append(reasons, fmt.Sprintf("party members already in a party: %v", strings.Join(e.InParty, ",")))
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// Service is the state storage interface used by the Open Match APIs and the
+// matchmaker function orchestrator.  The Redis implementation lives in
+// internal/statestorage/redis; any other backend just needs to satisfy this
+// interface.
+//
+// All calls are expected to be concurrent-safe.
+type Service interface {
+	// Player records.
+
+	// CreatePlayer writes the player record and adds the player to all
+	// configured player indices.
+	CreatePlayer(ctx context.Context, player *pb.Player) error
+	// CreatePlayers does the same as CreatePlayer for many players in as few
+	// round trips to state storage as possible.  The returned slice holds
+	// the error for each player, or nil if it was created.
+	CreatePlayers(ctx context.Context, players []*pb.Player) []error
+	// UpdatePlayer replaces the properties of an existing player and updates
+	// the player indices whose values changed, atomically.  Metadata indices
+	// are not reset, so the player keeps their original creation time.  A
+	// player who has been deindexed (for example, because they were matched)
+	// is not added back to the indices.  Returns ErrNotFound if the player
+	// doesn't exist.
+	UpdatePlayer(ctx context.Context, player *pb.Player) error
+	// CreateParty writes and indexes the party's player record (see
+	// PartyPlayer) and deindexes its members, atomically.  A *PartyError is
+	// returned, and nothing changed, if any of the members doesn't exist or
+	// is in another party that still exists.
+	CreateParty(ctx context.Context, party *pb.Player) error
+	// RetrievePlayer fills in the player record for the ID in the input
+	// player.  Returns ErrNotFound if the player doesn't exist.
+	RetrievePlayer(ctx context.Context, player *pb.Player) error
+	// DeindexPlayer removes the player from the player indices they were
+	// added to without deleting the player record.
+	DeindexPlayer(ctx context.Context, playerID string) error
+	// DeindexPlayers does the same as DeindexPlayer for many players in as
+	// few round trips to state storage as possible.  The returned slice
+	// holds the error for each player, or nil if it was deindexed.
+	DeindexPlayers(ctx context.Context, playerIDs []string) []error
+	// DeletePlayer removes the player record, the player's metadata indices,
+	// and the player's entries in all ignorelists.
+	DeletePlayer(ctx context.Context, playerID string) error
+	// TouchPlayers updates the 'accessed' metadata timestamp of multiple
+	// players in as few round trips to state storage as possible.  The
+	// returned slice holds the error for each player: ErrNotFound if the
+	// player isn't in state storage, or nil if it was touched.
+	TouchPlayers(ctx context.Context, playerIDs []string) []error
+	// UpdatePlayersField sets one field of multiple player records.  The
+	// 'values' map is keyed by player ID.
+	UpdatePlayersField(ctx context.Context, field string, values map[string]string) error
+	// TransitionPlayers sets the status of each existing player whose
+	// current status is one of 'from' ("" matches players without a status)
+	// to 'to', checking and writing each player atomically.  Players who
+	// don't exist are left out rather than created.  It returns the IDs of
+	// the players whose status was set.
+	TransitionPlayers(ctx context.Context, to string, from []string, playerIDs []string) ([]string, error)
+	// RetrievePlayersField reads one field of multiple player records, keyed
+	// by player ID.  Players without the field are left out.
+	RetrievePlayersField(ctx context.Context, field string, playerIDs []string) (map[string]string, error)
+	// DeletePlayersField clears one field of multiple player records.
+	DeletePlayersField(ctx context.Context, field string, playerIDs []string) error
+	// WatchPlayer streams updates to the player's assignment, status and
+	// error fields until the backoff is exhausted or its context is
+	// cancelled.
+	WatchPlayer(bo backoff.BackOffContext, player pb.Player) <-chan pb.Player
+
+	// MatchObject records.
+
+	// CreateMatchObject writes the match object under its ID.
+	CreateMatchObject(ctx context.Context, mo *pb.MatchObject) error
+	// RetrieveMatchObject fills in the match object for the ID in the input
+	// match object.  Returns ErrNotFound if it doesn't exist.
+	RetrieveMatchObject(ctx context.Context, mo *pb.MatchObject) error
+	// DeleteMatchObject removes the match object with the given ID, along
+	// with the record of any results submitted under it, so results with
+	// the same ID can be submitted again.
+	DeleteMatchObject(ctx context.Context, id string) error
+	// WatchMatchObject sends the match object on the returned channel once it
+	// exists, then closes the channel.  The channel is closed without a value
+	// if the backoff is exhausted or its context is cancelled.
+	WatchMatchObject(bo backoff.BackOffContext, mo pb.MatchObject) <-chan pb.MatchObject
+	// SubmitProposal records an MMF's results in one atomic operation: it
+	// writes the match object, leases the players to it on the ignorelist,
+	// adds its ID to the queue and decrements the counter.  A match object
+	// with an error is only written and counted.  Results already submitted
+	// under the same ID are left as they are: false is returned if they are
+	// the same results, so retries are safe, and ErrResultsConflict if they
+	// differ.  In strict mode nothing is written, and a *ClaimError is
+	// returned, if any of the players was added to the ignorelist at or
+	// after the epoch timestamp 'since' under another lease; players added
+	// before then are no longer ignored.
+	SubmitProposal(ctx context.Context, mo *pb.MatchObject, il string, queue string, counter string, playerIDs []string, strict bool, since int64) (bool, error)
+
+	// Player indices.
+
+	// CountIndexRange returns the number of players matching the filter.
+	// Numeric filters match a range of values; string and tags filters match
+	// any of the filter's values.
+	CountIndexRange(ctx context.Context, filter *pb.Filter) (int64, error)
+	// RetrieveIndexRange returns a page of players matching the filter,
+	// mapped to their value for the filtered attribute.  Players in a string
+	// or tags index are mapped to the time they were indexed.
+	RetrieveIndexRange(ctx context.Context, filter *pb.Filter, offset int, count int) (map[string]float64, error)
+
+	// Index registry.
+
+	// RetrieveIndexRegistry returns every entry in the player index registry.
+	RetrieveIndexRegistry(ctx context.Context) ([]*pb.PlayerIndex, error)
+	// UpdateIndexRegistry reads the attribute's registry entry (nil if it
+	// isn't registered) and writes the entry returned by update, atomically:
+	// if the entry changes in between, it is read again and update called
+	// again.  If update returns nil nothing is written and false is
+	// returned; an error from update is returned as it is.
+	UpdateIndexRegistry(ctx context.Context, attribute string, update func(entry *pb.PlayerIndex) (*pb.PlayerIndex, error)) (bool, error)
+	// IndexPlayer adds the player to the given indices, without changing the
+	// player's other indices.  Players who have been deindexed are left out.
+	IndexPlayer(ctx context.Context, player *pb.Player, indices []Index) error
+	// DeleteIndex deletes all of the index's sorted sets.  The registry is
+	// not changed.
+	DeleteIndex(ctx context.Context, index Index) error
+
+	// Ignorelists.
+
+	// AddToIgnoreList adds the players to the ignorelist with the current time.
+	AddToIgnoreList(ctx context.Context, il string, playerIDs []string) error
+	// RemoveFromIgnoreList removes the players from the ignorelist.
+	RemoveFromIgnoreList(ctx context.Context, il string, playerIDs []string) error
+	// MoveIgnoredPlayers moves the players from one ignorelist to another.
+	MoveIgnoredPlayers(ctx context.Context, playerIDs []string, src string, dest string) error
+	// RetrieveIgnoreList returns the players added to the ignorelist between
+	// the 'from' and 'until' epoch timestamps (inclusive).
+	RetrieveIgnoreList(ctx context.Context, il string, from int64, until int64) ([]string, error)
+	// LeasePlayers adds the players to the ignorelist on behalf of a lease,
+	// usually a proposal, which then owns their entries until another lease
+	// takes them over.
+	LeasePlayers(ctx context.Context, il string, leaseID string, playerIDs []string) error
+	// ReleaseLease removes the players whose entries the lease still owns
+	// from the ignorelist, then deletes the lease.  It returns the players
+	// removed.
+	ReleaseLease(ctx context.Context, il string, leaseID string) ([]string, error)
+	// RetrieveLeases returns the IDs of the leases on the ignorelist granted
+	// at or before the 'until' epoch timestamp.
+	RetrieveLeases(ctx context.Context, il string, until int64) ([]string, error)
+
+	// Queues.
+
+	// PushQueue adds the value to the queue.
+	PushQueue(ctx context.Context, queue string, value string) error
+	// PopQueue removes and returns up to 'count' values from the queue.
+	PopQueue(ctx context.Context, queue string, count int) ([]string, error)
+	// CountQueue returns the number of values in the queue.
+	CountQueue(ctx context.Context, queue string) (int, error)
+
+	// Counters.
+
+	// IncrementCounter increments the counter and returns the new value.
+	IncrementCounter(ctx context.Context, key string) (int64, error)
+	// DecrementCounter decrements the counter and returns the new value.
+	DecrementCounter(ctx context.Context, key string) (int64, error)
+	// RetrieveCounter returns the value of the counter, or ErrNotFound if
+	// it hasn't been set.
+	RetrieveCounter(ctx context.Context, key string) (int64, error)
+	// DeleteCounter removes the counter.
+	DeleteCounter(ctx context.Context, key string) error
+
+	// Close releases any resources held by the state storage client.
+	Close() error
+}
+
+// RetrieveIgnoreList looks up the configured name and time window of an
+// ignorelist in the 'ignoreLists' section of the config and returns the
+// players currently on it.
+//
+// Ignorelists store the epoch timestamp (in seconds) of when a player was
+// added.  For example, assume the current timestamp is 1000001000:
+//  duration is 800 and offset is 0:
+//   Ignore all players in the list with timestamps between 1000000200 - 1000001000
+//  duration is 0 and offset is 500:
+//   Ignore players in the list with timestamps between 0 - 1000000500
+func RetrieveIgnoreList(ctx context.Context, store Service, cfg *viper.Viper, il string) ([]string, error) {
+	name, from, until := IgnoreListWindow(cfg.Sub(fmt.Sprintf("ignoreLists.%v", il)), il, time.Now())
+	return store.RetrieveIgnoreList(ctx, name, from, until)
+}
+
+// IgnoreListWindow returns the state storage name of an ignorelist and the
+// range of timestamps that are currently ignored.  'ilCfg' is a viper.Sub
+// sub-tree of the config file with just the parameters for this ignorelist,
+// and may be nil if the ignorelist isn't configured.
+func IgnoreListWindow(ilCfg *viper.Viper, il string, now time.Time) (name string, from int64, until int64) {
+	// Default to all players on the list
+	name = il
+	until = now.Unix()
+	if ilCfg == nil {
+		return name, 0, until
+	}
+
+	if ilCfg.IsSet("name") && ilCfg.GetString("name") != "" {
+		name = ilCfg.GetString("name")
+	}
+	if ilCfg.IsSet("offset") && ilCfg.GetInt64("offset") > 0 {
+		until = until - ilCfg.GetInt64("offset")
+	}
+	if ilCfg.IsSet("duration") && ilCfg.GetInt64("duration") > 0 {
+		from = until - ilCfg.GetInt64("duration")
+	}
+	return name, from, until
+}
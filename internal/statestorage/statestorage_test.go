@@ -0,0 +1,126 @@
+package statestorage
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+	"github.com/spf13/viper"
+	"github.com/tidwall/gjson"
+)
+
+func TestIgnoreListWindow(t *testing.T) {
+	now := time.Unix(1000001000, 0)
+
+	cases := []struct {
+		desc      string
+		cfg       map[string]interface{}
+		wantName  string
+		wantFrom  int64
+		wantUntil int64
+	}{
+		{"not configured", nil, "proposed", 0, 1000001000},
+		{"duration", map[string]interface{}{"name": "proposed", "duration": 800}, "proposed", 1000000200, 1000001000},
+		{"offset", map[string]interface{}{"name": "OM_METADATA.accessed", "offset": 500}, "OM_METADATA.accessed", 0, 1000000500},
+		{"offset and duration", map[string]interface{}{"offset": 500, "duration": 100}, "proposed", 1000000400, 1000000500},
+	}
+
+	for _, c := range cases {
+		var ilCfg *viper.Viper
+		if c.cfg != nil {
+			ilCfg = viper.New()
+			for k, v := range c.cfg {
+				ilCfg.Set(k, v)
+			}
+		}
+		name, from, until := IgnoreListWindow(ilCfg, "proposed", now)
+		if name != c.wantName || from != c.wantFrom || until != c.wantUntil {
+			t.Errorf("%s: got (%v, %v, %v), want (%v, %v, %v)", c.desc, name, from, until, c.wantName, c.wantFrom, c.wantUntil)
+		}
+	}
+}
+
+func TestPartyPlayer(t *testing.T) {
+	cfg := viper.New()
+	cfg.Set("playerIndices", []string{"mmr.rating", "region.europe-west1"})
+	members := []*pb.Player{
+		{Id: "a", Properties: `{"mmr": {"rating": 100}}`},
+		{Id: "b", Properties: `{"mmr": {"rating": 300}, "region": {"europe-west1": 20}}`},
+	}
+	party := &pb.Party{Id: "p", Properties: `{"mode": "ctf"}`, Members: []string{"a", "b"}}
+
+	cases := []struct {
+		aggregate string
+		want      map[string]float64
+	}{
+		{"", map[string]float64{"mmr.rating": 200, "region.europe-west1": 20, "party.size": 2, "party.avg.mmr.rating": 200, "party.max.mmr.rating": 300}},
+		{"max", map[string]float64{"mmr.rating": 300, "party.avg.mmr.rating": 200}},
+		{"sum", map[string]float64{"mmr.rating": 400}},
+	}
+	for _, c := range cases {
+		cfg.Set("parties.aggregate", c.aggregate)
+		p, err := PartyPlayer(cfg, party, members)
+		if err != nil {
+			t.Fatalf("aggregate '%v': %v", c.aggregate, err)
+		}
+		if p.Id != "p" || len(p.Members) != 2 || gjson.Get(p.Properties, "mode").String() != "ctf" {
+			t.Errorf("aggregate '%v': got %v", c.aggregate, p)
+		}
+		for path, want := range c.want {
+			if got := gjson.Get(p.Properties, path).Float(); got != want {
+				t.Errorf("aggregate '%v': %v = %v, want %v", c.aggregate, path, got, want)
+			}
+		}
+	}
+
+	cfg.Set("parties.aggregate", "median")
+	if _, err := PartyPlayer(cfg, party, members); err == nil {
+		t.Error("expected an error for an unknown aggregate")
+	}
+}
+
+func TestIndexChanges(t *testing.T) {
+	indices, err := parseIndices([]string{"mmr.rating", "region.europe-west1", "mode.ctf", "map:string", "OM_METADATA.created"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	set, removed := IndexChanges(
+		`{"mmr": {"rating": 100}, "region": {"europe-west1": 10}, "map": "oasis"}`,
+		`{"mmr": {"rating": 100}, "mode": {"ctf": 1}, "map": "aleroth"}`,
+		indices)
+	if len(set) != 2 || set["mode.ctf"] != 1 || set["map=aleroth"] == 0 {
+		t.Errorf("set: got %v, want mode.ctf and map=aleroth", set)
+	}
+	sort.Strings(removed)
+	if !reflect.DeepEqual(removed, []string{"map=oasis", "region.europe-west1"}) {
+		t.Errorf("removed: got %v, want [map=oasis region.europe-west1]", removed)
+	}
+}
+
+func TestIndexValues(t *testing.T) {
+	indices, err := parseIndices([]string{"mmr.rating", "ping.us-east:float", "mode:string", "maps:tags", "role:tags"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1000, 0)
+	player := &pb.Player{Properties: `{"mmr": {"rating": 1500}, "ping": {"us-east": 32.5}, "mode": "ctf", "maps": ["oasis", "aleroth"], "role": "tank"}`}
+
+	got := IndexValues(player, indices, now)
+	want := map[string]float64{
+		"mmr.rating":   1500,
+		"ping.us-east": 32.5,
+		"mode=ctf":     1000,
+		"maps=oasis":   1000,
+		"maps=aleroth": 1000,
+		"role=tank":    1000,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err := ParseIndex("mode:enum"); err == nil {
+		t.Error("expected an error for an unknown index type")
+	}
+}
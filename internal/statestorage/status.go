@@ -0,0 +1,60 @@
+/*
+Copyright 2019 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statestorage
+
+import (
+	"context"
+
+	"github.com/GoogleCloudPlatform/open-match/internal/pb"
+)
+
+// transitions lists the statuses a player can move to each status from; ""
+// is a player without a status.  Players stay in ASSIGNED, EXPIRED and
+// CANCELLED once they get there.  See PlayerStatus in messages.proto for the
+// lifecycle.
+var transitions = map[pb.PlayerStatus][]pb.PlayerStatus{
+	pb.PlayerStatus_QUEUED:    {pb.PlayerStatus_UNSET, pb.PlayerStatus_QUEUED, pb.PlayerStatus_PROPOSED},
+	pb.PlayerStatus_PROPOSED:  {pb.PlayerStatus_UNSET, pb.PlayerStatus_QUEUED, pb.PlayerStatus_PROPOSED},
+	pb.PlayerStatus_MATCHED:   {pb.PlayerStatus_UNSET, pb.PlayerStatus_QUEUED, pb.PlayerStatus_PROPOSED, pb.PlayerStatus_MATCHED},
+	pb.PlayerStatus_ASSIGNED:  {pb.PlayerStatus_UNSET, pb.PlayerStatus_QUEUED, pb.PlayerStatus_PROPOSED, pb.PlayerStatus_MATCHED, pb.PlayerStatus_ASSIGNED},
+	pb.PlayerStatus_EXPIRED:   {pb.PlayerStatus_UNSET, pb.PlayerStatus_QUEUED, pb.PlayerStatus_PROPOSED, pb.PlayerStatus_MATCHED},
+	pb.PlayerStatus_CANCELLED: {pb.PlayerStatus_UNSET, pb.PlayerStatus_QUEUED, pb.PlayerStatus_PROPOSED, pb.PlayerStatus_MATCHED},
+}
+
+// SetPlayerStatus moves the players, and the members of any parties among
+// them, to the lifecycle status, so it is streamed to their Frontend API
+// GetUpdates calls.  Players who don't exist, or whose current status can't
+// move to the new one, are left as they are.
+func SetPlayerStatus(ctx context.Context, store Service, status pb.PlayerStatus, playerIDs []string) error {
+	if len(playerIDs) == 0 {
+		return nil
+	}
+	ids := append([]string{}, playerIDs...)
+	for _, members := range PartyMembers(ctx, store, playerIDs) {
+		ids = append(ids, members...)
+	}
+	from := make([]string, 0, len(transitions[status]))
+	for _, s := range transitions[status] {
+		if s == pb.PlayerStatus_UNSET {
+			from = append(from, "")
+		} else {
+			from = append(from, s.String())
+		}
+	}
+	_, err := store.TransitionPlayers(ctx, status.String(), from, ids)
+	return err
+}